@@ -0,0 +1,354 @@
+package mailjet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// MaxAXVariants is the maximum number of variants of an A/X test.
+const MaxAXVariants = 10
+
+// AXTestError is returned by the steps of an AXTest which cannot be made in the state of
+// the test, before any request is sent.
+type AXTestError struct {
+	TestID int64
+	Step   string
+	Reason string
+}
+
+func (e *AXTestError) Error() string {
+	return fmt.Sprintf("mailjet: A/X test %d: %s: %s", e.TestID, e.Step, e.Reason)
+}
+
+// AXTestOptions are functional options of AXTest.Wait.
+type AXTestOptions func(*axWaiter)
+
+type axWaiter struct {
+	backoff  Backoff
+	progress func(*AXResults)
+}
+
+// WithAXTestBackoff sets the schedule of the polls of the test, DefaultBackoff by default.
+func WithAXTestBackoff(backoff Backoff) AXTestOptions {
+	return func(w *axWaiter) {
+		w.backoff = backoff
+	}
+}
+
+// WithAXTestProgress sets a function called with the results of the test after each poll.
+func WithAXTestProgress(progress func(results *AXResults)) AXTestOptions {
+	return func(w *axWaiter) {
+		w.progress = progress
+	}
+}
+
+// AXVariantResult is the statistics of a variant of an A/X test, with the metrics of the
+// winner methods in percents of the delivered messages.
+type AXVariantResult struct {
+	DraftID    int64
+	Name       string
+	Statistics resources.Campaignstatistics
+	OpenRate   float64
+	ClickRate  float64
+	SpamRate   float64
+	UnsubRate  float64
+}
+
+// Rate returns the metric of a winner method. MJScore is computed by Mailjet only, and is
+// reported for the winner of the test only, by the WinnerMethod of the test.
+func (r *AXVariantResult) Rate(method resources.WinnerMethod) (float64, bool) {
+	switch method {
+	case resources.WinnerOpenRate:
+		return r.OpenRate, true
+	case resources.WinnerClickRate:
+		return r.ClickRate, true
+	case resources.WinnerSpamRate:
+		return r.SpamRate, true
+	case resources.WinnerUnsubRate:
+		return r.UnsubRate, true
+	}
+	return 0, false
+}
+
+// AXResults is the state of an A/X test and the statistics of its variants.
+type AXResults struct {
+	Test     resources.Axtesting
+	Variants []AXVariantResult
+}
+
+// Winner returns the variant selected as the winner, nil until it is selected.
+func (r *AXResults) Winner() *AXVariantResult {
+	for i := range r.Variants {
+		if r.Test.WinnerID != 0 && r.Variants[i].DraftID == int64(r.Test.WinnerID) {
+			return &r.Variants[i]
+		}
+	}
+	return nil
+}
+
+// Leader returns the variant leading by the WinnerMethod of the test so far: the highest
+// open or click rate, or the lowest spam or unsubscribe rate. It returns nil without
+// statistics, and for the MJScore method.
+func (r *AXResults) Leader() *AXVariantResult {
+	lowest := r.Test.WinnerMethod == resources.WinnerSpamRate || r.Test.WinnerMethod == resources.WinnerUnsubRate
+	var leader *AXVariantResult
+	var best float64
+	for i := range r.Variants {
+		v := &r.Variants[i]
+		rate, ok := v.Rate(r.Test.WinnerMethod)
+		if !ok || v.Statistics.DeliveredCount == 0 {
+			continue
+		}
+		if leader == nil || (lowest && rate < best) || (!lowest && rate > best) {
+			leader, best = v, rate
+		}
+	}
+	return leader
+}
+
+// AXTest is an A/X test: variants of a campaign, as campaign drafts, each sent to a fraction
+// of the list given by Percentage. In automatic mode, the best variant by the WinnerMethod of
+// the test is selected at RemainderAt; in manual mode, it is selected with SelectWinner.
+// An AXTest is not safe for concurrent use.
+type AXTest struct {
+	client *Client
+	// Test is the test as last read or written.
+	Test resources.Axtesting
+	// Variants are the campaign drafts of the test.
+	Variants []*CampaignDraft
+}
+
+// CreateAXTest creates an A/X test, without variants.
+func (c *Client) CreateAXTest(ctx context.Context, test *resources.Axtesting) (*AXTest, error) {
+	var tests []resources.Axtesting
+	err := c.Post(&FullRequest{Info: &Request{Resource: "axtesting"}, Payload: test}, &tests, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(tests) == 0 {
+		return nil, errors.New("mailjet: no A/X test created")
+	}
+	return &AXTest{client: c, Test: tests[0]}, nil
+}
+
+// AXTest reads the A/X test with this ID, with its variants.
+func (c *Client) AXTest(ctx context.Context, id int64) (*AXTest, error) {
+	t := &AXTest{client: c, Test: resources.Axtesting{ID: id}}
+	if err := t.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	var drafts []resources.Campaigndraft
+	if _, _, err := c.List("campaigndraft", &drafts, Filter("AXTesting", strconv.FormatInt(id, 10)), WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	for _, draft := range drafts {
+		variant, err := c.CampaignDraft(ctx, draft.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Variants = append(t.Variants, variant)
+	}
+	return t, nil
+}
+
+// ID returns the ID of the test.
+func (t *AXTest) ID() int64 {
+	return t.Test.ID
+}
+
+// Refresh reads the test again.
+func (t *AXTest) Refresh(ctx context.Context) error {
+	var tests []resources.Axtesting
+	if err := t.client.Get(&Request{Resource: "axtesting", ID: t.Test.ID}, &tests, WithContext(ctx)); err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("mailjet: A/X test %d not found", t.Test.ID)
+	}
+	t.Test = tests[0]
+	return nil
+}
+
+// AddVariant creates a variant of the test named name, e.g. "A", from a campaign draft
+// and its content. The list and the segmentation of the test are used unless the draft
+// sets them.
+func (t *AXTest) AddVariant(ctx context.Context, name string, draft *resources.Campaigndraft, content *resources.CampaigndraftDetailcontent) (*CampaignDraft, error) {
+	if len(t.Variants) >= MaxAXVariants {
+		return nil, &AXTestError{TestID: t.Test.ID, Step: "add variant", Reason: fmt.Sprintf("more than %d variants", MaxAXVariants)}
+	}
+	for _, v := range t.Variants {
+		if v.Draft.AXFractionName == name {
+			return nil, &AXTestError{TestID: t.Test.ID, Step: "add variant", Reason: fmt.Sprintf("variant %q already exists", name)}
+		}
+		if v.Draft.Status != CampaignDraftStatusDraft {
+			return nil, &AXTestError{TestID: t.Test.ID, Step: "add variant", Reason: "the test is scheduled"}
+		}
+	}
+
+	variant := *draft
+	variant.AXTesting = &resources.Axtesting{ID: t.Test.ID}
+	variant.AXFractionName = name
+	if variant.ContactsListID == 0 && variant.ContactsListALT == "" {
+		variant.ContactsListID = t.Test.ContactListID
+	}
+	if variant.SegmentationID == 0 && variant.SegmentationALT == "" {
+		variant.SegmentationID = t.Test.SegmentationID
+	}
+	d, err := t.client.CreateCampaignDraft(ctx, &variant)
+	if err != nil {
+		return nil, err
+	}
+	t.Variants = append(t.Variants, d)
+	if content != nil {
+		if err = d.SetContent(ctx, content); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// Schedule sends the variants at start. In automatic mode, remainder is when the winner is
+// selected and sent to the rest of the list; it is ignored in manual mode. The test needs
+// at least two variants which can each be scheduled, and a percentage of the list. When a
+// variant fails to be scheduled, the variants scheduled before it are unscheduled.
+func (t *AXTest) Schedule(ctx context.Context, start, remainder time.Time) error {
+	if len(t.Variants) < 2 {
+		return &AXTestError{TestID: t.Test.ID, Step: ActionSchedule, Reason: "at least 2 variants are needed"}
+	}
+	if t.Test.Percentage <= 0 || t.Test.Percentage > 100 {
+		return &AXTestError{TestID: t.Test.ID, Step: ActionSchedule, Reason: fmt.Sprintf("invalid percentage %g", t.Test.Percentage)}
+	}
+	fields := []string{"StartAt"}
+	if t.Test.Mode != resources.AXTestManual {
+		if t.Test.WinnerMethod == "" {
+			return &AXTestError{TestID: t.Test.ID, Step: ActionSchedule, Reason: "no WinnerMethod"}
+		}
+		if !remainder.After(start) {
+			return &AXTestError{TestID: t.Test.ID, Step: ActionSchedule, Reason: "the remainder must be sent after the variants"}
+		}
+		fields = append(fields, "RemainderAt")
+	}
+	for _, v := range t.Variants {
+		if err := v.check(ActionSchedule); err != nil {
+			return err
+		}
+	}
+
+	test := t.Test
+	test.StartAt = resources.NewRFC3339DateTime(start.UTC())
+	test.RemainderAt = resources.NewRFC3339DateTime(remainder.UTC())
+	if err := t.client.Put(&FullRequest{Info: &Request{Resource: "axtesting", ID: t.Test.ID}, Payload: test}, fields, WithContext(ctx)); err != nil {
+		return err
+	}
+	for i, v := range t.Variants {
+		if err := v.Schedule(ctx, start); err != nil {
+			err = fmt.Errorf("mailjet: scheduling variant %s: %w", v.Draft.AXFractionName, err)
+			return t.unschedule(ctx, t.Variants[:i], err)
+		}
+	}
+	return t.Refresh(ctx)
+}
+
+// unschedule unschedules the variants scheduled before the scheduling of the test failed
+// with err, so that no variant is sent alone. It returns err, with the variants left
+// scheduled when they cannot be unscheduled.
+func (t *AXTest) unschedule(ctx context.Context, variants []*CampaignDraft, err error) error {
+	var failed []string
+	for _, v := range variants {
+		if v.Draft.Status != CampaignDraftStatusProgrammed {
+			continue
+		}
+		if uerr := v.Unschedule(ctx); uerr != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", v.Draft.AXFractionName, uerr))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w; variants left scheduled: %s", err, strings.Join(failed, ", "))
+	}
+	return err
+}
+
+// Results reads the test and the statistics of its variants.
+func (t *AXTest) Results(ctx context.Context) (*AXResults, error) {
+	if err := t.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	byDraft := make(map[int64]*resources.Campaignstatistics)
+	var statistics []resources.Campaignstatistics
+	err := listPages(ctx, "campaignstatistics", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Campaignstatistics
+		count, _, err := t.client.List("campaignstatistics", &page, append(filters, Filter("AXTesting", strconv.FormatInt(t.Test.ID, 10)))...)
+		statistics = append(statistics, page...)
+		return count, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range statistics {
+		byDraft[statistics[i].NewsLetterID] = &statistics[i]
+	}
+
+	results := &AXResults{Test: t.Test}
+	for _, v := range t.Variants {
+		r := AXVariantResult{DraftID: v.ID(), Name: v.Draft.AXFractionName}
+		if s := byDraft[v.ID()]; s != nil {
+			r.Statistics = *s
+			if s.DeliveredCount > 0 {
+				delivered := float64(s.DeliveredCount)
+				r.OpenRate = 100 * float64(s.OpenedCount) / delivered
+				r.ClickRate = 100 * float64(s.ClickedCount) / delivered
+				r.SpamRate = 100 * float64(s.SpamComplaintCount) / delivered
+				r.UnsubRate = 100 * float64(s.UnsubscribedCount) / delivered
+			}
+		}
+		results.Variants = append(results.Variants, r)
+	}
+	return results, nil
+}
+
+// Wait polls the results of the test until its winner is selected, and returns them.
+// In manual mode, the winner is selected with SelectWinner.
+func (t *AXTest) Wait(ctx context.Context, options ...AXTestOptions) (*AXResults, error) {
+	waiter := &axWaiter{backoff: DefaultBackoff}
+	for _, option := range options {
+		option(waiter)
+	}
+	var results *AXResults
+	err := waiter.backoff.poll(ctx, func() (bool, error) {
+		var err error
+		if results, err = t.Results(ctx); err != nil {
+			return false, err
+		}
+		if waiter.progress != nil {
+			waiter.progress(results)
+		}
+		return results.Test.WinnerID != 0, nil
+	})
+	return results, err
+}
+
+// SelectWinner selects a variant as the winner of the test, once the variants are sent.
+func (t *AXTest) SelectWinner(ctx context.Context, draftID int64) error {
+	if t.Test.WinnerID != 0 {
+		return &AXTestError{TestID: t.Test.ID, Step: "select winner", Reason: fmt.Sprintf("variant %d already won", t.Test.WinnerID)}
+	}
+	found := false
+	for _, v := range t.Variants {
+		found = found || v.ID() == draftID
+	}
+	if !found {
+		return &AXTestError{TestID: t.Test.ID, Step: "select winner", Reason: fmt.Sprintf("campaign draft %d is not a variant", draftID)}
+	}
+	test := t.Test
+	test.WinnerID = int(draftID)
+	if err := t.client.Put(&FullRequest{Info: &Request{Resource: "axtesting", ID: t.Test.ID}, Payload: test}, []string{"WinnerID"}, WithContext(ctx)); err != nil {
+		return err
+	}
+	return t.Refresh(ctx)
+}
@@ -0,0 +1,137 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestAXTest(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	srv := fake.NewServer(fake.WithClock(clock))
+	t.Cleanup(srv.Close)
+	client := mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+	ctx := context.Background()
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	newTest := func(mode resources.AXTestMode) *mailjet.AXTest {
+		test, err := client.CreateAXTest(ctx, &resources.Axtesting{
+			Name: "Subject lines", ContactListID: lists[0], Percentage: 20, Mode: mode, WinnerMethod: resources.WinnerClickRate,
+		})
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		for _, subject := range []string{"A", "B"} {
+			draft := &resources.Campaigndraft{Locale: "en_US", Sender: "Pilot", SenderEmail: "pilot@mailjet.com", Subject: "Offer " + subject}
+			if _, err = test.AddVariant(ctx, subject, draft, &resources.CampaigndraftDetailcontent{TextPart: "Summer offers"}); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+		}
+		return test
+	}
+	seedStatistics := func(test *mailjet.AXTest, clicked ...int64) {
+		for i, v := range test.Variants {
+			_, err := srv.Seed("campaignstatistics", resources.Campaignstatistics{
+				AXTesting: &resources.Axtesting{ID: test.ID()}, NewsLetterID: v.ID(), CampaignID: v.Draft.CampaignID,
+				DeliveredCount: 100, OpenedCount: 40, ClickedCount: clicked[i],
+			})
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+		}
+	}
+
+	test := newTest(resources.AXTestAutomatic)
+	if _, err = test.AddVariant(ctx, "A", &resources.Campaigndraft{Locale: "en_US"}, nil); err == nil {
+		t.Error("Expected an error for a duplicate variant")
+	}
+	var axErr *mailjet.AXTestError
+	if err = test.Schedule(ctx, now.Add(time.Hour), now.Add(time.Hour)); !errors.As(err, &axErr) {
+		t.Errorf("Expected an AXTestError, got %v", err)
+	}
+	// A variant failing to be scheduled unschedules the variants scheduled before it.
+	srv.FailNext(http.MethodPost, fmt.Sprintf("/v3/REST/campaigndraft/%d/schedule", test.Variants[1].ID()), http.StatusServiceUnavailable)
+	if err = test.Schedule(ctx, now.Add(time.Hour), now.Add(3*time.Hour)); err == nil {
+		t.Fatal("Expected an error when a variant fails to be scheduled")
+	}
+	for _, v := range test.Variants {
+		if v.Draft.Status != mailjet.CampaignDraftStatusDraft {
+			t.Fatalf("Variant %s left in status %d", v.Draft.AXFractionName, v.Draft.Status)
+		}
+	}
+	if err = test.Schedule(ctx, now.Add(time.Hour), now.Add(3*time.Hour)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if test.Test.Status != "programmed" || test.Test.StartAt == nil {
+		t.Fatalf("Wrong test: %+v", test.Test)
+	}
+
+	advance(2 * time.Hour)
+	reread, err := client.AXTest(ctx, test.ID())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(reread.Variants) != 2 || reread.Variants[0].Draft.Status != mailjet.CampaignDraftStatusSent {
+		t.Fatalf("Variants not sent: %+v", reread.Variants)
+	}
+	seedStatistics(reread, 5, 12)
+	results, err := reread.Results(ctx)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if leader := results.Leader(); leader == nil || leader.Name != "B" || leader.ClickRate != 12 || results.Winner() != nil {
+		t.Fatalf("Wrong results: %+v", results)
+	}
+
+	advance(2 * time.Hour)
+	results, err = reread.Wait(ctx, mailjet.WithAXTestBackoff(fastBackoff))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if winner := results.Winner(); winner == nil || winner.Name != "B" || results.Test.WinnerClickRate != 12 {
+		t.Errorf("Wrong winner: %+v", results.Test)
+	}
+
+	manual := newTest(resources.AXTestManual)
+	if err = manual.Schedule(ctx, now.Add(time.Hour), time.Time{}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = manual.SelectWinner(ctx, manual.Variants[0].ID()); err == nil {
+		t.Error("Expected an error before the variants are sent")
+	}
+	advance(2 * time.Hour)
+	if _, err = manual.Results(ctx); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = manual.SelectWinner(ctx, reread.Variants[0].ID()); !errors.As(err, &axErr) {
+		t.Errorf("Expected an AXTestError, got %v", err)
+	}
+	if err = manual.SelectWinner(ctx, manual.Variants[0].ID()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if manual.Test.WinnerID != int(manual.Variants[0].ID()) || manual.Test.Status != "finished" {
+		t.Errorf("Wrong test: %+v", manual.Test)
+	}
+}
@@ -0,0 +1,320 @@
+package mailjet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Statuses of a campaign draft, as Status of resources.Campaigndraft.
+const (
+	CampaignDraftStatusAXTest     = -3
+	CampaignDraftStatusDeleted    = -2
+	CampaignDraftStatusArchived   = -1
+	CampaignDraftStatusDraft      = 0
+	CampaignDraftStatusProgrammed = 1
+	CampaignDraftStatusSent       = 2
+	CampaignDraftStatusAXTested   = 3
+	CampaignDraftStatusAXSelected = 4
+)
+
+// Actions of campaigndraft.
+const (
+	ActionDetailContent = "detailcontent"
+	ActionTest          = "test"
+	ActionSchedule      = "schedule"
+	ActionSend          = "send"
+)
+
+// Terminal statuses of a campaign, as Status of resources.Campaign, whose sending never ends.
+const (
+	CampaignStatusError     = "error"
+	CampaignStatusCancelled = "cancelled"
+)
+
+// CampaignError is returned by CampaignDraft.Wait when the campaign stops in a terminal
+// status, CampaignStatusError or CampaignStatusCancelled, before its sending ends.
+type CampaignError struct {
+	Campaign resources.Campaign
+}
+
+func (e *CampaignError) Error() string {
+	return fmt.Sprintf("mailjet: campaign %d: %s", e.Campaign.ID, e.Campaign.Status)
+}
+
+// CampaignDraftError is returned by the steps of a CampaignDraft which cannot be made
+// in the state of the draft, before any request is sent.
+type CampaignDraftError struct {
+	DraftID int64
+	// Step is the step refused: ActionDetailContent, ActionTest, ActionSchedule or ActionSend.
+	Step   string
+	Status int64
+	// Missing are the properties the step needs, e.g. "content" or "SenderEmail".
+	Missing []string
+}
+
+func (e *CampaignDraftError) Error() string {
+	if len(e.Missing) > 0 {
+		return fmt.Sprintf("mailjet: campaign draft %d: %s needs %s", e.DraftID, e.Step, strings.Join(e.Missing, ", "))
+	}
+	return fmt.Sprintf("mailjet: campaign draft %d: %s not allowed in status %d", e.DraftID, e.Step, e.Status)
+}
+
+// CampaignOptions are functional options of CampaignDraft.Wait.
+type CampaignOptions func(*campaignWaiter)
+
+type campaignWaiter struct {
+	backoff  Backoff
+	progress func(resources.Campaign)
+}
+
+// WithCampaignBackoff sets the schedule of the polls of the campaign, DefaultBackoff by default.
+func WithCampaignBackoff(backoff Backoff) CampaignOptions {
+	return func(w *campaignWaiter) {
+		w.backoff = backoff
+	}
+}
+
+// WithCampaignProgress sets a function called with the campaign after each poll.
+func WithCampaignProgress(progress func(campaign resources.Campaign)) CampaignOptions {
+	return func(w *campaignWaiter) {
+		w.progress = progress
+	}
+}
+
+// CampaignDraft walks a campaign draft through the steps of its sending: SetContent, Test,
+// then Schedule or Send, and Wait for the campaign to be sent. Each step checks the state
+// of the draft first, and returns a *CampaignDraftError when it cannot be made.
+// A CampaignDraft is not safe for concurrent use.
+type CampaignDraft struct {
+	client *Client
+	// Draft is the draft as last read or written.
+	Draft resources.Campaigndraft
+	// Content is the content of the draft, nil when it has none.
+	Content *resources.CampaigndraftDetailcontent
+}
+
+// CreateCampaignDraft creates a campaign draft.
+func (c *Client) CreateCampaignDraft(ctx context.Context, draft *resources.Campaigndraft) (*CampaignDraft, error) {
+	var drafts []resources.Campaigndraft
+	err := c.Post(&FullRequest{Info: &Request{Resource: "campaigndraft"}, Payload: draft}, &drafts, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(drafts) == 0 {
+		return nil, errors.New("mailjet: no campaign draft created")
+	}
+	return &CampaignDraft{client: c, Draft: drafts[0]}, nil
+}
+
+// CampaignDraft reads the campaign draft with this ID, with its content.
+func (c *Client) CampaignDraft(ctx context.Context, id int64) (*CampaignDraft, error) {
+	d := &CampaignDraft{client: c, Draft: resources.Campaigndraft{ID: id}}
+	if err := d.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	var contents []resources.CampaigndraftDetailcontent
+	if err := c.Get(d.request(ActionDetailContent), &contents, WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	if len(contents) > 0 && !isEmptyContent(&contents[0]) {
+		d.Content = &contents[0]
+	}
+	return d, nil
+}
+
+// ID returns the ID of the draft.
+func (d *CampaignDraft) ID() int64 {
+	return d.Draft.ID
+}
+
+// request returns the request of an action of the draft.
+func (d *CampaignDraft) request(action string) *Request {
+	return &Request{Resource: "campaigndraft", ID: d.Draft.ID, Action: action}
+}
+
+// Refresh reads the draft again.
+func (d *CampaignDraft) Refresh(ctx context.Context) error {
+	var drafts []resources.Campaigndraft
+	if err := d.client.Get(d.request(""), &drafts, WithContext(ctx)); err != nil {
+		return err
+	}
+	if len(drafts) == 0 {
+		return fmt.Errorf("mailjet: campaign draft %d not found", d.Draft.ID)
+	}
+	d.Draft = drafts[0]
+	return nil
+}
+
+// check returns a *CampaignDraftError when the draft is not a draft, or misses a property
+// needed by the step. Scheduling and sending need the content, the sender, the subject and
+// the list.
+func (d *CampaignDraft) check(step string) error {
+	if d.Draft.Status != CampaignDraftStatusDraft {
+		return &CampaignDraftError{DraftID: d.Draft.ID, Step: step, Status: d.Draft.Status}
+	}
+	var missing []string
+	if step != ActionDetailContent && d.Content == nil {
+		missing = append(missing, "content")
+	}
+	if step == ActionSchedule || step == ActionSend {
+		if d.Draft.SenderEmail == "" {
+			missing = append(missing, "SenderEmail")
+		}
+		if d.Draft.Subject == "" {
+			missing = append(missing, "Subject")
+		}
+		if d.Draft.ContactsListID == 0 && d.Draft.ContactsListALT == "" {
+			missing = append(missing, "ContactsListID")
+		}
+	}
+	if len(missing) > 0 {
+		return &CampaignDraftError{DraftID: d.Draft.ID, Step: step, Status: d.Draft.Status, Missing: missing}
+	}
+	return nil
+}
+
+// SetContent sets the content of the draft.
+func (d *CampaignDraft) SetContent(ctx context.Context, content *resources.CampaigndraftDetailcontent) error {
+	if err := d.check(ActionDetailContent); err != nil {
+		return err
+	}
+	if isEmptyContent(content) {
+		return &CampaignDraftError{DraftID: d.Draft.ID, Step: ActionDetailContent, Missing: []string{"Text-part", "Html-part", "MJMLContent"}}
+	}
+	var contents []resources.CampaigndraftDetailcontent
+	err := d.client.Post(&FullRequest{Info: d.request(ActionDetailContent), Payload: content}, &contents, WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	d.Content = content
+	return nil
+}
+
+func isEmptyContent(content *resources.CampaigndraftDetailcontent) bool {
+	return content.TextPart == "" && content.HtmlPart == "" && content.MJMLContent == ""
+}
+
+// Test sends the draft to test recipients.
+func (d *CampaignDraft) Test(ctx context.Context, recipients ...resources.Recipient) error {
+	if err := d.check(ActionTest); err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return &CampaignDraftError{DraftID: d.Draft.ID, Step: ActionTest, Missing: []string{"Recipients"}}
+	}
+	var resp []struct {
+		Status string
+	}
+	payload := &resources.CampaigndraftTest{Recipients: recipients}
+	return d.client.Post(&FullRequest{Info: d.request(ActionTest), Payload: payload}, &resp, WithContext(ctx))
+}
+
+// Schedule schedules the sending of the draft at a time.
+func (d *CampaignDraft) Schedule(ctx context.Context, at time.Time) error {
+	if err := d.check(ActionSchedule); err != nil {
+		return err
+	}
+	var resp []resources.CampaigndraftSchedule
+	payload := &resources.CampaigndraftSchedule{Date: resources.NewRFC3339DateTime(at.UTC())}
+	if err := d.client.Post(&FullRequest{Info: d.request(ActionSchedule), Payload: payload}, &resp, WithContext(ctx)); err != nil {
+		return err
+	}
+	return d.Refresh(ctx)
+}
+
+// Unschedule cancels the scheduled sending of the draft, which becomes a draft again.
+func (d *CampaignDraft) Unschedule(ctx context.Context) error {
+	if d.Draft.Status != CampaignDraftStatusProgrammed {
+		return &CampaignDraftError{DraftID: d.Draft.ID, Step: "unschedule", Status: d.Draft.Status}
+	}
+	req, err := createRequest(http.MethodDelete, buildURL(d.client.apiBase, d.request(ActionSchedule)), nil, nil, WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	d.client.Lock()
+	_, _, err = d.client.httpClient.Send(req).Call()
+	d.client.Unlock()
+	if err != nil {
+		return err
+	}
+	return d.Refresh(ctx)
+}
+
+// Send sends the draft now.
+func (d *CampaignDraft) Send(ctx context.Context) error {
+	if err := d.check(ActionSend); err != nil {
+		return err
+	}
+	var resp []struct {
+		Status string
+	}
+	if err := d.client.Post(&FullRequest{Info: d.request(ActionSend), Payload: struct{}{}}, &resp, WithContext(ctx)); err != nil {
+		return err
+	}
+	return d.Refresh(ctx)
+}
+
+// Wait polls the draft until it is sent, then its campaign until the sending ends, and
+// returns the campaign. A scheduled draft is waited for until its date. It returns a
+// *CampaignError, with the campaign, when the campaign errors or is cancelled.
+func (d *CampaignDraft) Wait(ctx context.Context, options ...CampaignOptions) (*resources.Campaign, error) {
+	waiter := &campaignWaiter{backoff: DefaultBackoff}
+	for _, option := range options {
+		option(waiter)
+	}
+	switch d.Draft.Status {
+	case CampaignDraftStatusProgrammed, CampaignDraftStatusSent:
+	default:
+		return nil, &CampaignDraftError{DraftID: d.Draft.ID, Step: "wait", Status: d.Draft.Status}
+	}
+
+	var campaign resources.Campaign
+	err := waiter.backoff.poll(ctx, func() (bool, error) {
+		if d.Draft.CampaignID == 0 {
+			if err := d.Refresh(ctx); err != nil {
+				return false, err
+			}
+			switch d.Draft.Status {
+			case CampaignDraftStatusProgrammed, CampaignDraftStatusSent:
+			default:
+				return false, &CampaignDraftError{DraftID: d.Draft.ID, Step: "wait", Status: d.Draft.Status}
+			}
+			if d.Draft.CampaignID == 0 {
+				return false, nil
+			}
+		}
+		var campaigns []resources.Campaign
+		if err := d.client.Get(&Request{Resource: "campaign", ID: d.Draft.CampaignID}, &campaigns, WithContext(ctx)); err != nil {
+			return false, err
+		}
+		if len(campaigns) > 0 {
+			campaign = campaigns[0]
+		}
+		if waiter.progress != nil {
+			waiter.progress(campaign)
+		}
+		if isTerminalCampaign(campaign.Status) {
+			return false, &CampaignError{Campaign: campaign}
+		}
+		return campaign.SendEndAt != nil && !campaign.SendEndAt.IsZero(), nil
+	})
+	var campaignErr *CampaignError
+	if errors.As(err, &campaignErr) {
+		return &campaign, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// isTerminalCampaign reports whether a campaign status is a terminal one other than sent.
+func isTerminalCampaign(status string) bool {
+	return strings.EqualFold(status, CampaignStatusError) || strings.EqualFold(status, CampaignStatusCancelled)
+}
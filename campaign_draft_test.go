@@ -0,0 +1,167 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestCampaignDraft(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ctx := context.Background()
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	draft, err := client.CreateCampaignDraft(ctx, &resources.Campaigndraft{Locale: "en_US", Title: "Summer offers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Nothing can be sent without content, sender, subject and list.
+	err = draft.Send(ctx)
+	var draftErr *mailjet.CampaignDraftError
+	if !errors.As(err, &draftErr) || !reflect.DeepEqual(draftErr.Missing, []string{"content", "SenderEmail", "Subject", "ContactsListID"}) {
+		t.Fatalf("Expected a CampaignDraftError, got %v", err)
+	}
+	if err = draft.Test(ctx, resources.Recipient{Email: "pilot@mailjet.com"}); !errors.As(err, &draftErr) {
+		t.Errorf("Expected a CampaignDraftError, got %v", err)
+	}
+
+	content := &resources.CampaigndraftDetailcontent{HtmlPart: "<h1>Summer offers</h1>", TextPart: "Summer offers"}
+	if err = draft.SetContent(ctx, content); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = draft.Test(ctx, resources.Recipient{Email: "pilot@mailjet.com"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	draft.Draft.SenderEmail = "pilot@mailjet.com"
+	draft.Draft.Sender = "Pilot"
+	draft.Draft.Subject = "Summer offers"
+	draft.Draft.ContactsListID = lists[0]
+	if err = client.Put(&mailjet.FullRequest{Info: &mailjet.Request{Resource: "campaigndraft", ID: draft.ID()}, Payload: draft.Draft}, nil); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	reread, err := client.CampaignDraft(ctx, draft.ID())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if reread.Content == nil || reread.Content.HtmlPart != content.HtmlPart || reread.Draft.Subject != "Summer offers" {
+		t.Fatalf("Wrong draft: %+v %+v", reread.Draft, reread.Content)
+	}
+	if err = reread.Send(ctx); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if reread.Draft.Status != mailjet.CampaignDraftStatusSent || reread.Draft.CampaignID == 0 {
+		t.Fatalf("Wrong draft: %+v", reread.Draft)
+	}
+	if err = reread.SetContent(ctx, content); !errors.As(err, &draftErr) || draftErr.Status != mailjet.CampaignDraftStatusSent {
+		t.Errorf("Expected a CampaignDraftError, got %v", err)
+	}
+
+	polls := 0
+	campaign, err := reread.Wait(ctx, mailjet.WithCampaignBackoff(fastBackoff),
+		mailjet.WithCampaignProgress(func(resources.Campaign) { polls++ }))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if campaign.ID != reread.Draft.CampaignID || campaign.NewsLetterID != draft.ID() || campaign.SendEndAt == nil || polls != 2 {
+		t.Errorf("Wrong campaign after %d polls: %+v", polls, campaign)
+	}
+}
+
+func TestCampaignDraftSchedule(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	srv := fake.NewServer(fake.WithClock(clock))
+	t.Cleanup(srv.Close)
+	client := mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+	ctx := context.Background()
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	draft, err := client.CreateCampaignDraft(ctx, &resources.Campaigndraft{
+		Locale: "en_US", Sender: "Pilot", SenderEmail: "pilot@mailjet.com", Subject: "Boarding", ContactsListID: lists[0],
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = draft.SetContent(ctx, &resources.CampaigndraftDetailcontent{TextPart: "Boarding at 10:00"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if err = draft.Schedule(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if draft.Draft.Status != mailjet.CampaignDraftStatusProgrammed {
+		t.Fatalf("Wrong status: %d", draft.Draft.Status)
+	}
+	if err = draft.Unschedule(ctx); err != nil || draft.Draft.Status != mailjet.CampaignDraftStatusDraft {
+		t.Fatalf("Draft not unscheduled: %d (%v)", draft.Draft.Status, err)
+	}
+	if err = draft.Schedule(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// The draft is not sent before its date.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err = draft.Wait(short, mailjet.WithCampaignBackoff(fastBackoff)); err == nil || draft.Draft.Status != mailjet.CampaignDraftStatusProgrammed {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	mu.Unlock()
+	campaign, err := draft.Wait(ctx, mailjet.WithCampaignBackoff(fastBackoff))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if draft.Draft.Status != mailjet.CampaignDraftStatusSent || campaign.NewsLetterID != draft.ID() {
+		t.Errorf("Wrong campaign: %+v", campaign)
+	}
+}
+
+func TestCampaignDraftWaitError(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ctx := context.Background()
+	for _, status := range []string{mailjet.CampaignStatusError, mailjet.CampaignStatusCancelled} {
+		campaigns, err := srv.Seed("campaign", map[string]interface{}{"Status": status, "Subject": "Boarding"})
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		drafts, err := srv.Seed("campaigndraft", map[string]interface{}{
+			"Locale": "en_US", "Status": mailjet.CampaignDraftStatusSent, "CampaignID": campaigns[0],
+		})
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		draft, err := client.CampaignDraft(ctx, drafts[0])
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		short, cancel := context.WithTimeout(ctx, time.Second)
+		campaign, err := draft.Wait(short, mailjet.WithCampaignBackoff(fastBackoff))
+		cancel()
+		var campaignErr *mailjet.CampaignError
+		if !errors.As(err, &campaignErr) || campaignErr.Campaign.Status != status || campaign == nil || campaign.ID != campaigns[0] {
+			t.Errorf("Expected a CampaignError in status %s, got %v", status, err)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// Package cassette records the interactions of a Client with the Mailjet API
+// in fixture files, and replays them in tests without network access.
+//
+// A Recorder is an http.RoundTripper, installed with Client.SetClient:
+//
+//	rec, err := cassette.New("testdata/contacts.json", cassette.ModeAuto)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Save()
+//	client.SetClient(rec.Client())
+//
+// Credentials and e-mail addresses are scrubbed from the recorded interactions.
+// When replaying, requests are matched by method, path, query and body, and a
+// request without recorded interaction fails with ErrUnmatched.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// Modes of a Recorder.
+const (
+	// ModeReplay serves the recorded interactions, and fails on other requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records the interactions.
+	ModeRecord
+	// ModeAuto replays the cassette if the file exists, and records it otherwise.
+	ModeAuto
+)
+
+// Placeholders replacing the credentials in the recorded interactions.
+const (
+	ScrubbedAPIKeyPublic  = "apiKeyPublic"
+	ScrubbedAPIKeyPrivate = "apiKeyPrivate"
+)
+
+// ErrUnmatched is returned in replay mode for a request without recorded interaction.
+var ErrUnmatched = errors.New("cassette: no recorded interaction")
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request
+	Response Response
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string
+	Path   string
+	Query  string `json:",omitempty"`
+	Body   string `json:",omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int
+	Header     http.Header `json:",omitempty"`
+	Body       string      `json:",omitempty"`
+}
+
+// cassette is the content of a fixture file.
+type cassette struct {
+	Interactions []*Interaction
+}
+
+// Recorder is an http.RoundTripper recording or replaying interactions.
+type Recorder struct {
+	path        string
+	mode        Mode
+	transport   http.RoundTripper
+	keptDomains []string
+	scrubbers   []func(string) string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	played       []bool
+	unmatched    []string
+}
+
+// Options are functional options that configure the Recorder.
+type Options func(*Recorder)
+
+// WithTransport sets the transport of the requests in record mode. It defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Options {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithKeptDomains sets the domains whose e-mail addresses are not scrubbed,
+// in addition to the reserved example.com, example.net and example.org.
+func WithKeptDomains(domains ...string) Options {
+	return func(r *Recorder) {
+		r.keptDomains = append(r.keptDomains, domains...)
+	}
+}
+
+// WithScrubber adds a function applied to the paths, queries and bodies of the
+// recorded interactions, e.g. to remove personal data other than e-mail addresses.
+// It is applied to the requests being replayed too, so it must be deterministic.
+func WithScrubber(scrub func(string) string) Options {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrub)
+	}
+}
+
+// New returns a Recorder of the cassette file at path.
+// In replay mode, the file must exist.
+func New(path string, mode Mode, options ...Options) (*Recorder, error) {
+	r := &Recorder{
+		path:        path,
+		mode:        mode,
+		transport:   http.DefaultTransport,
+		keptDomains: []string{"example.com", "example.net", "example.org"},
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: reading %s: %w", path, err)
+		}
+		var c cassette
+		if err = json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("cassette: decoding %s: %w", path, err)
+		}
+		r.interactions = c.Interactions
+		r.played = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the mode of the recorder, ModeAuto being resolved.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an http.Client using the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := r.request(req, body)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Date")
+	header.Del("Set-Cookie")
+	header.Del("Content-Length")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       r.scrub(req, string(respBody)),
+		},
+	})
+	return resp, nil
+}
+
+// replay returns the response of the first interaction matching the request not played yet.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.played[i] || !matches(interaction.Request, recorded) {
+			continue
+		}
+		r.played[i] = true
+		resp := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			StatusCode:    resp.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        resp.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(resp.Body)),
+			ContentLength: int64(len(resp.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	call := recorded.Method + " " + recorded.Path
+	if recorded.Query != "" {
+		call += "?" + recorded.Query
+	}
+	r.unmatched = append(r.unmatched, call)
+	return nil, fmt.Errorf("%w in %s for %s %s", ErrUnmatched, r.path, call, recorded.Body)
+}
+
+// matches reports whether both requests are the same, JSON bodies being compared by value.
+func matches(a, b Request) bool {
+	if a.Method != b.Method || a.Path != b.Path || a.Query != b.Query {
+		return false
+	}
+	return a.Body == b.Body || normalizeJSON(a.Body) == normalizeJSON(b.Body)
+}
+
+func normalizeJSON(s string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return s
+	}
+	return string(b)
+}
+
+// request returns the scrubbed record of the request.
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	query := req.URL.Query()
+	for key, values := range query {
+		for i, value := range values {
+			values[i] = r.scrub(req, value)
+		}
+		query[key] = values
+	}
+	return Request{
+		Method: req.Method,
+		Path:   r.scrub(req, req.URL.Path),
+		Query:  query.Encode(),
+		Body:   r.scrub(req, string(body)),
+	}
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+\.)+[A-Za-z]{2,}`)
+
+// minKeyLength is the length from which the API keys are scrubbed, to avoid replacing
+// short test keys everywhere.
+const minKeyLength = 8
+
+// scrub removes the credentials of the request and the e-mail addresses from s.
+// Addresses are replaced with a hash, so that a given address is always replaced
+// with the same placeholder and requests can still be matched.
+func (r *Recorder) scrub(req *http.Request, s string) string {
+	if public, private, ok := req.BasicAuth(); ok {
+		if len(private) >= minKeyLength {
+			s = strings.Replace(s, private, ScrubbedAPIKeyPrivate, -1)
+		}
+		if len(public) >= minKeyLength {
+			s = strings.Replace(s, public, ScrubbedAPIKeyPublic, -1)
+		}
+	}
+	s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+		for _, kept := range r.keptDomains {
+			if domain == strings.ToLower(kept) {
+				return email
+			}
+		}
+		sum := sha256.Sum256([]byte(strings.ToLower(email)))
+		return "scrubbed-" + hex.EncodeToString(sum[:6]) + "@example.com"
+	})
+	for _, scrub := range r.scrubbers {
+		s = scrub(s)
+	}
+	return s
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: creating directory: %w", err)
+	}
+	return ioutil.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+// Unplayed returns the recorded interactions not replayed yet.
+func (r *Recorder) Unplayed() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unplayed []*Interaction
+	for i, interaction := range r.interactions {
+		if r.mode == ModeReplay && !r.played[i] {
+			unplayed = append(unplayed, interaction)
+		}
+	}
+	return unplayed
+}
+
+// Check returns an error if a request has not been matched or if an interaction has not been replayed,
+// e.g. to be called at the end of a test.
+func (r *Recorder) Check() error {
+	r.mu.Lock()
+	unmatched := append([]string(nil), r.unmatched...)
+	r.mu.Unlock()
+
+	var problems []string
+	for _, call := range unmatched {
+		problems = append(problems, "unmatched request "+call)
+	}
+	for _, interaction := range r.Unplayed() {
+		problems = append(problems, "unplayed interaction "+interaction.Request.Method+" "+interaction.Request.Path)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("cassette %s: %s", r.path, strings.Join(problems, "; "))
+	}
+	return nil
+}
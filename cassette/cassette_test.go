@@ -0,0 +1,149 @@
+package cassette_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/cassette"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+const (
+	apiKeyPublic  = "0123456789abcdef0123456789abcdef"
+	apiKeyPrivate = "fedcba9876543210fedcba9876543210"
+)
+
+// scenario creates a contact and reads it back.
+func scenario(client *mailjet.Client) (*resources.Contact, error) {
+	err := client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "passenger@mailjet.com", Name: "Passenger"},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var contacts []resources.Contact
+	err = client.Get(&mailjet.Request{Resource: "contact", AltID: "passenger@mailjet.com"}, &contacts)
+	if err != nil {
+		return nil, err
+	}
+	return &contacts[0], nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "contact.json")
+
+	srv := fake.NewServer()
+	rec, err := cassette.New(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if rec.Mode() != cassette.ModeRecord {
+		t.Fatal("Expected record mode without cassette file")
+	}
+	client := mailjet.NewMailjetClient(apiKeyPublic, apiKeyPrivate, srv.URL)
+	client.SetClient(rec.Client())
+	recorded, err := scenario(client)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	srv.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for _, secret := range []string{apiKeyPublic, apiKeyPrivate, "passenger@mailjet.com", "passenger%40mailjet.com"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("The cassette contains %q:\n%s", secret, b)
+		}
+	}
+
+	rec, err = cassette.New(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if rec.Mode() != cassette.ModeReplay {
+		t.Fatal("Expected replay mode with a cassette file")
+	}
+	client = mailjet.NewMailjetClient(apiKeyPublic, apiKeyPrivate, srv.URL)
+	client.SetClient(rec.Client())
+	replayed, err := scenario(client)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if replayed.ID != recorded.ID || replayed.Name != "Passenger" {
+		t.Fatalf("Wrong replayed contact: %+v", replayed)
+	}
+	if err = rec.Check(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	_, _, err = client.List("contact", nil, mailjet.Filter("Limit", "5"))
+	if err == nil || !strings.Contains(err.Error(), cassette.ErrUnmatched.Error()) {
+		t.Fatalf("Expected an unmatched request error, got %v", err)
+	}
+	if err = rec.Check(); err == nil || !strings.Contains(err.Error(), "GET /v3/REST/contact?Limit=5") {
+		t.Fatalf("Expected Check to report the unmatched request, got %v", err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := cassette.New(filepath.Join("testdata", "missing.json"), cassette.ModeReplay)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected a missing file error, got %v", err)
+	}
+}
+
+func TestReplayMatchesBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "send.json")
+
+	fixture := `{"Interactions":[{
+		"Request":{"Method":"POST","Path":"/v3/REST/contactslist","Body":"{\"Name\": \"Passengers\"}"},
+		"Response":{"StatusCode":201,"Header":{"Content-Type":["application/json"]},"Body":"{\"Count\":1,\"Data\":[{\"ID\":7}],\"Total\":1}"}
+	}]}`
+	if err = ioutil.WriteFile(path, []byte(fixture), 0o600); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	rec, err := cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	client := mailjet.NewMailjetClient(apiKeyPublic, apiKeyPrivate, "http://127.0.0.1:0/v3")
+	client.SetClient(rec.Client())
+
+	request := &mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contactslist"},
+		Payload: &resources.Contactslist{Name: "Crew"},
+	}
+	if err = client.Post(request, nil); err == nil {
+		t.Fatal("Expected an error for a different body")
+	}
+	var lists []resources.Contactslist
+	request.Payload = &resources.Contactslist{Name: "Passengers"}
+	if err = client.Post(request, &lists); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(lists) != 1 || lists[0].ID != 7 {
+		t.Fatalf("Wrong replayed lists: %+v", lists)
+	}
+}
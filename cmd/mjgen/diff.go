@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// field is a serialized field of a struct of the resources package.
+type field struct {
+	goType   string
+	readOnly bool
+}
+
+// parseStructs returns the fields of the structs of the Go files of dir, keyed by
+// type name and JSON name. Unexported fields are ignored, as they are not serialized.
+func parseStructs(dir string) (map[string]map[string]field, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	structs := make(map[string]map[string]field)
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			fields := make(map[string]field)
+			for _, f := range st.Fields.List {
+				var tag reflect.StructTag
+				if f.Tag != nil {
+					value, _ := strconv.Unquote(f.Tag.Value)
+					tag = reflect.StructTag(value)
+				}
+				for _, ident := range f.Names {
+					if !ident.IsExported() {
+						continue
+					}
+					jsonName := strings.Split(tag.Get("json"), ",")[0]
+					if jsonName == "-" {
+						continue
+					}
+					if jsonName == "" {
+						jsonName = ident.Name
+					}
+					fields[jsonName] = field{
+						goType:   exprString(f.Type),
+						readOnly: tag.Get("mailjet") == "read_only",
+					}
+				}
+			}
+			structs[spec.Name.Name] = fields
+			return false
+		})
+	}
+	return structs, nil
+}
+
+func exprString(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + exprString(t.Elt)
+	}
+	return "interface{}"
+}
+
+// kind groups the Go types which are compatible with the same metadata data types.
+func kind(goType string) string {
+	switch strings.TrimPrefix(goType, "*") {
+	case "bool":
+		return "bool"
+	case "int", "int32", "int64", "uint", "uint32", "uint64", "float32", "float64":
+		return "number"
+	case "string":
+		return "string"
+	case "RFC3339DateTime", "UnixTime", "time.Time":
+		return "time"
+	}
+	return ""
+}
+
+// compatible reports whether a Go type matches the type wanted for a property. Numbers
+// of any size match, and times must be sent the same way: as RFC3339 strings or as
+// Unix timestamps. Unknown types match anything.
+func compatible(goType, want string) bool {
+	got, wantKind := kind(goType), kind(want)
+	switch {
+	case got == "" || wantKind == "":
+		return true
+	case got != wantKind:
+		return false
+	case got == "time":
+		return isUnixTime(goType) == isUnixTime(want)
+	}
+	return true
+}
+
+func isUnixTime(goType string) bool {
+	return strings.TrimPrefix(goType, "*") == "UnixTime"
+}
+
+// diff returns the drift of the structs from the metadata, one line per difference.
+// Resources missing from the metadata are not reported, as the snapshot may be partial.
+func diff(metadata []resources.Metadata, structs map[string]map[string]field) []string {
+	var drift []string
+	for _, m := range sorted(metadata) {
+		name := typeName(m.Name)
+		fields, ok := structs[name]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("%s: missing resource %s", name, m.Name))
+			continue
+		}
+		properties := make(map[string]bool)
+		for _, p := range m.Properties {
+			properties[p.Name] = true
+			f, ok := fields[p.Name]
+			if !ok {
+				drift = append(drift, fmt.Sprintf("%s: missing property %s (%s)", name, p.Name, p.DataType))
+				continue
+			}
+			want := propertyType(m.Name, p)
+			if !compatible(f.goType, want) {
+				drift = append(drift, fmt.Sprintf("%s: property %s is %s, want %s (%s)", name, p.Name, f.goType, want, p.DataType))
+			}
+			if f.readOnly != p.ReadOnly {
+				drift = append(drift, fmt.Sprintf("%s: property %s read_only is %t, want %t", name, p.Name, f.readOnly, p.ReadOnly))
+			}
+		}
+		var extra []string
+		for jsonName := range fields {
+			if !properties[jsonName] {
+				extra = append(extra, jsonName)
+			}
+		}
+		sort.Strings(extra)
+		for _, jsonName := range extra {
+			drift = append(drift, fmt.Sprintf("%s: unknown property %s", name, jsonName))
+		}
+	}
+	return drift
+}
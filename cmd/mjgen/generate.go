@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+const resourcesImport = "github.com/mailjet/mailjet-apiv3-go/v4/resources"
+
+// initialisms are the words of property names written upper-case in Go.
+var initialisms = map[string]bool{
+	"ALT": true, "API": true, "CSV": true, "DNS": true, "HTML": true, "HTTP": true, "ID": true,
+	"IP": true, "JSON": true, "SMTP": true, "SPF": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// unixTimes are the time properties the API sends as Unix timestamps, keyed by resource
+// and property. The others are RFC3339 strings.
+var unixTimes = map[string]bool{
+	"aggregategraphstatistics.RefTimestamp": true,
+	"apikeytotals.LastActivity":             true,
+	"batchjob.AliveAt":                      true,
+	"batchjob.JobEnd":                       true,
+	"batchjob.JobStart":                     true,
+	"batchjob.RequestAt":                    true,
+	"campaigngraphstatistics.Tick":          true,
+	"campaignoverview.SendTimeStart":        true,
+	"contactslistsignup.ConfirmAt":          true,
+	"contactslistsignup.SignupAt":           true,
+	"graphstatistics.RefTimestamp":          true,
+	"graphstatistics.SendtimeStart":         true,
+	"messagehistory.EventAt":                true,
+	"widget.CreatedAt":                      true,
+}
+
+// generate returns the Go source of the structs, filters and actions of the resources.
+func generate(metadata []resources.Metadata, pkg string) ([]byte, error) {
+	metadata = sorted(metadata)
+	qualifier := ""
+	if pkg != "resources" {
+		qualifier = "resources."
+	}
+
+	var body bytes.Buffer
+	usesResources := false
+	for _, m := range metadata {
+		name := typeName(m.Name)
+		fmt.Fprintf(&body, "\n// %s: %s\n", name, oneLine(m.Description))
+		fmt.Fprintf(&body, "type %s struct {\n", name)
+		for _, p := range m.Properties {
+			typ := propertyType(m.Name, p)
+			if kind(typ) == "time" {
+				typ = "*" + qualifier + strings.TrimPrefix(typ, "*")
+				usesResources = usesResources || qualifier != ""
+			}
+			field := fieldName(p.Name)
+			fmt.Fprintf(&body, "\t%s %s%s\n", field, typ, fieldTag(field, p))
+		}
+		body.WriteString("}\n")
+	}
+
+	for _, m := range metadata {
+		if len(m.Filters) == 0 {
+			continue
+		}
+		name := typeName(m.Name)
+		fmt.Fprintf(&body, "\n// %sFilter is the name of a filter of the %s resource.\n", name, m.Name)
+		fmt.Fprintf(&body, "type %sFilter string\n\n", name)
+		fmt.Fprintf(&body, "// Filters of the %s resource.\nconst (\n", m.Name)
+		for _, f := range m.Filters {
+			if f.Description != "" {
+				fmt.Fprintf(&body, "\t// %sFilter%s: %s\n", name, fieldName(f.Name), oneLine(f.Description))
+			}
+			fmt.Fprintf(&body, "\t%sFilter%s %sFilter = %q\n", name, fieldName(f.Name), name, f.Name)
+		}
+		body.WriteString(")\n")
+	}
+
+	for _, m := range metadata {
+		if len(m.Actions) == 0 {
+			continue
+		}
+		name := typeName(m.Name)
+		fmt.Fprintf(&body, "\n// Actions of the %s resource.\nconst (\n", m.Name)
+		for _, a := range m.Actions {
+			fmt.Fprintf(&body, "\t%sAction%s = %q\n", name, typeName(a.Name), strings.ToLower(a.Name))
+		}
+		body.WriteString(")\n")
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by mjgen from the Mailjet metadata. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n", pkg)
+	if usesResources {
+		fmt.Fprintf(&src, "\nimport %q\n", resourcesImport)
+	}
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func sorted(metadata []resources.Metadata) []resources.Metadata {
+	metadata = append([]resources.Metadata(nil), metadata...)
+	sort.Slice(metadata, func(i, j int) bool {
+		return strings.ToLower(metadata[i].Name) < strings.ToLower(metadata[j].Name)
+	})
+	return metadata
+}
+
+// typeName returns the Go type of a resource, e.g. Contactslist for contactslist.
+func typeName(resource string) string {
+	if resource == "" {
+		return ""
+	}
+	return strings.ToUpper(resource[:1]) + resource[1:]
+}
+
+// fieldName returns the Go field of a property, with initialisms upper-case,
+// e.g. LinkID for LinkId.
+func fieldName(property string) string {
+	var b strings.Builder
+	for _, word := range words(property) {
+		if initialisms[strings.ToUpper(word)] {
+			word = strings.ToUpper(word)
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// words splits a camel case name, keeping upper-case runs such as API in APIKeyID together.
+func words(name string) []string {
+	runes := []rune(name)
+	var result []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+		upperRun := unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (lower || upperRun) {
+			result = append(result, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		result = append(result, string(runes[start:]))
+	}
+	return result
+}
+
+// fieldTag returns the struct tag of a property: read_only for the properties set by the API,
+// omitempty for the optional ones, and the JSON name when it differs from the field.
+func fieldTag(field string, p resources.ResourceProperty) string {
+	var tags []string
+	jsonName := ""
+	if field != p.Name {
+		jsonName = p.Name
+	}
+	switch {
+	case p.ReadOnly:
+		if jsonName != "" {
+			tags = append(tags, fmt.Sprintf(`json:%q`, jsonName))
+		}
+		tags = append(tags, `mailjet:"read_only"`)
+	case !p.IsRequired:
+		tags = append(tags, fmt.Sprintf(`json:"%s,omitempty"`, jsonName))
+	case jsonName != "":
+		tags = append(tags, fmt.Sprintf(`json:%q`, jsonName))
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return " `" + strings.Join(tags, " ") + "`"
+}
+
+// goType returns the Go type of a metadata data type, e.g. TInt64 or Boolean.
+func goType(dataType string) string {
+	t := dataType
+	if len(t) > 1 && t[0] == 'T' && unicode.IsUpper(rune(t[1])) {
+		t = t[1:]
+	}
+	switch strings.ToLower(t) {
+	case "bool", "boolean":
+		return "bool"
+	case "int", "int32", "integer", "smallint", "byte", "word":
+		return "int"
+	case "int64", "long", "longint", "cardinal":
+		return "int64"
+	case "double", "float", "single", "real", "currency", "extended":
+		return "float64"
+	case "string", "text", "email", "guid", "uuid", "ansistring", "widestring":
+		return "string"
+	case "datetime", "date", "time":
+		return "*RFC3339DateTime"
+	}
+	return "interface{}"
+}
+
+// propertyType returns the Go type of a property of a resource: the type of its data type,
+// with the times sent as Unix timestamps as *UnixTime.
+func propertyType(resource string, p resources.ResourceProperty) string {
+	typ := goType(p.DataType)
+	if typ == "*RFC3339DateTime" && unixTimes[strings.ToLower(resource)+"."+p.Name] {
+		return "*UnixTime"
+	}
+	return typ
+}
+
+// oneLine collapses the white space of a description.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
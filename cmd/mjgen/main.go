@@ -0,0 +1,124 @@
+// Command mjgen generates the resource structs, filters and actions from a snapshot
+// of the Mailjet metadata resource, or reports the drift of existing structs from it.
+//
+// When the snapshot does not exist, mjgen saves it from the API with the keys of the
+// MJ_APIKEY_PUBLIC and MJ_APIKEY_PRIVATE environment variables. It can also be saved with:
+//
+//	curl -s -u "$MJ_APIKEY_PUBLIC:$MJ_APIKEY_PRIVATE" "https://api.mailjet.com/v3/REST/metadata?Limit=1000" > resources/metadata.json
+//
+// go generate then reports how the structs of the resources package drift from the API,
+// running go run ../cmd/mjgen -metadata metadata.json -diff . from its directory:
+//
+//	go generate ./resources
+//
+// and generates the resources into the generated package, next to the hand-written ones,
+// running go run ../../cmd/mjgen -metadata ../metadata.json -pkg generated -o resources_gen.go:
+//
+//	go generate ./resources/generated
+//
+// With -diff, mjgen exits with status 1 when there is a drift. The snapshot depends on
+// the account and is not committed.
+//
+// Times are generated as *RFC3339DateTime, except the properties the API sends as
+// Unix timestamps, listed in unixTimes, which are generated as *UnixTime.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mjgen: ")
+
+	metadataPath := flag.String("metadata", "metadata.json", "metadata snapshot, as returned by GET /v3/REST/metadata")
+	output := flag.String("o", "", "output file, standard output if empty")
+	pkg := flag.String("pkg", "resources", "package of the generated file")
+	diffDir := flag.String("diff", "", "report the drift of the structs of this package directory instead of generating")
+	flag.Parse()
+
+	metadata, err := readMetadata(*metadataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *diffDir != "" {
+		structs, err := parseStructs(*diffDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		drift := diff(metadata, structs)
+		for _, line := range drift {
+			fmt.Println(line)
+		}
+		if len(drift) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	src, err := generate(metadata, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readMetadata reads a snapshot, either the API response with its Data or the bare array.
+// A missing snapshot is saved from the API first, when the API keys are set.
+func readMetadata(path string) ([]resources.Metadata, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && os.Getenv("MJ_APIKEY_PUBLIC") != "" && os.Getenv("MJ_APIKEY_PRIVATE") != "" {
+		b, err = saveMetadata(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var metadata []resources.Metadata
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &metadata)
+	} else {
+		var res struct {
+			Data []resources.Metadata
+		}
+		err = json.Unmarshal(b, &res)
+		metadata = res.Data
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// saveMetadata reads the metadata resource from the API and saves it to path.
+func saveMetadata(path string) ([]byte, error) {
+	client := mailjet.NewMailjetClient(os.Getenv("MJ_APIKEY_PUBLIC"), os.Getenv("MJ_APIKEY_PRIVATE"))
+	var metadata []resources.Metadata
+	if _, _, err := client.List("metadata", &metadata, mailjet.Filter("Limit", "1000")); err != nil {
+		return nil, fmt.Errorf("reading the metadata: %w", err)
+	}
+	b, err := json.MarshalIndent(metadata, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(path, b, 0o644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
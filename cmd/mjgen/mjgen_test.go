@@ -0,0 +1,108 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestGenerate(t *testing.T) {
+	metadata, err := readMetadata("testdata/metadata.json")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	src, err := generate(metadata, "generated")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = parser.ParseFile(token.NewFileSet(), "generated.go", src, 0); err != nil {
+		t.Fatalf("Invalid source: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"// Code generated by mjgen from the Mailjet metadata. DO NOT EDIT.",
+		`import "github.com/mailjet/mailjet-apiv3-go/v4/resources"`,
+		"// Bouncestatistics: Statistics on the bounces generated by emails sent on a given API Key.",
+		"CreatedAt               *resources.RFC3339DateTime `mailjet:\"read_only\"`",
+		"IsExcludedFromCampaigns bool\n",
+		"Name                    string                     `json:\",omitempty\"`",
+		"LinkURL          string                     `json:\"LinkUrl\" mailjet:\"read_only\"`",
+		"type ContactFilter string",
+		`ContactFilterContactsList            ContactFilter = "ContactsList"`,
+		`ContactActionManageContactsLists = "managecontactslists"`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("Expected %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for property, want := range map[string]string{
+		"APIKeyID":   "APIKeyID",
+		"LinkId":     "LinkID",
+		"Url":        "URL",
+		"ContactALT": "ContactALT",
+		"tagName":    "TagName",
+		"SPFStatus":  "SPFStatus",
+	} {
+		if got := fieldName(property); got != want {
+			t.Errorf("fieldName(%q) = %q, want %q", property, got, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	metadata, err := readMetadata("testdata/metadata.json")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	structs, err := parseStructs("../../resources")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want := []string{
+		"Bouncestatistics: missing property Email (TString)",
+		"Bouncestatistics: property IsStatePermanent is bool, want string (TString)",
+		"Bouncestatistics: missing property LinkUrl (TString)",
+		"Bouncestatistics: unknown property CampaignALT",
+		"Bouncestatistics: unknown property ContactALT",
+	}
+	if got := diff(metadata, structs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Wrong drift:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTimes(t *testing.T) {
+	metadata := []resources.Metadata{{
+		Name: "batchjob",
+		Properties: []resources.ResourceProperty{
+			{Name: "JobStart", DataType: "TDateTime"},
+			{Name: "JobEnd", DataType: "TDateTime"},
+			{Name: "Status", DataType: "TString"},
+		},
+	}}
+	src, err := generate(metadata, "generated")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !strings.Contains(string(src), "JobStart *resources.UnixTime") {
+		t.Errorf("Expected a UnixTime in\n%s", src)
+	}
+
+	structs := map[string]map[string]field{"Batchjob": {
+		"JobStart": {goType: "*UnixTime"},
+		"JobEnd":   {goType: "*RFC3339DateTime"},
+		"Status":   {goType: "*UnixTime"},
+	}}
+	want := []string{
+		"Batchjob: property JobEnd is *RFC3339DateTime, want *UnixTime (TDateTime)",
+		"Batchjob: property Status is *UnixTime, want string (TString)",
+	}
+	if got := diff(metadata, structs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Wrong drift:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,22 @@
+package mailjet
+
+import (
+	"context"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/segmentation"
+)
+
+// ValidateContactFilter parses the segmentation expression of a contactfilter and validates
+// it against the contactmetadata of the account, before it is sent to the API.
+// It returns a *segmentation.SyntaxError or a *segmentation.ValidationError.
+func (c *Client) ValidateContactFilter(expression string) (segmentation.Expr, error) {
+	e, err := segmentation.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := c.contactMetadata(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return e, segmentation.Validate(e, metadata)
+}
@@ -0,0 +1,26 @@
+package mailjet_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+	"github.com/mailjet/mailjet-apiv3-go/v4/segmentation"
+)
+
+func TestValidateContactFilter(t *testing.T) {
+	srv, client := newFakeClient(t)
+	if _, err := srv.Seed("contactmetadata", resources.Contactmetadata{Name: "age", Datatype: "int"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	e, err := client.ValidateContactFilter("age > 30")
+	if err != nil || e.String() != "(age>30)" {
+		t.Fatalf("Wrong expression: %v (%v)", e, err)
+	}
+	_, err = client.ValidateContactFilter(`(age>30) AND (country="FR")`)
+	var validationErr *segmentation.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Unknown) != 1 {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+}
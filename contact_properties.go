@@ -0,0 +1,391 @@
+package mailjet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Datatypes of the contact properties, as defined in contactmetadata.
+const (
+	ContactPropertyString   = "str"
+	ContactPropertyInt      = "int"
+	ContactPropertyFloat    = "float"
+	ContactPropertyBool     = "bool"
+	ContactPropertyDatetime = "datetime"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	datetimeType = reflect.TypeOf(resources.RFC3339DateTime{})
+)
+
+// contactProperty is a field of a struct mapped to a contact property by its `mailjet` tag.
+type contactProperty struct {
+	name      string
+	datatype  string
+	index     []int
+	omitempty bool
+}
+
+// contactProperties returns the contact properties of the fields of a struct type tagged
+// with `mailjet:"name"` or `mailjet:"name,omitempty"`. Other fields are ignored.
+func contactProperties(t reflect.Type) ([]contactProperty, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mailjet: contact properties of %s: not a struct", t)
+	}
+
+	var properties []contactProperty
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mailjet")
+		if tag == "" || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		datatype := contactDatatype(f.Type)
+		if datatype == "" {
+			return nil, fmt.Errorf("mailjet: contact property %s: unsupported type %s", parts[0], f.Type)
+		}
+		properties = append(properties, contactProperty{
+			name:      parts[0],
+			datatype:  datatype,
+			index:     f.Index,
+			omitempty: len(parts) > 1 && parts[1] == "omitempty",
+		})
+	}
+	return properties, nil
+}
+
+// contactDatatype returns the datatype of the contact property of a field type.
+func contactDatatype(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || t == datetimeType {
+		return ContactPropertyDatetime
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return ContactPropertyString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ContactPropertyInt
+	case reflect.Float32, reflect.Float64:
+		return ContactPropertyFloat
+	case reflect.Bool:
+		return ContactPropertyBool
+	}
+	return ""
+}
+
+// formatContactProperty returns the value of a field as sent in contact data.
+// ok is false for a nil pointer or, with omitempty, a zero value.
+func formatContactProperty(p contactProperty, v reflect.Value) (value string, ok bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if p.omitempty && v.IsZero() {
+		return "", false
+	}
+	switch p.datatype {
+	case ContactPropertyDatetime:
+		t := v.Interface()
+		if dt, isDatetime := t.(resources.RFC3339DateTime); isDatetime {
+			t = dt.Time
+		}
+		return t.(time.Time).UTC().Format(time.RFC3339), true
+	case ContactPropertyInt:
+		if v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+			return strconv.FormatUint(v.Uint(), 10), true
+		}
+		return strconv.FormatInt(v.Int(), 10), true
+	case ContactPropertyFloat:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	case ContactPropertyBool:
+		return strconv.FormatBool(v.Bool()), true
+	}
+	return v.String(), true
+}
+
+// parseContactProperty sets a field from the value of a contact property.
+// An empty value sets the zero value.
+func parseContactProperty(p contactProperty, v reflect.Value, value string) error {
+	if v.Kind() == reflect.Ptr {
+		if value == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if value == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	var err error
+	switch p.datatype {
+	case ContactPropertyDatetime:
+		var dt resources.RFC3339DateTime
+		if err = dt.UnmarshalJSON([]byte(strconv.Quote(value))); err == nil {
+			if v.Type() == timeType {
+				v.Set(reflect.ValueOf(dt.Time))
+			} else {
+				v.Set(reflect.ValueOf(dt))
+			}
+		}
+	case ContactPropertyInt:
+		if v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+			var n uint64
+			if n, err = strconv.ParseUint(value, 10, 64); err == nil {
+				v.SetUint(n)
+			}
+		} else {
+			var n int64
+			if n, err = strconv.ParseInt(value, 10, 64); err == nil {
+				v.SetInt(n)
+			}
+		}
+	case ContactPropertyFloat:
+		var f float64
+		if f, err = strconv.ParseFloat(value, 64); err == nil {
+			v.SetFloat(f)
+		}
+	case ContactPropertyBool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			v.SetBool(b)
+		}
+	default:
+		v.SetString(value)
+	}
+	if err != nil {
+		return fmt.Errorf("mailjet: contact property %s: %w", p.name, err)
+	}
+	return nil
+}
+
+// MarshalContactProperties returns the contact data of the tagged fields of v,
+// a struct or a pointer to a struct, e.g.
+//
+//	type Passenger struct {
+//		FirstName string    `mailjet:"firstname"`
+//		Miles     int       `mailjet:"miles,omitempty"`
+//		Birthday  time.Time `mailjet:"birthday"`
+//	}
+//
+// Strings, integers, floats, booleans and times map to the str, int, float, bool and
+// datetime datatypes. Nil pointers, and zero values with omitempty, are left out.
+func MarshalContactProperties(v interface{}) (resources.KeyValueList, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	properties, err := contactProperties(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	data := resources.KeyValueList{}
+	for _, p := range properties {
+		if value, ok := formatContactProperty(p, rv.FieldByIndex(p.index)); ok {
+			data = append(data, map[string]string{"Name": p.name, "Value": value})
+		}
+	}
+	return data, nil
+}
+
+// ContactPropertiesMap returns the tagged fields of v as the properties of an AddContactAction.
+func ContactPropertiesMap(v interface{}) (map[string]interface{}, error) {
+	data, err := MarshalContactProperties(v)
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]interface{}, len(data))
+	for _, d := range data {
+		properties[d["Name"]] = d["Value"]
+	}
+	return properties, nil
+}
+
+// UnmarshalContactProperties sets the tagged fields of v, a pointer to a struct, from contact data.
+// Properties without a field are ignored.
+func UnmarshalContactProperties(data resources.KeyValueList, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("mailjet: contact properties: %T is not a pointer to a struct", v)
+	}
+	rv = rv.Elem()
+	properties, err := contactProperties(rv.Type())
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string, len(data))
+	for _, d := range data {
+		values[d["Name"]] = d["Value"]
+	}
+	for _, p := range properties {
+		value, ok := values[p.name]
+		if !ok {
+			continue
+		}
+		if err = parseContactProperty(p, rv.FieldByIndex(p.index), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContactPropertyError reports a contact property defined in contactmetadata with another
+// datatype than the one of its field.
+type ContactPropertyError struct {
+	Name     string
+	Datatype string
+	Want     string
+}
+
+func (e *ContactPropertyError) Error() string {
+	return fmt.Sprintf("mailjet: contact property %s is defined as %s, not %s", e.Name, e.Datatype, e.Want)
+}
+
+// EnsureContactMetadata creates the contactmetadata of the tagged fields of v which are not defined.
+// It returns a *ContactPropertyError when a property is defined with another datatype.
+// A property created meanwhile by another client is accepted if it has the same datatype.
+func (c *Client) EnsureContactMetadata(ctx context.Context, v interface{}) error {
+	properties, err := contactProperties(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	datatypes, err := c.contactDatatypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range properties {
+		datatype, ok := datatypes[strings.ToLower(p.name)]
+		if ok && datatype != p.datatype {
+			return &ContactPropertyError{Name: p.name, Datatype: datatype, Want: p.datatype}
+		}
+		if ok {
+			continue
+		}
+		err = c.Post(&FullRequest{
+			Info:    &Request{Resource: "contactmetadata"},
+			Payload: resources.Contactmetadata{Name: p.name, Datatype: p.datatype},
+		}, nil, WithContext(ctx))
+		if err != nil {
+			// The property may have been created by a concurrent call: the name is taken.
+			if datatypes, rerr := c.contactDatatypes(ctx); rerr == nil {
+				if datatype, ok = datatypes[strings.ToLower(p.name)]; ok && datatype != p.datatype {
+					return &ContactPropertyError{Name: p.name, Datatype: datatype, Want: p.datatype}
+				}
+			}
+			if !ok {
+				return err
+			}
+		}
+		datatypes[strings.ToLower(p.name)] = p.datatype
+	}
+	return nil
+}
+
+// contactDatatypes returns the datatypes of the contactmetadata, by lower-case name.
+func (c *Client) contactDatatypes(ctx context.Context) (map[string]string, error) {
+	defined, err := c.contactMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	datatypes := make(map[string]string, len(defined))
+	for _, m := range defined {
+		datatypes[strings.ToLower(m.Name)] = m.Datatype
+	}
+	return datatypes, nil
+}
+
+// contactMetadata reads all the contactmetadata of the account, page by page.
+func (c *Client) contactMetadata(ctx context.Context) ([]resources.Contactmetadata, error) {
+	var metadata []resources.Contactmetadata
+	err := listPages(ctx, "contactmetadata", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Contactmetadata
+		count, _, err := c.List("contactmetadata", &page, filters...)
+		metadata = append(metadata, page...)
+		return count, err
+	})
+	return metadata, err
+}
+
+// SetContactProperties updates the properties of a contact, given by ID or e-mail address,
+// from the tagged fields of v. The missing contactmetadata are created first.
+func (c *Client) SetContactProperties(ctx context.Context, contact string, v interface{}) error {
+	if err := c.EnsureContactMetadata(ctx, v); err != nil {
+		return err
+	}
+	data, err := MarshalContactProperties(v)
+	if err != nil {
+		return err
+	}
+	return c.Put(&FullRequest{
+		Info:    &Request{Resource: "contactdata", AltID: contact},
+		Payload: resources.Contactdata{Data: data},
+	}, []string{"Data"}, WithContext(ctx))
+}
+
+// GetContactProperties reads the properties of a contact, given by ID or e-mail address,
+// into the tagged fields of v, a pointer to a struct.
+func (c *Client) GetContactProperties(ctx context.Context, contact string, v interface{}) error {
+	var res []struct {
+		Data []struct {
+			Name  string
+			Value interface{}
+		}
+	}
+	if err := c.Get(&Request{Resource: "contactdata", AltID: contact}, &res, WithContext(ctx)); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return fmt.Errorf("mailjet: no contact data for %s", contact)
+	}
+	data := make(resources.KeyValueList, 0, len(res[0].Data))
+	for _, d := range res[0].Data {
+		data = append(data, map[string]string{"Name": d.Name, "Value": contactValue(d.Value)})
+	}
+	return UnmarshalContactProperties(data, v)
+}
+
+// contactValue returns a value of contact data as a string, as the API may return typed values.
+// Times are formatted as formatContactProperty sends them.
+func contactValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if value == nil {
+			return ""
+		}
+		return contactValue(*value)
+	case resources.RFC3339DateTime:
+		return contactValue(value.Time)
+	case *resources.RFC3339DateTime:
+		if value == nil {
+			return ""
+		}
+		return contactValue(value.Time)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,151 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func newFakeClient(t *testing.T) (*fake.Server, *mailjet.Client) {
+	t.Helper()
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+}
+
+type passenger struct {
+	FirstName string     `mailjet:"firstname"`
+	Miles     int        `mailjet:"miles,omitempty"`
+	Ratio     float64    `mailjet:"ratio"`
+	Member    bool       `mailjet:"member"`
+	Birthday  time.Time  `mailjet:"birthday"`
+	LastTrip  *time.Time `mailjet:"last_trip"`
+	Seat      string
+}
+
+func TestMarshalContactProperties(t *testing.T) {
+	birthday := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	data, err := mailjet.MarshalContactProperties(&passenger{FirstName: "Jane", Ratio: 0.5, Member: true, Birthday: birthday, Seat: "15B"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want := resources.KeyValueList{
+		{"Name": "firstname", "Value": "Jane"},
+		{"Name": "ratio", "Value": "0.5"},
+		{"Name": "member", "Value": "true"},
+		{"Name": "birthday", "Value": "1990-05-17T00:00:00Z"},
+	}
+	if len(data) != len(want) {
+		t.Fatalf("Wrong contact data: %v", data)
+	}
+	for i := range want {
+		if data[i]["Name"] != want[i]["Name"] || data[i]["Value"] != want[i]["Value"] {
+			t.Errorf("Wrong property %d: %v, want %v", i, data[i], want[i])
+		}
+	}
+
+	var p passenger
+	err = mailjet.UnmarshalContactProperties(append(data, map[string]string{"Name": "miles", "Value": "1200"},
+		map[string]string{"Name": "unknown", "Value": "x"}), &p)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if p.FirstName != "Jane" || p.Miles != 1200 || !p.Member || !p.Birthday.Equal(birthday) || p.LastTrip != nil {
+		t.Errorf("Wrong passenger: %+v", p)
+	}
+
+	if _, err = mailjet.MarshalContactProperties(struct {
+		Tags []string `mailjet:"tags"`
+	}{}); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+	err = mailjet.UnmarshalContactProperties(resources.KeyValueList{{"Name": "miles", "Value": "far"}}, &p)
+	if err == nil {
+		t.Error("Expected an error for an invalid int")
+	}
+}
+
+func TestContactProperties(t *testing.T) {
+	_, client := newFakeClient(t)
+	err := client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "passenger@mailjet.com"},
+	}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	lastTrip := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := passenger{FirstName: "Jane", Miles: 1200, Member: true, Birthday: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), LastTrip: &lastTrip}
+	if err = client.SetContactProperties(context.Background(), "passenger@mailjet.com", &in); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	in.FirstName = "John"
+	if err = client.SetContactProperties(context.Background(), "passenger@mailjet.com", &in); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var metadata []resources.Contactmetadata
+	if _, _, err = client.List("contactmetadata", &metadata, mailjet.Filter("Datatype", "datetime")); err != nil || len(metadata) != 2 {
+		t.Fatalf("Wrong datetime metadata: %+v (%v)", metadata, err)
+	}
+
+	var out passenger
+	if err = client.GetContactProperties(context.Background(), "passenger@mailjet.com", &out); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if out.FirstName != "John" || out.Miles != 1200 || !out.Member || out.LastTrip == nil || !out.LastTrip.Equal(lastTrip) {
+		t.Errorf("Wrong properties: %+v", out)
+	}
+
+	err = client.EnsureContactMetadata(context.Background(), struct {
+		Miles string `mailjet:"miles"`
+	}{})
+	var propertyErr *mailjet.ContactPropertyError
+	if !errors.As(err, &propertyErr) || propertyErr.Datatype != mailjet.ContactPropertyInt {
+		t.Fatalf("Expected a datatype conflict, got %v", err)
+	}
+}
+
+func TestContactMetadataPages(t *testing.T) {
+	srv, client := newFakeClient(t)
+	metadata := make([]interface{}, 0, 1001)
+	for i := 0; i < 1000; i++ {
+		metadata = append(metadata, resources.Contactmetadata{Name: fmt.Sprint("property", i), Datatype: mailjet.ContactPropertyString})
+	}
+	metadata = append(metadata, resources.Contactmetadata{Name: "miles", Datatype: mailjet.ContactPropertyInt})
+	if _, err := srv.Seed("contactmetadata", metadata...); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// miles is on the second page.
+	if err := client.EnsureContactMetadata(context.Background(), struct {
+		Miles int `mailjet:"miles"`
+	}{}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := client.ValidateContactFilter("(miles > 1000)"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var defined []resources.Contactmetadata
+	if count, _, err := client.List("contactmetadata", &defined, mailjet.Filter("countOnly", "1")); err != nil || count != 1001 {
+		t.Errorf("Expected 1001 properties, got %d (%v)", count, err)
+	}
+
+	// Concurrent calls create each property once, and accept the ones created meanwhile.
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() { errs <- client.EnsureContactMetadata(context.Background(), &passenger{}) }()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package mailjet
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// ExportFormat is the file format of ExportContacts.
+type ExportFormat int
+
+// Formats of ExportContacts.
+const (
+	// ExportCSV writes a header line and a line per contact. The lists are written
+	// as "ListID:subscribed" or "ListID:unsubscribed", separated by semicolons.
+	ExportCSV = ExportFormat(iota)
+	// ExportJSONL writes a JSON object per contact and per line.
+	ExportJSONL
+)
+
+// Columns of the contacts in ExportContacts. The other columns are contact properties.
+const (
+	ExportColumnID                      = "ID"
+	ExportColumnEmail                   = "Email"
+	ExportColumnName                    = "Name"
+	ExportColumnIsExcludedFromCampaigns = "IsExcludedFromCampaigns"
+	ExportColumnCreatedAt               = "CreatedAt"
+	ExportColumnLastActivityAt          = "LastActivityAt"
+	ExportColumnLists                   = "Lists"
+)
+
+// defaultExportPageSize is the number of contacts read per page.
+const defaultExportPageSize = 1000
+
+// ExportOptions are functional options of ExportContacts.
+type ExportOptions func(*contactsExport)
+
+type contactsExport struct {
+	listID   int64
+	format   ExportFormat
+	columns  []string
+	offset   int
+	pageSize int
+	progress func(next int)
+	// lists are the subscriptions of the contacts by contact ID, read once per export of
+	// the account.
+	lists map[int64][]resources.ContactGetcontactslists
+}
+
+// WithExportList only exports the contacts of a list. All the contacts of the account are exported by default.
+func WithExportList(listID int64) ExportOptions {
+	return func(e *contactsExport) {
+		e.listID = listID
+	}
+}
+
+// WithExportFormat sets the format of the export, ExportCSV by default.
+func WithExportFormat(format ExportFormat) ExportOptions {
+	return func(e *contactsExport) {
+		e.format = format
+	}
+}
+
+// WithExportColumns sets the columns of the export, the ExportColumn constants and
+// contact properties. By default, the ID, Email, Name, IsExcludedFromCampaigns and
+// CreatedAt columns are followed by the properties defined in contactmetadata and the lists.
+func WithExportColumns(columns ...string) ExportOptions {
+	return func(e *contactsExport) {
+		e.columns = columns
+	}
+}
+
+// WithExportOffset resumes an export at an offset, as returned by ExportContacts or given
+// to the progress function. The CSV header is only written at offset 0.
+func WithExportOffset(offset int) ExportOptions {
+	return func(e *contactsExport) {
+		e.offset = offset
+	}
+}
+
+// WithExportPageSize sets the number of contacts read per page, 1000 by default.
+func WithExportPageSize(size int) ExportOptions {
+	return func(e *contactsExport) {
+		e.pageSize = size
+	}
+}
+
+// WithExportProgress sets a function called with the offset of the next contact after each
+// page is written, to save it and resume an interrupted export with WithExportOffset.
+func WithExportProgress(progress func(next int)) ExportOptions {
+	return func(e *contactsExport) {
+		e.progress = progress
+	}
+}
+
+// exportRow is a contact joined with its properties and lists.
+type exportRow struct {
+	contact    resources.Contact
+	properties map[string]string
+	lists      []resources.ContactGetcontactslists
+}
+
+// ExportContacts writes the contacts of the account or of a list to w, sorted by ID, with
+// their properties and the lists they are subscribed to. It returns the offset of the next
+// contact, from which an interrupted export can be resumed with WithExportOffset.
+func (c *Client) ExportContacts(ctx context.Context, w io.Writer, options ...ExportOptions) (next int, err error) {
+	e := &contactsExport{format: ExportCSV, pageSize: defaultExportPageSize}
+	for _, option := range options {
+		option(e)
+	}
+	if e.pageSize <= 0 || e.pageSize > 1000 {
+		e.pageSize = defaultExportPageSize
+	}
+	if e.columns == nil {
+		if e.columns, err = c.defaultExportColumns(ctx); err != nil {
+			return e.offset, err
+		}
+	}
+
+	var csvWriter *csv.Writer
+	if e.format == ExportCSV {
+		csvWriter = csv.NewWriter(w)
+		if e.offset == 0 {
+			if err = csvWriter.Write(e.columns); err != nil {
+				return e.offset, err
+			}
+		}
+	}
+
+	next = e.offset
+	for {
+		rows, err := c.exportPage(ctx, e, next)
+		if err != nil {
+			return next, err
+		}
+		for _, row := range rows {
+			if csvWriter != nil {
+				err = csvWriter.Write(e.csvRecord(row))
+			} else {
+				err = e.writeJSON(w, row)
+			}
+			if err != nil {
+				return next, err
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err = csvWriter.Error(); err != nil {
+				return next, err
+			}
+		}
+		next += len(rows)
+		if e.progress != nil {
+			e.progress(next)
+		}
+		if len(rows) < e.pageSize {
+			return next, nil
+		}
+	}
+}
+
+// defaultExportColumns returns the default columns, with the contact properties.
+func (c *Client) defaultExportColumns(ctx context.Context) ([]string, error) {
+	columns := []string{ExportColumnID, ExportColumnEmail, ExportColumnName, ExportColumnIsExcludedFromCampaigns, ExportColumnCreatedAt}
+	metadata, err := c.contactMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metadata {
+		columns = append(columns, m.Name)
+	}
+	return append(columns, ExportColumnLists), nil
+}
+
+// exportPage reads the contacts of the page at offset, with their properties and lists.
+func (c *Client) exportPage(ctx context.Context, e *contactsExport, offset int) ([]exportRow, error) {
+	filters := []RequestOptions{
+		Filter("Limit", strconv.Itoa(e.pageSize)), Filter("Offset", strconv.Itoa(offset)), WithContext(ctx),
+	}
+	if e.listID != 0 {
+		filters = append(filters, ContactFilters.ContactsList(e.listID))
+	}
+
+	var contacts []resources.Contact
+	if _, _, err := c.List("contact", &contacts, append(filters, Sort("ID", SortAsc))...); err != nil {
+		return nil, fmt.Errorf("mailjet: reading contact: %w", err)
+	}
+	rows := make([]exportRow, len(contacts))
+	for i, contact := range contacts {
+		rows[i].contact = contact
+	}
+	if e.needsProperties() {
+		if err := c.exportProperties(ctx, e, filters, rows); err != nil {
+			return nil, err
+		}
+	}
+	if e.hasColumn(ExportColumnLists) {
+		if e.listID != 0 {
+			// The contacts of a list may be a small part of the account: only their
+			// subscriptions are read.
+			for i := range rows {
+				lists := make(map[int64][]resources.ContactGetcontactslists)
+				if err := c.exportLists(ctx, lists, ListrecipientFilters.Contact(rows[i].contact.ID)); err != nil {
+					return nil, err
+				}
+				rows[i].lists = lists[rows[i].contact.ID]
+			}
+			return rows, nil
+		}
+		if e.lists == nil {
+			e.lists = make(map[int64][]resources.ContactGetcontactslists)
+			if err := c.exportLists(ctx, e.lists); err != nil {
+				return nil, err
+			}
+		}
+		for i := range rows {
+			rows[i].lists = e.lists[rows[i].contact.ID]
+		}
+	}
+	return rows, nil
+}
+
+// exportLists reads the subscriptions of the contacts matching the filters into lists,
+// by contact ID, from listrecipient, page by page, rather than the lists of each contact
+// with getcontactslists.
+func (c *Client) exportLists(ctx context.Context, lists map[int64][]resources.ContactGetcontactslists, filters ...RequestOptions) error {
+	return listPages(ctx, "listrecipient", func(page ...RequestOptions) (int, error) {
+		var recipients []resources.Listrecipient
+		options := append(append(page, filters...), ListrecipientFilters.IgnoreDeleted(true), Sort("ID", SortAsc))
+		count, _, err := c.List("listrecipient", &recipients, options...)
+		for _, r := range recipients {
+			lists[r.ContactID] = append(lists[r.ContactID], resources.ContactGetcontactslists{
+				ListID: r.ListID, IsUnsub: r.IsUnsubscribed, IsActive: r.IsActive,
+			})
+		}
+		return count, err
+	})
+}
+
+// exportProperties sets the properties of the rows, from the contactdata of the same page,
+// and from the contactdata of each contact missing from it.
+func (c *Client) exportProperties(ctx context.Context, e *contactsExport, filters []RequestOptions, rows []exportRow) error {
+	type contactData struct {
+		ContactID int64
+		Data      []struct {
+			Name  string
+			Value interface{}
+		}
+	}
+	var page []contactData
+	if _, _, err := c.List("contactdata", &page, append(filters, Sort("ContactID", SortAsc))...); err != nil {
+		return fmt.Errorf("mailjet: reading contactdata: %w", err)
+	}
+	byContact := make(map[int64]contactData, len(page))
+	for _, d := range page {
+		byContact[d.ContactID] = d
+	}
+
+	for i := range rows {
+		d, ok := byContact[rows[i].contact.ID]
+		if !ok {
+			var res []contactData
+			if err := c.Get(&Request{Resource: "contactdata", ID: rows[i].contact.ID}, &res, WithContext(ctx)); err != nil {
+				return fmt.Errorf("mailjet: reading the contactdata of contact %d: %w", rows[i].contact.ID, err)
+			}
+			if len(res) > 0 {
+				d = res[0]
+			}
+		}
+		rows[i].properties = make(map[string]string, len(d.Data))
+		for _, p := range d.Data {
+			rows[i].properties[p.Name] = contactValue(p.Value)
+		}
+	}
+	return nil
+}
+
+// isContactColumn reports whether a column is a property of the contact resource.
+func isContactColumn(column string) bool {
+	switch column {
+	case ExportColumnID, ExportColumnEmail, ExportColumnName, ExportColumnIsExcludedFromCampaigns,
+		ExportColumnCreatedAt, ExportColumnLastActivityAt, ExportColumnLists:
+		return true
+	}
+	return false
+}
+
+func (e *contactsExport) hasColumn(column string) bool {
+	for _, c := range e.columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *contactsExport) needsProperties() bool {
+	for _, c := range e.columns {
+		if !isContactColumn(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the value of a column of a row, for JSON.
+func (row exportRow) value(column string) interface{} {
+	switch column {
+	case ExportColumnID:
+		return row.contact.ID
+	case ExportColumnEmail:
+		return row.contact.Email
+	case ExportColumnName:
+		return row.contact.Name
+	case ExportColumnIsExcludedFromCampaigns:
+		return row.contact.IsExcludedFromCampaigns
+	case ExportColumnCreatedAt:
+		return exportTime(row.contact.CreatedAt)
+	case ExportColumnLastActivityAt:
+		return exportTime(row.contact.LastActivityAt)
+	case ExportColumnLists:
+		lists := make([]map[string]interface{}, 0, len(row.lists))
+		for _, l := range row.lists {
+			lists = append(lists, map[string]interface{}{"ListID": l.ListID, "IsUnsub": l.IsUnsub})
+		}
+		return lists
+	}
+	return row.properties[column]
+}
+
+func exportTime(t *resources.RFC3339DateTime) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (e *contactsExport) csvRecord(row exportRow) []string {
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		switch value := row.value(column).(type) {
+		case string:
+			record[i] = value
+		case []map[string]interface{}:
+			lists := make([]string, len(value))
+			for j, l := range value {
+				status := "subscribed"
+				if l["IsUnsub"] == true {
+					status = "unsubscribed"
+				}
+				lists[j] = fmt.Sprintf("%d:%s", l["ListID"], status)
+			}
+			record[i] = strings.Join(lists, ";")
+		default:
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return record
+}
+
+func (e *contactsExport) writeJSON(w io.Writer, row exportRow) error {
+	object := make(map[string]interface{}, len(e.columns))
+	for _, column := range e.columns {
+		object[column] = row.value(column)
+	}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
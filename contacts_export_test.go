@@ -0,0 +1,108 @@
+package mailjet_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestExportContacts(t *testing.T) {
+	srv, client := newFakeClient(t)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"}, resources.Contactslist{Name: "Crew"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("contactmetadata", resources.Contactmetadata{Name: "firstname", Datatype: "str"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	contacts, err := srv.Seed("contact",
+		resources.Contact{Email: "passenger1@mailjet.com", Name: "P1"},
+		resources.Contact{Email: "passenger2@mailjet.com", Name: "P2"},
+		resources.Contact{Email: "pilot@mailjet.com", Name: "Pilot"},
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	_, err = srv.Seed("listrecipient",
+		resources.Listrecipient{ContactID: contacts[0], ListID: lists[0]},
+		resources.Listrecipient{ContactID: contacts[1], ListID: lists[0], IsUnsubscribed: true},
+		resources.Listrecipient{ContactID: contacts[1], ListID: lists[1]},
+		resources.Listrecipient{ContactID: contacts[2], ListID: lists[1]},
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = client.SetContactProperties(context.Background(), "passenger2@mailjet.com", &struct {
+		FirstName string `mailjet:"firstname"`
+	}{"John"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	requests := make(map[string]int)
+	var recipients []string
+	client.SetClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests[r.URL.Path]++
+		if r.URL.Path == "/v3/REST/listrecipient" {
+			recipients = append(recipients, r.URL.Query().Get("Contact"))
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})})
+
+	var b bytes.Buffer
+	var progress []int
+	next, err := client.ExportContacts(context.Background(), &b, mailjet.WithExportList(lists[0]),
+		mailjet.WithExportPageSize(1), mailjet.WithExportProgress(func(next int) { progress = append(progress, next) }))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	if next != 2 || len(lines) != 4 || fmt.Sprint(progress) != "[1 2 2]" {
+		t.Fatalf("Wrong export: %d %v\n%s", next, progress, b.String())
+	}
+	if lines[0] != "ID,Email,Name,IsExcludedFromCampaigns,CreatedAt,firstname,Lists" {
+		t.Errorf("Wrong header: %s", lines[0])
+	}
+	want := fmt.Sprintf("passenger2@mailjet.com,P2,false,%s,John,%d:unsubscribed;%d:subscribed",
+		strings.Split(lines[2], ",")[4], lists[0], lists[1])
+	if !strings.HasSuffix(lines[2], want) {
+		t.Errorf("Wrong line: %s, want %s", lines[2], want)
+	}
+	// Only the subscriptions of the exported contacts are read.
+	if fmt.Sprint(recipients) != fmt.Sprint([]int64{contacts[0], contacts[1]}) ||
+		requests[fmt.Sprintf("/v3/REST/contact/%d/getcontactslists", contacts[1])] != 0 {
+		t.Errorf("Expected the lists of the exported contacts to be read from listrecipient, got %v %v", recipients, requests)
+	}
+
+	// The subscriptions of all the contacts are read once for an export of the account.
+	b.Reset()
+	recipients = nil
+	if _, err = client.ExportContacts(context.Background(), &b, mailjet.WithExportPageSize(1)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(recipients) != 1 || recipients[0] != "" || !strings.Contains(b.String(), "pilot@mailjet.com,Pilot,false,") {
+		t.Errorf("Expected a single listrecipient request, got %v\n%s", recipients, b.String())
+	}
+
+	b.Reset()
+	next, err = client.ExportContacts(context.Background(), &b, mailjet.WithExportFormat(mailjet.ExportJSONL),
+		mailjet.WithExportColumns("Email", "firstname"), mailjet.WithExportOffset(1))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want = `{"Email":"passenger2@mailjet.com","firstname":"John"}` + "\n" + `{"Email":"pilot@mailjet.com","firstname":""}` + "\n"
+	if next != 3 || b.String() != want {
+		t.Errorf("Wrong export: %d\n%s", next, b.String())
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,178 @@
+package mailjet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Methods of a contacts import, applied to each contact of the file.
+const (
+	// ImportAddForce adds the contacts to the list, subscribing again the unsubscribed ones.
+	ImportAddForce = "addforce"
+	// ImportAddNoForce adds the contacts to the list, leaving the unsubscribed ones unsubscribed.
+	ImportAddNoForce = "addnoforce"
+	// ImportRemove removes the contacts from the list.
+	ImportRemove = "remove"
+	// ImportUnsub unsubscribes the contacts from the list.
+	ImportUnsub = "unsub"
+	// ImportExcludeMarketing excludes the contacts from the marketing campaigns.
+	ImportExcludeMarketing = "excludemarketing"
+	// ImportIncludeMarketing includes the contacts in the marketing campaigns.
+	ImportIncludeMarketing = "includemarketing"
+)
+
+// Statuses of the batch jobs which are done.
+const (
+	JobStatusCompleted = "Completed"
+	JobStatusError     = "Error"
+	JobStatusAbort     = "Abort"
+)
+
+// ImportContactsOptions are functional options of ImportContacts.
+type ImportContactsOptions func(*contactsImport)
+
+type contactsImport struct {
+	method        string
+	errThreshold  int
+	importOptions string
+	backoff       Backoff
+	progress      func(resources.Csvimport)
+}
+
+// WithImportMethod sets the method of the import, ImportAddNoForce by default.
+func WithImportMethod(method string) ImportContactsOptions {
+	return func(i *contactsImport) {
+		i.method = method
+	}
+}
+
+// WithImportErrorThreshold sets the percentage of lines in error above which the API stops the import.
+func WithImportErrorThreshold(percent int) ImportContactsOptions {
+	return func(i *contactsImport) {
+		i.errThreshold = percent
+	}
+}
+
+// WithImportOptions sets the ImportOptions of the job, a JSON object such as
+// `{"DateTimeFormat": "yyyy/mm/dd", "TimezoneOffset": 2, "FieldNames": ["email", "firstname"]}`.
+func WithImportOptions(options string) ImportContactsOptions {
+	return func(i *contactsImport) {
+		i.importOptions = options
+	}
+}
+
+// WithImportBackoff sets the schedule of the polls of the job, DefaultBackoff by default.
+func WithImportBackoff(backoff Backoff) ImportContactsOptions {
+	return func(i *contactsImport) {
+		i.backoff = backoff
+	}
+}
+
+// WithImportProgress sets a function called with the job after each poll,
+// to follow its progress with Current, Count and Errcount.
+func WithImportProgress(progress func(job resources.Csvimport)) ImportContactsOptions {
+	return func(i *contactsImport) {
+		i.progress = progress
+	}
+}
+
+// ImportError is returned by ImportContacts when the import failed or has lines in error.
+type ImportError struct {
+	Job resources.Csvimport
+	// Report is the CSV file of the lines in error, as returned by the API.
+	Report []byte
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("mailjet: contacts import %d: %s with %d errors out of %d lines",
+		e.Job.ID, e.Job.Status, e.Job.Errcount, e.Job.Count)
+}
+
+// ImportContacts imports a CSV file of contacts into a list: it uploads the file, starts
+// a csvimport job and polls it until it is done. The first line of the file names the
+// columns, e.g. "email,name,firstname", the other ones being contact properties.
+// When the job fails or has lines in error, it returns the job and an *ImportError
+// with the report of the lines in error.
+func (c *Client) ImportContacts(ctx context.Context, listID int64, csv io.Reader, options ...ImportContactsOptions) (*resources.Csvimport, error) {
+	imp := &contactsImport{method: ImportAddNoForce, backoff: DefaultBackoff}
+	for _, option := range options {
+		option(imp)
+	}
+
+	content, err := ioutil.ReadAll(csv)
+	if err != nil {
+		return nil, err
+	}
+	var raw []byte
+	err = c.PostData(&FullDataRequest{
+		Info:    &DataRequest{SourceType: "contactslist", SourceTypeID: listID, DataType: "CSVData", MimeType: "text:plain"},
+		Payload: content,
+	}, &raw, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var upload struct {
+		ID int64
+	}
+	if err = json.Unmarshal(raw, &upload); err != nil {
+		return nil, fmt.Errorf("mailjet: decoding the upload of the contacts: %w", err)
+	}
+
+	var jobs []resources.Csvimport
+	err = c.Post(&FullRequest{
+		Info: &Request{Resource: "csvimport"},
+		Payload: resources.Csvimport{
+			ContactsListID: listID,
+			DataID:         upload.ID,
+			ErrTreshold:    imp.errThreshold,
+			ImportOptions:  imp.importOptions,
+			Method:         imp.method,
+		},
+	}, &jobs, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("mailjet: no csvimport job created")
+	}
+	job := jobs[0]
+
+	err = imp.backoff.poll(ctx, func() (bool, error) {
+		jobs = nil
+		if err := c.Get(&Request{Resource: "csvimport", ID: job.ID}, &jobs, WithContext(ctx)); err != nil {
+			return false, err
+		}
+		if len(jobs) > 0 {
+			job = jobs[0]
+		}
+		if imp.progress != nil {
+			imp.progress(job)
+		}
+		switch job.Status {
+		case JobStatusCompleted, JobStatusError, JobStatusAbort:
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return &job, err
+	}
+
+	if job.Status == JobStatusCompleted && job.Errcount == 0 {
+		return &job, nil
+	}
+	importErr := &ImportError{Job: job}
+	if job.Errcount > 0 {
+		err = c.GetData(&DataRequest{SourceType: "BatchJob", SourceTypeID: job.ID, DataType: "CSVError", MimeType: "text:csv"},
+			&importErr.Report, WithContext(ctx))
+		if err != nil {
+			return &job, fmt.Errorf("%s, fetching the error report: %w", importErr, err)
+		}
+	}
+	return &job, importErr
+}
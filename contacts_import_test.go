@@ -0,0 +1,83 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+var fastBackoff = mailjet.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Factor: 2}
+
+func TestImportContacts(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("contactmetadata", resources.Contactmetadata{Name: "firstname", Datatype: "str"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var progress []string
+	csv := "email,name,firstname\npassenger1@mailjet.com,P1,Jane\npassenger2@mailjet.com,P2,John\n"
+	job, err := client.ImportContacts(context.Background(), ids[0], strings.NewReader(csv),
+		mailjet.WithImportMethod(mailjet.ImportAddForce),
+		mailjet.WithImportBackoff(fastBackoff),
+		mailjet.WithImportProgress(func(job resources.Csvimport) { progress = append(progress, job.Status) }),
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if job.Status != mailjet.JobStatusCompleted || job.Count != 2 || job.Current != 2 {
+		t.Fatalf("Wrong job: %+v", job)
+	}
+	if strings.Join(progress, ",") != "In Progress,Completed" {
+		t.Errorf("Wrong progress: %v", progress)
+	}
+
+	var recipients []resources.Listrecipient
+	count, _, err := client.List("listrecipient", &recipients, mailjet.ListrecipientFilters.ContactsList(ids[0]))
+	if err != nil || count != 2 {
+		t.Fatalf("Wrong recipients: %d (%v)", count, err)
+	}
+	var props struct {
+		FirstName string `mailjet:"firstname"`
+	}
+	if err = client.GetContactProperties(context.Background(), "passenger2@mailjet.com", &props); err != nil || props.FirstName != "John" {
+		t.Fatalf("Wrong properties: %+v (%v)", props, err)
+	}
+}
+
+func TestImportContactsErrors(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	csv := "email\npassenger1@mailjet.com\nnot an address\n"
+	job, err := client.ImportContacts(context.Background(), ids[0], strings.NewReader(csv),
+		mailjet.WithImportBackoff(fastBackoff), mailjet.WithImportErrorThreshold(10))
+	var importErr *mailjet.ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("Expected an ImportError, got %v", err)
+	}
+	if job.Status != mailjet.JobStatusError || job.Errcount != 1 {
+		t.Errorf("Wrong job: %+v", job)
+	}
+	if !strings.HasPrefix(string(importErr.Report), "email,error\nnot an address,") {
+		t.Errorf("Wrong report: %q", importErr.Report)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = client.ImportContacts(ctx, ids[0], strings.NewReader(csv),
+		mailjet.WithImportBackoff(fastBackoff), mailjet.WithImportProgress(func(resources.Csvimport) { cancel() }))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a canceled import, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package mailjet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// AuditEntry is a request made for a privacy request.
+type AuditEntry struct {
+	At     time.Time
+	Method string
+	URL    string
+	// Objects is the number of objects returned.
+	Objects int
+	// Error is the error of the request, empty when it succeeded.
+	Error string
+}
+
+// DataSubjectExport is the data held by Mailjet about a contact, as exported by DataSubject.Access.
+// Contact is nil when there is no contact with this address.
+type DataSubjectExport struct {
+	Email       string
+	ExportedAt  time.Time
+	Contact     *resources.Contact                  `json:",omitempty"`
+	Data        []ContactDataItem                   `json:",omitempty"`
+	HistoryData []resources.Contacthistorydata      `json:",omitempty"`
+	Lists       []resources.ContactGetcontactslists `json:",omitempty"`
+	Messages    []DataSubjectMessage                `json:",omitempty"`
+	Audit       []AuditEntry
+}
+
+// ContactDataItem is a contact property, as returned by contactdata.
+type ContactDataItem struct {
+	Name  string
+	Value interface{}
+}
+
+// DataSubjectMessage is a message sent to the contact, with its events.
+type DataSubjectMessage struct {
+	resources.Message
+	History []resources.Messagehistory
+}
+
+// DataSubject handles the privacy requests of a contact, given by e-mail address: the access
+// to its data with Access, and its erasure with Erase. Each request made is recorded in the
+// audit trail returned by Audit.
+type DataSubject struct {
+	client *Client
+	email  string
+
+	mu    sync.Mutex
+	audit []AuditEntry
+}
+
+// DataSubject returns the data subject with this e-mail address.
+func (c *Client) DataSubject(email string) *DataSubject {
+	return &DataSubject{client: c, email: email}
+}
+
+// Audit returns the requests made so far, in order.
+func (d *DataSubject) Audit() []AuditEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]AuditEntry(nil), d.audit...)
+}
+
+// record adds a request to the audit trail.
+func (d *DataSubject) record(method, url string, objects int, err error) {
+	entry := AuditEntry{At: time.Now().UTC(), Method: method, URL: url, Objects: objects}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.audit = append(d.audit, entry)
+}
+
+// get issues an audited GET on a resource.
+func (d *DataSubject) get(ctx context.Context, req *Request, resp interface{}, options ...RequestOptions) (int, error) {
+	url := buildURL(d.client.apiBase, req)
+	httpReq, err := createRequest(http.MethodGet, url, nil, nil, append(options, WithContext(ctx))...)
+	if err != nil {
+		return 0, err
+	}
+	d.client.Lock()
+	count, _, err := d.client.httpClient.Send(httpReq).Read(resp).Call()
+	d.client.Unlock()
+	d.record(http.MethodGet, httpReq.URL.String(), count, err)
+	return count, err
+}
+
+// contact returns the contact with the address of the data subject, nil if there is none.
+func (d *DataSubject) contact(ctx context.Context) (*resources.Contact, error) {
+	var contacts []resources.Contact
+	_, err := d.get(ctx, &Request{Resource: "contact", AltID: d.email}, &contacts)
+	var reqErr RequestError
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(contacts) == 0 {
+		return nil, nil
+	}
+	return &contacts[0], nil
+}
+
+// Access exports the data held about the contact: the contact, its properties and history
+// data, its subscriptions, and the messages sent to it with their events, as a single
+// document to encode in JSON. The export includes the audit trail of its requests, also
+// when it fails.
+func (d *DataSubject) Access(ctx context.Context) (export *DataSubjectExport, err error) {
+	export = &DataSubjectExport{Email: d.email, ExportedAt: time.Now().UTC()}
+	defer func() { export.Audit = d.Audit() }()
+
+	contact, err := d.contact(ctx)
+	if err != nil || contact == nil {
+		return export, err
+	}
+	export.Contact = contact
+	id := contact.ID
+
+	var data []struct {
+		Data []ContactDataItem
+	}
+	if _, err = d.get(ctx, &Request{Resource: "contactdata", ID: id}, &data); err != nil {
+		return export, err
+	}
+	if len(data) > 0 {
+		export.Data = data[0].Data
+	}
+
+	err = listPages(ctx, "contacthistorydata", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Contacthistorydata
+		count, err := d.get(ctx, &Request{Resource: "contacthistorydata"}, &page, append(filters, Filter("Contact", strconv.FormatInt(id, 10)))...)
+		export.HistoryData = append(export.HistoryData, page...)
+		return count, err
+	})
+	if err != nil {
+		return export, err
+	}
+
+	if _, err = d.get(ctx, &Request{Resource: "contact", ID: id, Action: "getcontactslists"}, &export.Lists); err != nil {
+		return export, err
+	}
+
+	err = listPages(ctx, "message", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Message
+		count, err := d.get(ctx, &Request{Resource: "message"}, &page, append(filters, MessageFilters.Contact(id))...)
+		for _, m := range page {
+			export.Messages = append(export.Messages, DataSubjectMessage{Message: m})
+		}
+		return count, err
+	})
+	if err != nil {
+		return export, err
+	}
+	for i := range export.Messages {
+		m := &export.Messages[i]
+		if _, err = d.get(ctx, &Request{Resource: "messagehistory", ID: m.ID}, &m.History); err != nil {
+			return export, err
+		}
+	}
+
+	return export, nil
+}
+
+// Erase deletes the contact with the contacts endpoint of the API v4, which deletes its
+// properties and subscriptions as well. It reports whether a contact was deleted: there is
+// nothing to erase when there is no contact with this address.
+func (d *DataSubject) Erase(ctx context.Context) (bool, error) {
+	contact, err := d.contact(ctx)
+	if err != nil || contact == nil {
+		return false, err
+	}
+
+	url := strings.TrimSuffix(d.client.apiBase, "/v3") + "/v4/contacts/" + strconv.FormatInt(contact.ID, 10)
+	req, err := createRequest(http.MethodDelete, url, nil, nil, WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	d.client.Lock()
+	_, _, err = d.client.httpClient.Send(req).Call()
+	d.client.Unlock()
+	d.record(http.MethodDelete, url, 0, err)
+	if err != nil {
+		return false, fmt.Errorf("mailjet: erasing contact %d: %w", contact.ID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package mailjet_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestDataSubject(t *testing.T) {
+	srv, client := newFakeClient(t)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	contacts, err := srv.Seed("contact", resources.Contact{Email: "passenger@mailjet.com", Name: "Passenger"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("listrecipient", resources.Listrecipient{ContactID: contacts[0], ListID: lists[0]}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("contacthistorydata", resources.Contacthistorydata{ContactID: contacts[0], Name: "purchase", Data: "42"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = client.SetContactProperties(context.Background(), "passenger@mailjet.com", &struct {
+		FirstName string `mailjet:"firstname"`
+	}{"Jane"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	_, err = client.SendMailV31(&mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{{
+		From:     &mailjet.RecipientV31{Email: "pilot@mailjet.com"},
+		To:       &mailjet.RecipientsV31{{Email: "passenger@mailjet.com"}},
+		Subject:  "Your flight",
+		TextPart: "Boarding at 10:00",
+	}}})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	subject := client.DataSubject("passenger@mailjet.com")
+	export, err := subject.Access(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if export.Contact == nil || export.Contact.ID != contacts[0] {
+		t.Fatalf("Wrong contact: %+v", export.Contact)
+	}
+	if len(export.Data) != 1 || export.Data[0].Value != "Jane" || len(export.HistoryData) != 1 || len(export.Lists) != 1 {
+		t.Errorf("Wrong export: %+v", export)
+	}
+	if len(export.Messages) != 1 || len(export.Messages[0].History) != 1 || export.Messages[0].History[0].EventType != "sent" {
+		t.Errorf("Wrong messages: %+v", export.Messages)
+	}
+	if len(export.Audit) != 6 {
+		t.Errorf("Wrong audit: %+v", export.Audit)
+	}
+	if _, err = json.Marshal(export); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	srv.FailNext(http.MethodGet, fmt.Sprintf("/v3/REST/contactdata/%d", contacts[0]), http.StatusServiceUnavailable)
+	failed, err := client.DataSubject("passenger@mailjet.com").Access(context.Background())
+	if err == nil || len(failed.Audit) != 2 || failed.Audit[1].Error == "" {
+		t.Fatalf("Expected the audit of the failed access, got %+v (%v)", failed.Audit, err)
+	}
+
+	erased, err := subject.Erase(context.Background())
+	if err != nil || !erased {
+		t.Fatalf("Contact not erased: %v", err)
+	}
+	audit := subject.Audit()
+	if last := audit[len(audit)-1]; last.Method != http.MethodDelete || last.URL != fmt.Sprintf("%s/v4/contacts/%d", strings.TrimSuffix(srv.URL, "/v3"), contacts[0]) || last.Error != "" {
+		t.Errorf("Wrong audit entry: %+v", last)
+	}
+	export, err = subject.Access(context.Background())
+	if err != nil || export.Contact != nil {
+		t.Fatalf("Contact not erased: %+v (%v)", export, err)
+	}
+	if erased, err = subject.Erase(context.Background()); err != nil || erased {
+		t.Errorf("Contact erased twice: %v", err)
+	}
+}
@@ -144,13 +144,16 @@ func exampleMailjetClientSendMailSMTP() {
 	header.Add("From", "qwe@qwe.com")
 	header.Add("To", "qwe@qwe.com")
 	header.Add("Subject", "Hello World!")
-	header.Add("X-Mailjet-Campaign", "test")
 	content := []byte("Hi there !")
 	info := &mailjet.InfoSMTP{
 		From:       "qwe@qwe.com",
 		Recipients: header["To"],
 		Header:     header,
 		Content:    content,
+		Options: &mailjet.InfoSMTPOptions{
+			CustomCampaign: "test",
+			CustomID:       "hello-world",
+		},
 	}
 	err := mj.SendMailSMTP(info)
 	if err != nil {
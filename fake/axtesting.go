@@ -0,0 +1,172 @@
+package fake
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Statuses of the A/X tests. A test is "programmed" once all its variants are scheduled,
+// "running" once they are all sent, and "finished" once its winner is selected: by its
+// WinnerMethod at RemainderAt in automatic mode, or with a PUT of WinnerID.
+const (
+	axDraft      = "draft"
+	axProgrammed = "programmed"
+	axRunning    = "running"
+	axFinished   = "finished"
+)
+
+// axTestingID returns the ID of the A/X test of a campaign draft or of campaign statistics.
+func axTestingID(o object) interface{} {
+	if m, ok := o["AXTesting"].(map[string]interface{}); ok {
+		return m["ID"]
+	}
+	return nil
+}
+
+// axTesting matches the objects of the A/X test.
+func axTesting(s *Server, o object, value string) bool {
+	return matches(axTestingID(o), value)
+}
+
+// resolveAXTesting checks the A/X test of a campaign draft, given as an object with an ID.
+func (s *Server) resolveAXTesting(o object) error {
+	id := axTestingID(o)
+	if id == nil || fmt.Sprint(id) == "0" {
+		delete(o, "AXTesting")
+		return nil
+	}
+	test := s.tables["axtesting"].find(fmt.Sprint(id))
+	if test == nil {
+		return validationError(fmt.Sprintf("MJ05 Object %v not found for AXTesting", id))
+	}
+	o["AXTesting"] = map[string]interface{}{"ID": test["ID"]}
+	return nil
+}
+
+// variants returns the campaign drafts of an A/X test.
+func (s *Server) variants(test object) []object {
+	var variants []object
+	for _, o := range s.tables["campaigndraft"].objects {
+		if sameValue(axTestingID(o), test["ID"]) {
+			variants = append(variants, o)
+		}
+	}
+	return variants
+}
+
+// prepareAXTest checks an A/X test before it is stored. A WinnerID set on a running test
+// selects its winner.
+func prepareAXTest(s *Server, o object) error {
+	if err := s.resolve(o, "ContactListID", "ContactListALT", "contactslist"); err != nil {
+		return err
+	}
+	switch fmt.Sprint(o["Mode"]) {
+	case "automatic", "manual":
+	default:
+		return validationError(fmt.Sprintf("Invalid value %q for Mode", o["Mode"]))
+	}
+	if _, ok := axMetrics[fmt.Sprint(o["WinnerMethod"])]; !ok {
+		return validationError(fmt.Sprintf("Invalid value %q for WinnerMethod", o["WinnerMethod"]))
+	}
+	winner := fmt.Sprint(o["WinnerID"])
+	if o["WinnerID"] == nil || winner == "0" || o["Status"] == axFinished {
+		return nil
+	}
+	if o["Status"] != axRunning {
+		return validationError("The winner can only be selected once all the variants are sent")
+	}
+	for _, variant := range s.variants(o) {
+		if matches(variant["ID"], winner) {
+			s.selectWinner(o, variant)
+			return nil
+		}
+	}
+	return validationError(fmt.Sprintf("Campaign draft %s is not a variant of the A/X test", winner))
+}
+
+// axMetrics are the metrics of the winner methods, computed from campaignstatistics in
+// percents, and whether the lowest value wins. MJScore is approximated by the click rate.
+var axMetrics = map[string]struct {
+	count  string
+	lowest bool
+}{
+	"OpenRate":  {"OpenedCount", false},
+	"ClickRate": {"ClickedCount", false},
+	"SpamRate":  {"SpamComplaintCount", true},
+	"UnsubRate": {"UnsubscribedCount", true},
+	"MJScore":   {"ClickedCount", false},
+}
+
+// rate returns the rate of a count of the statistics of a variant, in percents.
+func (s *Server) rate(variant object, count string) float64 {
+	var n, delivered float64
+	for _, o := range s.tables["campaignstatistics"].objects {
+		if sameValue(o["NewsLetterID"], variant["ID"]) {
+			v, _ := strconv.ParseFloat(fmt.Sprint(o[count]), 64)
+			d, _ := strconv.ParseFloat(fmt.Sprint(o["DeliveredCount"]), 64)
+			n += v
+			delivered += d
+		}
+	}
+	if delivered == 0 {
+		return 0
+	}
+	return 100 * n / delivered
+}
+
+// selectWinner sets the winner of an A/X test with its rates.
+func (s *Server) selectWinner(test, winner object) {
+	test["WinnerID"] = winner["ID"]
+	test["WinnerOpenRate"] = s.rate(winner, "OpenedCount")
+	test["WinnerClickRate"] = s.rate(winner, "ClickedCount")
+	test["WinnerSpamRate"] = s.rate(winner, "SpamComplaintCount")
+	test["WinnerUnsubRate"] = s.rate(winner, "UnsubscribedCount")
+	test["Status"] = axFinished
+}
+
+// runAXTests advances the status of the A/X tests. The caller must hold s.mu.
+func (s *Server) runAXTests() {
+	now := s.now()
+	for _, test := range s.tables["axtesting"].objects {
+		variants := s.variants(test)
+		switch test["Status"] {
+		case axDraft, axProgrammed:
+			if len(variants) == 0 {
+				continue
+			}
+			programmed, sent := 0, 0
+			for _, v := range variants {
+				switch draftStatus(v) {
+				case draftStatusProgrammed:
+					programmed++
+				case draftStatusSent:
+					sent++
+				}
+			}
+			switch {
+			case sent == len(variants):
+				test["Status"] = axRunning
+			case programmed+sent == len(variants):
+				test["Status"] = axProgrammed
+			default:
+				test["Status"] = axDraft
+			}
+		case axRunning:
+			remainder, err := time.Parse(time.RFC3339, fmt.Sprint(test["RemainderAt"]))
+			if test["Mode"] != "automatic" || err != nil || remainder.After(now) {
+				continue
+			}
+			metric := axMetrics[fmt.Sprint(test["WinnerMethod"])]
+			var winner object
+			var best float64
+			for _, v := range variants {
+				r := s.rate(v, metric.count)
+				if winner == nil || (metric.lowest && r < best) || (!metric.lowest && r > best) {
+					winner, best = v, r
+				}
+			}
+			s.selectWinner(test, winner)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Statuses of the campaign drafts.
+const (
+	draftStatusDraft      = 0
+	draftStatusProgrammed = 1
+	draftStatusSent       = 2
+)
+
+// Statuses of the campaigns. A campaign is "programmed" when its draft is sent, and is
+// reported "sending" then "sent" when it is read, so that clients see its progress.
+const (
+	campaignProgrammed = "programmed"
+	campaignSending    = "sending"
+	campaignSent       = "sent"
+)
+
+// campaignDraft is the state of a campaign draft kept out of its object: its content,
+// and the date it is scheduled at.
+type campaignDraft struct {
+	content  object
+	schedule time.Time
+}
+
+// draft returns the state of a campaign draft.
+func (s *Server) draft(o object) *campaignDraft {
+	key := fmt.Sprint(o["ID"])
+	d := s.drafts[key]
+	if d == nil {
+		d = &campaignDraft{}
+		s.drafts[key] = d
+	}
+	return d
+}
+
+// prepareDraft checks the A/X test of a campaign draft, and its list, which is only
+// needed to send it.
+func prepareDraft(s *Server, o object) error {
+	if err := s.resolveAXTesting(o); err != nil {
+		return err
+	}
+	if o["ContactsListID"] == nil && o["ContactsListALT"] == nil {
+		return nil
+	}
+	return s.resolve(o, "ContactsListID", "ContactsListALT", "contactslist")
+}
+
+// draftStatus returns the status of a campaign draft.
+func draftStatus(o object) int64 {
+	var status int64
+	fmt.Sscan(fmt.Sprint(o["Status"]), &status)
+	return status
+}
+
+// checkEditable returns an error when a campaign draft was scheduled or sent.
+func checkEditable(o object) error {
+	if status := draftStatus(o); status != draftStatusDraft {
+		return validationError(fmt.Sprintf("The campaign draft cannot be modified in status %d", status))
+	}
+	return nil
+}
+
+// checkSendable returns an error when a campaign draft cannot be scheduled or sent.
+func (s *Server) checkSendable(o object) error {
+	if err := checkEditable(o); err != nil {
+		return err
+	}
+	if s.draft(o).content == nil {
+		return validationError("The campaign draft has no content")
+	}
+	for _, key := range []string{"SenderEmail", "Subject", "ContactsListID"} {
+		if v, ok := o[key]; !ok || v == nil || v == "" {
+			return validationError(fmt.Sprintf("MJ03 A non-empty value is required for %s", key))
+		}
+	}
+	return nil
+}
+
+// draftContent serves GET campaigndraft/{id}/detailcontent.
+func draftContent(s *Server, o object) []object {
+	if content := s.draft(o).content; content != nil {
+		return []object{content}
+	}
+	return []object{{}}
+}
+
+// setDraftContent serves POST campaigndraft/{id}/detailcontent.
+func setDraftContent(s *Server, o object, payload object) ([]object, error) {
+	if err := checkEditable(o); err != nil {
+		return nil, err
+	}
+	empty := true
+	for _, key := range []string{"Text-part", "Html-part", "MJMLContent"} {
+		if v, ok := payload[key].(string); ok && v != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return nil, validationError(`At least "Html-part", "Text-part" or "MJMLContent" must be provided`)
+	}
+	s.draft(o).content = payload
+	o["ModifiedAt"] = s.timestamp()
+	return []object{payload}, nil
+}
+
+// testDraft serves POST campaigndraft/{id}/test.
+func testDraft(s *Server, o object, payload object) ([]object, error) {
+	if s.draft(o).content == nil {
+		return nil, validationError("The campaign draft has no content")
+	}
+	recipients, _ := payload["Recipients"].([]interface{})
+	if len(recipients) == 0 {
+		return nil, validationError("MJ03 A non-empty value is required for Recipients")
+	}
+	for _, r := range recipients {
+		email, _ := r.(map[string]interface{})["Email"].(string)
+		if !strings.Contains(email, "@") {
+			return nil, validationError(fmt.Sprintf("Invalid email %q", email))
+		}
+	}
+	return []object{{"Status": "Test:OK"}}, nil
+}
+
+// draftSchedule serves GET campaigndraft/{id}/schedule.
+func draftSchedule(s *Server, o object) []object {
+	d := s.draft(o)
+	if d.schedule.IsZero() || draftStatus(o) != draftStatusProgrammed {
+		return []object{}
+	}
+	return []object{{"Date": d.schedule.UTC().Format(time.RFC3339), "Status": "Programmed"}}
+}
+
+// scheduleDraft serves POST campaigndraft/{id}/schedule.
+func scheduleDraft(s *Server, o object, payload object) ([]object, error) {
+	if err := s.checkSendable(o); err != nil {
+		return nil, err
+	}
+	date, err := time.Parse(time.RFC3339, fmt.Sprint(payload["Date"]))
+	if err != nil {
+		return nil, validationError(fmt.Sprintf("Invalid value %q for Date", payload["Date"]))
+	}
+	if !date.After(s.now()) {
+		return nil, validationError("The Date must be in the future")
+	}
+	s.draft(o).schedule = date
+	o["Status"] = draftStatusProgrammed
+	return draftSchedule(s, o), nil
+}
+
+// unscheduleDraft serves DELETE campaigndraft/{id}/schedule.
+func unscheduleDraft(s *Server, o object) error {
+	if draftStatus(o) != draftStatusProgrammed {
+		return validationError("The campaign draft is not scheduled")
+	}
+	s.draft(o).schedule = time.Time{}
+	o["Status"] = draftStatusDraft
+	return nil
+}
+
+// sendDraft serves POST campaigndraft/{id}/send.
+func sendDraft(s *Server, o object, payload object) ([]object, error) {
+	if err := s.checkSendable(o); err != nil {
+		return nil, err
+	}
+	if err := s.send(o); err != nil {
+		return nil, err
+	}
+	return []object{{"Status": "Programmed"}}, nil
+}
+
+// send creates the campaign of a campaign draft.
+func (s *Server) send(o object) error {
+	campaign, err := s.insert(s.tables["campaign"], object{
+		"FromEmail":    o["SenderEmail"],
+		"FromName":     o["SenderName"],
+		"ListID":       o["ContactsListID"],
+		"NewsLetterID": o["ID"],
+		"Status":       campaignProgrammed,
+		"Subject":      o["Subject"],
+	})
+	if err != nil {
+		return err
+	}
+	o["Status"] = draftStatusSent
+	o["CampaignID"] = campaign["ID"]
+	return nil
+}
+
+// sendScheduled sends the campaign drafts whose schedule is due. The caller must hold s.mu.
+func (s *Server) sendScheduled() {
+	now := s.now()
+	for _, o := range s.tables["campaigndraft"].objects {
+		d := s.drafts[fmt.Sprint(o["ID"])]
+		if d != nil && !d.schedule.IsZero() && draftStatus(o) == draftStatusProgrammed && !d.schedule.After(now) {
+			_ = s.send(o)
+		}
+	}
+}
+
+// campaignProgress returns the campaign with this ID, advancing its status when it is read.
+func campaignProgress(s *Server, t *table, key string) object {
+	o := t.find(key)
+	if o == nil {
+		return nil
+	}
+	switch o["Status"] {
+	case campaignProgrammed:
+		o["Status"] = campaignSending
+		o["SendStartAt"] = s.timestamp()
+	case campaignSending:
+		o["Status"] = campaignSent
+		o["SendEndAt"] = s.timestamp()
+		for _, draft := range s.tables["campaigndraft"].objects {
+			if sameValue(draft["ID"], o["NewsLetterID"]) {
+				draft["DeliveredAt"] = o["SendEndAt"]
+			}
+		}
+	}
+	return o
+}
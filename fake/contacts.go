@@ -0,0 +1,183 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contactDatatypes are the datatypes of the contact properties, with the check of their values.
+var contactDatatypes = map[string]func(value string) error{
+	"str": func(string) error { return nil },
+	"int": func(value string) error {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err
+	},
+	"float": func(value string) error {
+		_, err := strconv.ParseFloat(value, 64)
+		return err
+	},
+	"bool": func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	"datetime": func(value string) error {
+		_, err := time.Parse(time.RFC3339, value)
+		return err
+	},
+}
+
+// contactData returns the contact data of the contact with this ID or e-mail.
+// As every contact has its data, it is created on first access.
+func contactData(s *Server, t *table, key string) object {
+	contact := s.tables["contact"].find(key)
+	if contact == nil {
+		return nil
+	}
+	if o := t.find(fmt.Sprint(contact["ID"])); o != nil {
+		return o
+	}
+	o := object{"ID": contact["ID"], "ContactID": contact["ID"], "Data": []interface{}{}}
+	t.objects = append(t.objects, o)
+	return o
+}
+
+// mergeContactData checks the properties of an update of contact data, and merges them
+// with the properties already set.
+func mergeContactData(s *Server, o object) error {
+	updates, ok := o["Data"].([]interface{})
+	if !ok {
+		return validationError("Invalid value for Data")
+	}
+	metadata := make(map[string]string)
+	for _, m := range s.tables["contactmetadata"].objects {
+		metadata[fmt.Sprint(m["Name"])] = fmt.Sprint(m["Datatype"])
+	}
+
+	var data []interface{}
+	if current := s.tables["contactdata"].find(fmt.Sprint(o["ID"])); current != nil {
+		existing, _ := current["Data"].([]interface{})
+		data = append(data, existing...)
+	}
+	for _, u := range updates {
+		update, ok := u.(map[string]interface{})
+		if !ok {
+			return validationError("Invalid value for Data")
+		}
+		name, value := fmt.Sprint(update["Name"]), ""
+		if update["Value"] != nil {
+			value = fmt.Sprint(update["Value"])
+		}
+		datatype, ok := metadata[name]
+		if !ok {
+			return validationError(fmt.Sprintf("Property %q is not defined in contactmetadata", name))
+		}
+		if value != "" {
+			if err := contactDatatypes[datatype](value); err != nil {
+				return validationError(fmt.Sprintf("Invalid %s value %q for %s", datatype, value, name))
+			}
+		}
+		entry := map[string]interface{}{"Name": name, "Value": value}
+		replaced := false
+		for i, d := range data {
+			if existing, ok := d.(map[string]interface{}); ok && existing["Name"] == name {
+				data[i], replaced = entry, true
+			}
+		}
+		if !replaced {
+			data = append(data, entry)
+		}
+	}
+	o["Data"] = data
+	return nil
+}
+
+// contactsLists returns the subscriptions of a contact, as its getcontactslists action.
+func contactsLists(s *Server, contact object) []object {
+	lists := []object{}
+	for _, r := range s.tables["listrecipient"].objects {
+		if sameValue(r["ContactID"], contact["ID"]) {
+			lists = append(lists, object{
+				"ListID": r["ListID"], "IsUnsub": r["IsUnsubscribed"], "IsActive": r["IsActive"], "SubscribedAt": r["SubscribedAt"],
+			})
+		}
+	}
+	return lists
+}
+
+// manageContactsLists applies the list actions of the managecontactslists action of a contact.
+// As the real API, no action is applied when one of them is invalid.
+func manageContactsLists(s *Server, contact object, payload object) ([]object, error) {
+	items, _ := payload["ContactsLists"].([]interface{})
+	var lists []listAction
+	for _, item := range items {
+		m, _ := item.(map[string]interface{})
+		if s.tables["contactslist"].find(fmt.Sprint(m["ListID"])) == nil {
+			return nil, validationError(fmt.Sprintf("Unknown contactslist %v", m["ListID"]))
+		}
+		action := strings.ToLower(fmt.Sprint(m["Action"]))
+		if !importMethods[action] {
+			return nil, validationError(fmt.Sprintf("Invalid value %q for Action", m["Action"]))
+		}
+		lists = append(lists, listAction{m["ListID"], action})
+	}
+	results := []interface{}{}
+	for _, l := range lists {
+		if err := s.subscribe(contact, l.listID, l.action); err != nil {
+			return nil, validationError(err.Error())
+		}
+		results = append(results, map[string]interface{}{"ListID": l.listID, "Action": l.action})
+	}
+	return []object{{"ContactsLists": results}}, nil
+}
+
+// handleContactsV4 serves DELETE /v4/contacts/{id}, which deletes a contact with its data,
+// history data and subscriptions. Its messages are kept without the contact.
+func (s *Server) handleContactsV4(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v4/contacts/"), "/")
+	contact := s.tables["contact"].find(id)
+	if contact == nil || !matches(contact["ID"], id) {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return
+	}
+	for _, name := range []string{"contact", "contactdata"} {
+		s.tables[name].remove(func(o object) bool { return sameValue(o["ID"], contact["ID"]) })
+	}
+	for _, name := range []string{"contacthistorydata", "listrecipient"} {
+		s.tables[name].remove(func(o object) bool { return sameValue(o["ContactID"], contact["ID"]) })
+	}
+	for _, m := range s.tables["message"].objects {
+		if sameValue(m["ContactID"], contact["ID"]) {
+			m["ContactID"] = nil
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// manageContactAction applies the managecontact action of a list: it creates or updates
+// the contact of the payload and applies its Action to the subscription.
+func manageContactAction(s *Server, list object, payload object) ([]object, error) {
+	action := strings.ToLower(fmt.Sprint(payload["Action"]))
+	if !importMethods[action] {
+		return nil, validationError(fmt.Sprintf("Invalid value %q for Action", payload["Action"]))
+	}
+	email, _ := payload["Email"].(string)
+	email = strings.TrimSpace(email)
+	if err := s.manageContact(payload, email, []listAction{{list["ID"], action}}); err != nil {
+		return nil, validationError(err.Error())
+	}
+	contact := s.tables["contact"].find(email)
+	return []object{{
+		"ContactID": contact["ID"], "Email": email, "Name": contact["Name"],
+		"Action": action, "Properties": payload["Properties"],
+	}}, nil
+}
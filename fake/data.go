@@ -0,0 +1,125 @@
+package fake
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// dataObject is a file uploaded with the DATA API.
+type dataObject struct {
+	id          int64
+	contentType string
+	body        []byte
+}
+
+// handleData serves /v3/DATA/{SourceType}/{SourceTypeID}/{DataType}[/{MimeType}][/{DataTypeID}|/LAST].
+func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v3/DATA/"), "/"), "/")
+	if len(tokens) < 3 {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return
+	}
+	sourceType, sourceID, dataType := strings.ToLower(tokens[0]), tokens[1], strings.ToLower(tokens[2])
+	tokens = tokens[3:]
+	contentType := ""
+	if len(tokens) > 0 && strings.Contains(tokens[0], ":") {
+		contentType = strings.Replace(tokens[0], ":", "/", 1)
+		tokens = tokens[1:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := s.tables[sourceType]; t != nil {
+		source := t.find(sourceID)
+		if source == nil {
+			writeError(w, http.StatusNotFound, "Object not found", "")
+			return
+		}
+		sourceID = fmt.Sprint(source["ID"])
+	}
+	key := sourceType + "/" + sourceID + "/" + dataType
+
+	if len(tokens) == 0 && r.Method == http.MethodGet {
+		tokens = []string{"LAST"}
+	}
+	if len(tokens) == 0 {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Unable to read the request body", err.Error())
+			return
+		}
+		if contentType == "" {
+			contentType = r.Header.Get("Content-Type")
+		}
+		d := &dataObject{id: s.nextID(), contentType: contentType, body: body}
+		s.data[key] = append(s.data[key], d)
+		writeJSON(w, http.StatusOK, map[string]int64{"ID": d.id})
+		return
+	}
+
+	i := s.findData(key, tokens[0])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return
+	}
+	d := s.data[key][i]
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", d.contentType)
+		_, _ = w.Write(d.body)
+	case http.MethodPut:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Unable to read the request body", err.Error())
+			return
+		}
+		d.body = body
+		writeJSON(w, http.StatusOK, map[string]int64{"ID": d.id})
+	case http.MethodDelete:
+		s.data[key] = append(s.data[key][:i], s.data[key][i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+	}
+}
+
+// findData returns the index of the data object with this ID, or of the last one for "LAST".
+// The caller must hold s.mu.
+func (s *Server) findData(key, id string) int {
+	objects := s.data[key]
+	if strings.EqualFold(id, "LAST") {
+		return len(objects) - 1
+	}
+	for i, d := range objects {
+		if strconv.FormatInt(d.id, 10) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Data returns the content of a file uploaded with the DATA API,
+// e.g. Data("contactslist", 42, "CSVData", 0) for the last CSV file uploaded to the list 42.
+func (s *Server) Data(sourceType string, sourceTypeID int64, dataType string, dataTypeID int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(sourceType) + "/" + strconv.FormatInt(sourceTypeID, 10) + "/" + strings.ToLower(dataType)
+	id := "LAST"
+	if dataTypeID != 0 {
+		id = strconv.FormatInt(dataTypeID, 10)
+	}
+	i := s.findData(key, id)
+	if i < 0 {
+		return nil, fmt.Errorf("fake: no %s data %s for %s %d", dataType, id, sourceType, sourceTypeID)
+	}
+	return append([]byte(nil), s.data[key][i].body...), nil
+}
@@ -0,0 +1,379 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Statuses of the batch jobs.
+const (
+	jobUpload     = "Upload"
+	jobInProgress = "In Progress"
+	jobCompleted  = "Completed"
+	jobError      = "Error"
+)
+
+// importMethods are the methods of a csvimport.
+var importMethods = map[string]bool{
+	"addforce": true, "addnoforce": true, "remove": true, "unsub": true,
+	"excludemarketing": true, "includemarketing": true,
+}
+
+// prepareImport checks a csvimport before it is stored.
+func prepareImport(s *Server, o object) error {
+	if !importMethods[strings.ToLower(fmt.Sprint(o["Method"]))] {
+		return validationError(fmt.Sprintf("Invalid value %q for Method", o["Method"]))
+	}
+	return s.resolve(o, "ContactsListID", "ContactsListALT", "contactslist")
+}
+
+// importJob returns the csvimport with this ID. A new job is started when it is first
+// read, and run when it is read again, so that clients see its progress.
+func importJob(s *Server, t *table, key string) object {
+	o := t.find(key)
+	if o == nil {
+		return nil
+	}
+	switch o["Status"] {
+	case jobUpload:
+		o["Status"] = jobInProgress
+		o["JobStart"] = s.timestamp()
+	case jobInProgress:
+		s.runImport(o)
+	}
+	return o
+}
+
+// runImport imports the CSV file of a csvimport into its list. The first line names
+// the columns: email, name, and the contact properties. The lines in error are stored
+// as the CSVError file of the job, with the error in a last column.
+func (s *Server) runImport(job object) {
+	listID := fmt.Sprint(job["ContactsListID"])
+	key := "contactslist/" + listID + "/csvdata"
+	i := s.findData(key, fmt.Sprint(job["DataID"]))
+	if i < 0 {
+		job["Status"] = jobError
+		job["JobEnd"] = s.timestamp()
+		return
+	}
+
+	reader := csv.NewReader(bytes.NewReader(s.data[key][i].body))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		job["Status"] = jobError
+		job["JobEnd"] = s.timestamp()
+		return
+	}
+	for j := range header {
+		header[j] = strings.TrimSpace(header[j])
+	}
+
+	var failed [][]string
+	count := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		count++
+		if err == nil {
+			err = s.importLine(job, header, record)
+		}
+		if err != nil {
+			failed = append(failed, append(record, err.Error()))
+		}
+	}
+
+	job["Count"], job["Current"], job["Errcount"] = count, count, len(failed)
+	job["Status"] = jobCompleted
+	threshold, _ := strconv.Atoi(fmt.Sprint(job["ErrTreshold"]))
+	if threshold > 0 && len(failed)*100 > threshold*count {
+		job["Status"] = jobError
+	}
+	job["JobEnd"] = s.timestamp()
+	if len(failed) > 0 {
+		var b bytes.Buffer
+		w := csv.NewWriter(&b)
+		_ = w.Write(append(append([]string(nil), header...), "error"))
+		_ = w.WriteAll(failed)
+		errorsKey := "batchjob/" + fmt.Sprint(job["ID"]) + "/csverror"
+		s.data[errorsKey] = append(s.data[errorsKey], &dataObject{id: s.nextID(), contentType: "text/csv", body: b.Bytes()})
+	}
+}
+
+// importLine applies the method of the job to the contact of a CSV line.
+func (s *Server) importLine(job object, header, record []string) error {
+	values := make(map[string]string)
+	for i, name := range header {
+		if i < len(record) {
+			values[strings.ToLower(name)] = strings.TrimSpace(record[i])
+		}
+	}
+	email := values["email"]
+	if !strings.Contains(email, "@") {
+		return fmt.Errorf("invalid email %q", email)
+	}
+
+	contacts := s.tables["contact"]
+	contact := contacts.find(email)
+	if contact == nil {
+		var err error
+		if contact, err = s.insert(contacts, object{"Email": email, "Name": values["name"]}); err != nil {
+			return err
+		}
+	}
+	var data []interface{}
+	for _, name := range header {
+		if lower := strings.ToLower(name); lower != "email" && lower != "name" {
+			data = append(data, map[string]interface{}{"Name": name, "Value": values[lower]})
+		}
+	}
+	if len(data) > 0 {
+		contactdata := s.tables["contactdata"]
+		o := contactData(s, contactdata, email)
+		update := object{"ID": o["ID"], "Data": data}
+		if err := mergeContactData(s, update); err != nil {
+			return err
+		}
+		o["Data"] = update["Data"]
+	}
+
+	return s.subscribe(contact, job["ContactsListID"], strings.ToLower(fmt.Sprint(job["Method"])))
+}
+
+// subscribe applies a contact list action to the subscription of a contact:
+// addforce, addnoforce, remove, unsub, excludemarketing or includemarketing.
+func (s *Server) subscribe(contact object, listID interface{}, action string) error {
+	switch action {
+	case "excludemarketing", "includemarketing":
+		contact["IsExcludedFromCampaigns"] = action == "excludemarketing"
+		return nil
+	}
+
+	recipients := s.tables["listrecipient"]
+	var recipient object
+	for _, r := range recipients.objects {
+		if sameValue(r["ContactID"], contact["ID"]) && sameValue(r["ListID"], listID) {
+			recipient = r
+		}
+	}
+	switch action {
+	case "remove":
+		if recipient != nil {
+			for i, r := range recipients.objects {
+				if sameObject(r, recipient) {
+					recipients.objects = append(recipients.objects[:i], recipients.objects[i+1:]...)
+					break
+				}
+			}
+		}
+		return nil
+	case "addforce", "addnoforce", "unsub":
+		if recipient == nil {
+			var err error
+			recipient, err = s.insert(recipients, object{"ContactID": contact["ID"], "ListID": listID})
+			if err != nil {
+				return err
+			}
+		}
+		if action == "addforce" {
+			recipient["IsUnsubscribed"] = false
+		} else if action == "unsub" {
+			recipient["IsUnsubscribed"] = true
+			recipient["UnsubscribedAt"] = s.timestamp()
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid action %q", action)
+}
+
+// contactsJob is a job of managemanycontacts or importlist. It is run when it is
+// started and reported "In Progress" on its first poll, so that clients see its progress.
+type contactsJob struct {
+	action string
+	polled bool
+	result object
+}
+
+// handleJobAction serves the asynchronous actions contact/managemanycontacts,
+// contactslist/{id}/managemanycontacts and contactslist/{id}/importlist, and the polls
+// of their jobs. It reports whether the path is one of them. The caller must hold s.mu.
+func (s *Server) handleJobAction(w http.ResponseWriter, r *http.Request, tokens []string) bool {
+	var list object
+	var action string
+	var rest []string
+	switch resource := strings.ToLower(tokens[0]); {
+	case resource == "contact" && len(tokens) > 1 && strings.EqualFold(tokens[1], "managemanycontacts"):
+		action, rest = "contact/managemanycontacts", tokens[2:]
+	case resource == "contactslist" && len(tokens) > 2 &&
+		(strings.EqualFold(tokens[2], "managemanycontacts") || strings.EqualFold(tokens[2], "importlist")):
+		if list = s.lookup(s.tables["contactslist"], tokens[1]); list == nil {
+			writeError(w, http.StatusNotFound, "Object not found", "")
+			return true
+		}
+		action, rest = "contactslist/"+strings.ToLower(tokens[2]), tokens[3:]
+	default:
+		return false
+	}
+
+	if len(rest) == 0 {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+			return true
+		}
+		payload, err := decodeObject(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid json input", err.Error())
+			return true
+		}
+		id, err := s.startJob(action, list, payload)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "")
+			return true
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"Count": 1, "Data": []object{{"JobID": id}}, "Total": 1,
+		})
+		return true
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return true
+	}
+	id, _ := strconv.ParseInt(rest[0], 10, 64)
+	job := s.jobs[id]
+	if job == nil || job.action != action {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return true
+	}
+	result := job.result
+	if !job.polled {
+		job.polled = true
+		result = object{"Count": 0, "Error": "", "ErrorFile": "", "JobStart": result["JobStart"], "JobEnd": "", "Status": jobInProgress}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"Count": 1, "Data": []object{result}, "Total": 1})
+	return true
+}
+
+// startJob validates and runs a job of a contacts action, and returns its ID.
+// The contacts in error are stored as the CSVError file of the job.
+func (s *Server) startJob(action string, list, payload object) (int64, error) {
+	var lists []listAction
+	var contacts []interface{}
+	switch action {
+	case "contact/managemanycontacts":
+		actions, _ := payload["ContactsLists"].([]interface{})
+		for _, a := range actions {
+			m, _ := a.(map[string]interface{})
+			l := s.tables["contactslist"].find(fmt.Sprint(m["ListID"]))
+			if l == nil {
+				return 0, validationError(fmt.Sprintf("Invalid value %v for ListID", m["ListID"]))
+			}
+			lists = append(lists, listAction{l["ID"], strings.ToLower(fmt.Sprint(m["Action"]))})
+		}
+		contacts, _ = payload["Contacts"].([]interface{})
+	case "contactslist/managemanycontacts":
+		lists = []listAction{{list["ID"], strings.ToLower(fmt.Sprint(payload["Action"]))}}
+		contacts, _ = payload["Contacts"].([]interface{})
+	case "contactslist/importlist":
+		source := s.tables["contactslist"].find(fmt.Sprint(payload["ListID"]))
+		if source == nil {
+			return 0, validationError(fmt.Sprintf("Invalid value %v for ListID", payload["ListID"]))
+		}
+		lists = []listAction{{list["ID"], strings.ToLower(fmt.Sprint(payload["Action"]))}}
+		for _, r := range s.tables["listrecipient"].objects {
+			if !sameValue(r["ListID"], source["ID"]) {
+				continue
+			}
+			if c := s.tables["contact"].find(fmt.Sprint(r["ContactID"])); c != nil {
+				contacts = append(contacts, map[string]interface{}{"Email": c["Email"]})
+			}
+		}
+	}
+	for _, l := range lists {
+		if !importMethods[l.action] {
+			return 0, validationError(fmt.Sprintf("Invalid value %q for Action", l.action))
+		}
+	}
+
+	id := s.nextID()
+	start := s.timestamp()
+	var failed [][]string
+	for _, c := range contacts {
+		m, _ := c.(map[string]interface{})
+		email := strings.TrimSpace(fmt.Sprint(m["Email"]))
+		if err := s.manageContact(m, email, lists); err != nil {
+			failed = append(failed, []string{email, err.Error()})
+		}
+	}
+
+	result := object{
+		"Count": len(contacts), "Error": "", "ErrorFile": "",
+		"JobStart": start, "JobEnd": s.timestamp(), "Status": jobCompleted,
+	}
+	if len(failed) > 0 {
+		var b bytes.Buffer
+		w := csv.NewWriter(&b)
+		_ = w.Write([]string{"email", "error"})
+		_ = w.WriteAll(failed)
+		key := "batchjob/" + strconv.FormatInt(id, 10) + "/csverror"
+		s.data[key] = append(s.data[key], &dataObject{id: s.nextID(), contentType: "text/csv", body: b.Bytes()})
+		result["Error"] = fmt.Sprintf("%d contacts in error", len(failed))
+		result["ErrorFile"] = s.URL + "/DATA/BatchJob/" + strconv.FormatInt(id, 10) + "/CSVError/text:csv/LAST"
+	}
+	s.jobs[id] = &contactsJob{action: action, result: result}
+	return id, nil
+}
+
+// listAction is an action of a job on the subscriptions to a list.
+type listAction struct {
+	listID interface{}
+	action string
+}
+
+// manageContact creates or updates a contact of a job, with its name, exclusion from
+// campaigns and properties, and applies the list actions to it.
+func (s *Server) manageContact(m map[string]interface{}, email string, lists []listAction) error {
+	if !strings.Contains(email, "@") {
+		return fmt.Errorf("invalid email %q", email)
+	}
+	contacts := s.tables["contact"]
+	contact := contacts.find(email)
+	if contact == nil {
+		var err error
+		if contact, err = s.insert(contacts, object{"Email": email}); err != nil {
+			return err
+		}
+	}
+	if name, ok := m["Name"].(string); ok && name != "" {
+		contact["Name"] = name
+	}
+	if excluded, ok := m["IsExcludedFromCampaigns"].(bool); ok {
+		contact["IsExcludedFromCampaigns"] = excluded
+	}
+	if properties, ok := m["Properties"].(map[string]interface{}); ok && len(properties) > 0 {
+		var data []interface{}
+		for name, value := range properties {
+			data = append(data, map[string]interface{}{"Name": name, "Value": value})
+		}
+		o := contactData(s, s.tables["contactdata"], email)
+		update := object{"ID": o["ID"], "Data": data}
+		if err := mergeContactData(s, update); err != nil {
+			return err
+		}
+		o["Data"] = update["Data"]
+	}
+	for _, l := range lists {
+		if err := s.subscribe(contact, l.listID, l.action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,803 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pagination of the REST API.
+const (
+	DefaultLimit = 10
+	MaxLimit     = 1000
+)
+
+// object is a REST object, as decoded from JSON with numbers kept as json.Number.
+type object map[string]interface{}
+
+// filter reports whether o matches the value of a query filter.
+type filter func(s *Server, o object, value string) bool
+
+// resource describes a REST resource.
+type resource struct {
+	name     string
+	altID    string     // property addressing an object in place of its ID
+	required []string   // properties which must be set on creation
+	unique   [][]string // sets of properties identifying an object
+	defaults object
+	created  []string // properties set to the creation time
+	updated  []string // properties set to the update time
+	filters  map[string]filter
+	readOnly bool // objects are only created by the server
+	noCreate bool
+	noDelete bool
+	// find returns the object with this ID or AltID, in place of the table lookup.
+	find func(s *Server, t *table, key string) object
+	// findAll returns the objects of a GET on an ID, for the resources addressed by the ID
+	// of another object, such as the events of a message in messagehistory.
+	findAll func(s *Server, t *table, key string) []object
+	// prepare resolves the references of the object before it is stored.
+	prepare func(s *Server, o object) error
+	// view sets the computed properties of the object before it is returned.
+	view func(s *Server, o object)
+	// actions are the actions on an object, by lower-case name.
+	actions map[string]action
+}
+
+// action is an action on an object: get returns objects, post applies a payload,
+// and delete cancels it.
+type action struct {
+	get    func(s *Server, o object) []object
+	post   func(s *Server, o object, payload object) ([]object, error)
+	delete func(s *Server, o object) error
+}
+
+// table holds the objects of a resource in creation order.
+type table struct {
+	resource *resource
+	objects  []object
+}
+
+// validationError is a 400 error of the REST API.
+type validationError string
+
+func (e validationError) Error() string {
+	return string(e)
+}
+
+func newTables() map[string]*table {
+	tables := make(map[string]*table)
+	for _, r := range []*resource{
+		{
+			name:     "axtesting",
+			required: []string{"Name"},
+			defaults: object{
+				"Mode": "automatic", "Percentage": 10, "Status": axDraft, "StatusCode": 0,
+				"WinnerID": 0, "WinnerMethod": "OpenRate",
+			},
+			created: []string{"CreatedAt"},
+			filters: map[string]filter{
+				"ContactsList": property("ContactListID"),
+				"Status":       property("Status"),
+			},
+			prepare: prepareAXTest,
+		},
+		{
+			name:     "campaign",
+			readOnly: true,
+			noDelete: true,
+			created:  []string{"CreatedAt"},
+			filters: map[string]filter{
+				"ContactsList": property("ListID"),
+				"NewsLetter":   property("NewsLetterID"),
+			},
+			find: campaignProgress,
+		},
+		{
+			name:     "campaigndraft",
+			required: []string{"Locale"},
+			defaults: object{"Status": draftStatusDraft},
+			created:  []string{"CreatedAt", "ModifiedAt"},
+			updated:  []string{"ModifiedAt"},
+			filters: map[string]filter{
+				"AXTesting":    axTesting,
+				"ContactsList": property("ContactsListID"),
+				"Status":       property("Status"),
+			},
+			prepare: prepareDraft,
+			actions: map[string]action{
+				"detailcontent": {get: draftContent, post: setDraftContent},
+				"test":          {post: testDraft},
+				"schedule":      {get: draftSchedule, post: scheduleDraft, delete: unscheduleDraft},
+				"send":          {post: sendDraft},
+			},
+		},
+		{
+			name:     "campaignstatistics",
+			readOnly: true,
+			noDelete: true,
+			filters: map[string]filter{
+				"AXTesting":  axTesting,
+				"Campaign":   property("CampaignID"),
+				"NewsLetter": property("NewsLetterID"),
+			},
+		},
+		{
+			name:     "contact",
+			altID:    "Email",
+			required: []string{"Email"},
+			unique:   [][]string{{"Email"}},
+			defaults: object{
+				"DeliveredCount": 0, "IsExcludedFromCampaigns": false, "IsOptInPending": false,
+				"IsSpamComplaining": false, "Name": "", "UnsubscribedBy": "",
+			},
+			created: []string{"CreatedAt", "LastUpdateAt"},
+			updated: []string{"LastUpdateAt"},
+			filters: map[string]filter{
+				"IsExcludedFromCampaigns": property("IsExcludedFromCampaigns"),
+				"ContactsList":            inList,
+			},
+			actions: map[string]action{
+				"getcontactslists":    {get: contactsLists},
+				"managecontactslists": {post: manageContactsLists},
+			},
+			noDelete: true,
+		},
+		{
+			name:     "contactdata",
+			noCreate: true,
+			noDelete: true,
+			filters: map[string]filter{
+				"Contact":      property("ContactID"),
+				"ContactsList": inList,
+			},
+			find:    contactData,
+			prepare: mergeContactData,
+		},
+		{
+			name:     "contacthistorydata",
+			required: []string{"Name"},
+			created:  []string{"CreatedAt"},
+			filters: map[string]filter{
+				"Contact": property("ContactID"),
+			},
+			prepare: func(s *Server, o object) error {
+				return s.resolve(o, "ContactID", "ContactALT", "contact")
+			},
+		},
+		{
+			name:     "contactmetadata",
+			required: []string{"Name", "Datatype"},
+			unique:   [][]string{{"Name"}},
+			defaults: object{"NameSpace": "static"},
+			filters: map[string]filter{
+				"Datatype":  property("Datatype"),
+				"NameSpace": property("NameSpace"),
+			},
+			prepare: func(s *Server, o object) error {
+				if _, ok := contactDatatypes[fmt.Sprint(o["Datatype"])]; !ok {
+					return validationError(fmt.Sprintf("Invalid datatype %q for Datatype", o["Datatype"]))
+				}
+				return nil
+			},
+		},
+		{
+			name:     "contactslist",
+			altID:    "Address",
+			required: []string{"Name"},
+			unique:   [][]string{{"Name"}},
+			defaults: object{"IsDeleted": false, "SubscriberCount": 0},
+			created:  []string{"CreatedAt"},
+			actions:  map[string]action{"managecontact": {post: manageContactAction}},
+			filters: map[string]filter{
+				"Address":   property("Address"),
+				"IsDeleted": property("IsDeleted"),
+				"Name":      property("Name"),
+			},
+			prepare: func(s *Server, o object) error {
+				if address, _ := o["Address"].(string); address == "" {
+					o["Address"] = strings.Replace(newUUID(), "-", "", -1)[:9]
+				}
+				return nil
+			},
+			view: func(s *Server, o object) {
+				count := 0
+				for _, r := range s.tables["listrecipient"].objects {
+					if sameValue(r["ListID"], o["ID"]) && r["IsUnsubscribed"] != true {
+						count++
+					}
+				}
+				o["SubscriberCount"] = count
+			},
+		},
+		{
+			name:     "contactslistsignup",
+			required: []string{"Email"},
+			filters: map[string]filter{
+				"ContactsList": property("ListID"),
+				"Email":        property("Email"),
+			},
+			prepare: func(s *Server, o object) error {
+				return s.resolve(o, "ListID", "ListALT", "contactslist")
+			},
+		},
+		{
+			name:     "listrecipient",
+			unique:   [][]string{{"ContactID", "ListID"}},
+			defaults: object{"IsActive": true, "IsUnsubscribed": false},
+			created:  []string{"SubscribedAt"},
+			filters: map[string]filter{
+				"Contact":        property("ContactID"),
+				"ContactEmail":   contactEmail,
+				"ContactsList":   property("ListID"),
+				"IsUnsubscribed": property("IsUnsubscribed"),
+				"Unsub":          property("IsUnsubscribed"),
+			},
+			prepare: func(s *Server, o object) error {
+				if err := s.resolve(o, "ContactID", "ContactALT", "contact"); err != nil {
+					return err
+				}
+				return s.resolve(o, "ListID", "ListALT", "contactslist")
+			},
+		},
+		{
+			name:     "sender",
+			altID:    "Email",
+			required: []string{"Email"},
+			unique:   [][]string{{"Email"}},
+			defaults: object{
+				"DNSID": 0, "EmailType": "unknown", "Filename": "", "IsDefaultSender": false,
+				"Name": "", "Status": "Inactive",
+			},
+			created: []string{"CreatedAt"},
+			filters: map[string]filter{
+				"Email":  property("Email"),
+				"Status": property("Status"),
+			},
+		},
+		{
+			name:     "template",
+			required: []string{"Name"},
+			defaults: object{
+				"Author": "", "Categories": []string{}, "Copyright": "", "Description": "",
+				"EditMode": 1, "IsStarred": false, "Locale": "en_US", "OwnerId": 0,
+				"OwnerType": "apikey", "Presets": "", "Previews": []int64{}, "Purposes": []string{},
+			},
+			created: []string{"CreationDate"},
+			updated: []string{"LastUpdatedAt"},
+			filters: map[string]filter{
+				"EditMode":  property("EditMode"),
+				"Name":      property("Name"),
+				"OwnerType": property("OwnerType"),
+			},
+		},
+		{
+			name:     "csvimport",
+			required: []string{"DataID"},
+			defaults: object{
+				"Count": 0, "Current": 0, "Errcount": 0, "ErrTreshold": 0, "ImportOptions": "",
+				"Method": "addnoforce", "Status": jobUpload,
+			},
+			created:  []string{"RequestAt", "AliveAt"},
+			updated:  []string{"AliveAt"},
+			noDelete: true,
+			find:     importJob,
+			prepare:  prepareImport,
+		},
+		{
+			name:     "eventcallbackurl",
+			required: []string{"Url"},
+			unique:   [][]string{{"EventType", "IsBackup"}},
+			defaults: object{"APIKeyID": 0, "EventType": "open", "IsBackup": false, "Status": "alive", "Version": 1},
+			filters: map[string]filter{
+				"EventType": property("EventType"),
+				"IsBackup":  property("IsBackup"),
+				"Status":    property("Status"),
+			},
+		},
+		{
+			name:     "message",
+			readOnly: true,
+			filters: map[string]filter{
+				"Contact":  property("ContactID"),
+				"CustomID": property("CustomID"),
+			},
+		},
+		{
+			name:     "messagehistory",
+			readOnly: true,
+			findAll: func(s *Server, t *table, key string) []object {
+				var events []object
+				for _, o := range t.objects {
+					if matches(o["MessageID"], key) {
+						events = append(events, o)
+					}
+				}
+				return events
+			},
+			view: func(s *Server, o object) {
+				delete(o, "MessageID")
+				delete(o, "ID")
+			},
+		},
+	} {
+		tables[r.name] = &table{resource: r}
+	}
+	return tables
+}
+
+// property returns a filter comparing the value to a property of the object.
+func property(name string) filter {
+	return func(s *Server, o object, value string) bool {
+		return matches(o[name], value)
+	}
+}
+
+// inList matches the contacts subscribed to the list.
+func inList(s *Server, o object, value string) bool {
+	for _, r := range s.tables["listrecipient"].objects {
+		if sameValue(r["ContactID"], o["ID"]) && matches(r["ListID"], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// contactEmail matches the list recipients of the contact with this address.
+func contactEmail(s *Server, o object, value string) bool {
+	contact := s.tables["contact"].find(value)
+	return contact != nil && sameValue(contact["ID"], o["ContactID"])
+}
+
+// matches reports whether the property value v equals the query value.
+func matches(v interface{}, value string) bool {
+	if b, ok := v.(bool); ok {
+		parsed, err := strconv.ParseBool(value)
+		return err == nil && parsed == b
+	}
+	return v != nil && strings.EqualFold(fmt.Sprint(v), value)
+}
+
+func sameValue(a, b interface{}) bool {
+	return a != nil && b != nil && fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// lookup returns the object with this ID or AltID.
+func (s *Server) lookup(t *table, key string) object {
+	if t.resource.find != nil {
+		return t.resource.find(s, t, key)
+	}
+	return t.find(key)
+}
+
+// find returns the object with this ID or AltID.
+func (t *table) find(key string) object {
+	for _, o := range t.objects {
+		if matches(o["ID"], key) || (t.resource.altID != "" && matches(o[t.resource.altID], key)) {
+			return o
+		}
+	}
+	return nil
+}
+
+// resolve sets the ID property of o from the ALT property, and checks that the referenced object exists.
+func (s *Server) resolve(o object, idProp, altProp, resource string) error {
+	t := s.tables[resource]
+	key := fmt.Sprint(o[idProp])
+	if alt, ok := o[altProp].(string); ok && alt != "" {
+		key = alt
+	}
+	if o[idProp] == nil && o[altProp] == nil {
+		return validationError(fmt.Sprintf("MJ03 A non-empty value is required for %s", idProp))
+	}
+	target := t.find(key)
+	if target == nil {
+		return validationError(fmt.Sprintf("MJ05 Object %s not found for %s", key, idProp))
+	}
+	o[idProp] = target["ID"]
+	delete(o, altProp)
+	return nil
+}
+
+// handleREST serves /v3/REST/{resource}[/{id}].
+func (s *Server) handleREST(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v3/REST/"), "/"), "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sendScheduled()
+	s.runAXTests()
+	t := s.tables[strings.ToLower(tokens[0])]
+	if t == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown resource: %q", tokens[0]), "")
+		return
+	}
+	if s.handleJobAction(w, r, tokens) {
+		return
+	}
+	if len(tokens) > 2 {
+		s.action(w, r, t, tokens)
+		return
+	}
+	if len(tokens) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r, t)
+		case http.MethodPost:
+			s.create(w, r, t)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		}
+		return
+	}
+
+	if t.resource.findAll != nil && r.Method == http.MethodGet {
+		objects := t.resource.findAll(s, t, tokens[1])
+		writeJSON(w, http.StatusOK, s.result(t, objects, len(objects)))
+		return
+	}
+	o := s.lookup(t, tokens[1])
+	if o == nil {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.result(t, []object{o}, 1))
+	case http.MethodPut:
+		s.update(w, r, t, o)
+	case http.MethodDelete:
+		s.delete(w, t, o)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+	}
+}
+
+// action serves a GET, a POST or a DELETE on an action of an object.
+func (s *Server) action(w http.ResponseWriter, r *http.Request, t *table, tokens []string) {
+	a, ok := t.resource.actions[strings.ToLower(tokens[2])]
+	if !ok || len(tokens) > 3 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown action: %q", tokens[2]), "")
+		return
+	}
+	if (r.Method != http.MethodGet || a.get == nil) && (r.Method != http.MethodPost || a.post == nil) &&
+		(r.Method != http.MethodDelete || a.delete == nil) {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	o := s.lookup(t, tokens[1])
+	if o == nil {
+		writeError(w, http.StatusNotFound, "Object not found", "")
+		return
+	}
+
+	status, objects := http.StatusOK, []object(nil)
+	switch r.Method {
+	case http.MethodDelete:
+		if err := a.delete(s, o); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet:
+		objects = a.get(s, o)
+	default:
+		payload, err := decodeObject(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid json input", err.Error())
+			return
+		}
+		if objects, err = a.post(s, o, payload); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]interface{}{"Count": len(objects), "Data": objects, "Total": len(objects)})
+}
+
+// list serves the filtered and paginated objects of the table.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, t *table) {
+	query := r.URL.Query()
+	limit, offset := DefaultLimit, 0
+	var sortBy string
+	countOnly := false
+	var filters []func(o object) bool
+	for key, values := range query {
+		value := values[0]
+		switch strings.ToLower(key) {
+		case "limit":
+			limit, _ = strconv.Atoi(value)
+			if limit <= 0 || limit > MaxLimit {
+				limit = MaxLimit
+			}
+		case "offset":
+			offset, _ = strconv.Atoi(value)
+		case "sort":
+			sortBy = value
+		case "countonly":
+			countOnly = value == "1" || strings.EqualFold(value, "true")
+		default:
+			// As the real API, unknown filters are ignored.
+			if f := t.filter(key); f != nil {
+				filters = append(filters, func(o object) bool { return f(s, o, value) })
+			}
+		}
+	}
+
+	var found []object
+	for _, o := range t.objects {
+		ok := true
+		for _, f := range filters {
+			ok = ok && f(o)
+		}
+		if ok {
+			found = append(found, o)
+		}
+	}
+	sortObjects(found, sortBy)
+
+	total := len(found)
+	if countOnly {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"Count": total, "Data": []object{}, "Total": total})
+		return
+	}
+	if offset > len(found) {
+		offset = len(found)
+	}
+	found = found[offset:]
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	writeJSON(w, http.StatusOK, s.result(t, found, total))
+}
+
+// filter returns the filter of the resource with this name, ignoring case.
+func (t *table) filter(name string) filter {
+	for key, f := range t.resource.filters {
+		if strings.EqualFold(key, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// sortObjects sorts by a property, given as "Property" or "Property DESC".
+func sortObjects(objects []object, sortBy string) {
+	fields := strings.Fields(strings.Replace(sortBy, "+", " ", -1))
+	if len(fields) == 0 {
+		return
+	}
+	desc := len(fields) > 1 && strings.EqualFold(fields[1], "DESC")
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := objects[i][fields[0]], objects[j][fields[0]]
+		if desc {
+			a, b = b, a
+		}
+		return less(a, b)
+	})
+}
+
+func less(a, b interface{}) bool {
+	fa, errA := strconv.ParseFloat(fmt.Sprint(a), 64)
+	fb, errB := strconv.ParseFloat(fmt.Sprint(b), 64)
+	if errA == nil && errB == nil {
+		return fa < fb
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// create serves a POST on the collection.
+func (s *Server) create(w http.ResponseWriter, r *http.Request, t *table) {
+	if t.resource.readOnly || t.resource.noCreate {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	payload, err := decodeObject(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid json input", err.Error())
+		return
+	}
+	o, err := s.insert(t, payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.result(t, []object{o}, 1))
+}
+
+// insert validates and stores a new object. The caller must hold s.mu.
+func (s *Server) insert(t *table, payload object) (object, error) {
+	o := make(object)
+	for key, value := range t.resource.defaults {
+		o[key] = value
+	}
+	for key, value := range payload {
+		o[key] = value
+	}
+	for _, key := range t.resource.required {
+		if v, ok := o[key]; !ok || v == nil || v == "" {
+			return nil, validationError(fmt.Sprintf("MJ03 A non-empty value is required for %s", key))
+		}
+	}
+	if t.resource.prepare != nil {
+		if err := t.resource.prepare(s, o); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.checkUnique(o, nil); err != nil {
+		return nil, err
+	}
+
+	o["ID"] = s.nextID()
+	now := s.timestamp()
+	for _, key := range t.resource.created {
+		o[key] = now
+	}
+	t.objects = append(t.objects, o)
+	return o, nil
+}
+
+// remove deletes the objects matching drop.
+func (t *table) remove(drop func(o object) bool) {
+	kept := t.objects[:0]
+	for _, o := range t.objects {
+		if !drop(o) {
+			kept = append(kept, o)
+		}
+	}
+	t.objects = kept
+}
+
+// checkUnique returns an error if another object than self has the same unique properties as o.
+func (t *table) checkUnique(o, self object) error {
+	for _, keys := range t.resource.unique {
+		for _, other := range t.objects {
+			if sameObject(other, self) {
+				continue
+			}
+			duplicate := true
+			values := make([]string, len(keys))
+			for i, key := range keys {
+				values[i] = fmt.Sprint(o[key])
+				duplicate = duplicate && strings.EqualFold(values[i], fmt.Sprint(other[key]))
+			}
+			if duplicate {
+				return validationError(fmt.Sprintf("MJ18 A %s resource with value %q for %s already exists.",
+					strings.ToUpper(t.resource.name[:1])+t.resource.name[1:], strings.Join(values, ","), strings.Join(keys, ",")))
+			}
+		}
+	}
+	return nil
+}
+
+func sameObject(a, b object) bool {
+	return a != nil && b != nil && sameValue(a["ID"], b["ID"])
+}
+
+// update serves a PUT on an object.
+func (s *Server) update(w http.ResponseWriter, r *http.Request, t *table, o object) {
+	if t.resource.readOnly {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	payload, err := decodeObject(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid json input", err.Error())
+		return
+	}
+	updated := make(object)
+	for key, value := range o {
+		updated[key] = value
+	}
+	for key, value := range payload {
+		if key != "ID" {
+			updated[key] = value
+		}
+	}
+	if t.resource.prepare != nil {
+		if err = t.resource.prepare(s, updated); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+	}
+	if err = t.checkUnique(updated, o); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+	now := s.timestamp()
+	for _, key := range t.resource.updated {
+		updated[key] = now
+	}
+	for key, value := range updated {
+		o[key] = value
+	}
+	writeJSON(w, http.StatusOK, s.result(t, []object{o}, 1))
+}
+
+// delete serves a DELETE on an object.
+func (s *Server) delete(w http.ResponseWriter, t *table, o object) {
+	if t.resource.readOnly || t.resource.noDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	for i, other := range t.objects {
+		if sameObject(other, o) {
+			t.objects = append(t.objects[:i], t.objects[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// result returns the response body of a REST call, with copies of the objects.
+func (s *Server) result(t *table, objects []object, total int) map[string]interface{} {
+	data := make([]object, 0, len(objects))
+	for _, o := range objects {
+		c := make(object, len(o))
+		for key, value := range o {
+			c[key] = value
+		}
+		if t.resource.view != nil {
+			t.resource.view(s, c)
+		}
+		data = append(data, c)
+	}
+	return map[string]interface{}{"Count": len(data), "Data": data, "Total": total}
+}
+
+// decodeObject decodes the JSON object of the request body.
+func decodeObject(r *http.Request) (object, error) {
+	var o object
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&o); err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return o, nil
+}
+
+// Seed creates objects of a REST resource, bypassing the API keys and the injected failures,
+// and returns their IDs. Objects can be structs of the resources package or maps.
+func (s *Server) Seed(resource string, objects ...interface{}) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.tables[strings.ToLower(resource)]
+	if t == nil {
+		return nil, fmt.Errorf("fake: unknown resource %q", resource)
+	}
+	ids := make([]int64, 0, len(objects))
+	for _, v := range objects {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ids, err
+		}
+		var payload object
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+		if err = decoder.Decode(&payload); err != nil {
+			return ids, err
+		}
+		for key, value := range payload {
+			// Drop the zero values of the read-only properties of the resources structs.
+			if key == "ID" || value == nil {
+				delete(payload, key)
+			}
+		}
+		o, err := s.insert(t, payload)
+		if err != nil {
+			return ids, fmt.Errorf("fake: seeding %s: %w", resource, err)
+		}
+		ids = append(ids, o["ID"].(int64))
+	}
+	return ids, nil
+}
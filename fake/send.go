@@ -0,0 +1,224 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+// MaxMessagesV31 is the maximum number of messages of a Send API v3.1 call.
+const MaxMessagesV31 = 50
+
+// handleSendV31 serves /v3.1/send.
+func (s *Server) handleSendV31(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	var data mailjet.MessagesV31
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed JSON, please review the syntax and properties types.", err.Error())
+		return
+	}
+	if len(data.Info) == 0 || len(data.Info) > MaxMessagesV31 {
+		writeError(w, http.StatusBadRequest,
+			fmt.Sprintf("The number of messages must be between 1 and %d.", MaxMessagesV31), "")
+		return
+	}
+
+	invalid := false
+	feedback := make([]map[string]interface{}, len(data.Info))
+	for i := range data.Info {
+		if errs := validateV31(&data.Info[i]); len(errs) > 0 {
+			invalid = true
+			feedback[i] = map[string]interface{}{"Status": "error", "Errors": errs}
+		} else {
+			feedback[i] = map[string]interface{}{"Status": "success"}
+		}
+	}
+	if invalid {
+		// As the real API, no message of the batch is sent.
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"Messages": feedback})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]mailjet.ResultV31, len(data.Info))
+	for i := range data.Info {
+		m := &data.Info[i]
+		results[i] = mailjet.ResultV31{
+			Status:   "success",
+			CustomID: m.CustomID,
+			To:       s.deliver(m.To, m.Subject, m.CustomID),
+			Cc:       s.deliver(m.Cc, m.Subject, m.CustomID),
+			Bcc:      s.deliver(m.Bcc, m.Subject, m.CustomID),
+		}
+		if !data.SandBoxMode {
+			s.messages = append(s.messages, *m)
+		}
+	}
+	writeJSON(w, http.StatusOK, mailjet.ResultsV31{ResultsV31: results})
+}
+
+// validateV31 returns the errors of a v3.1 message.
+func validateV31(m *mailjet.InfoMessagesV31) []mailjet.APIErrorDetailsV31 {
+	var errs []mailjet.APIErrorDetailsV31
+	add := func(code, message string, relatedTo ...string) {
+		errs = append(errs, mailjet.APIErrorDetailsV31{
+			ErrorCode:       code,
+			ErrorIdentifier: newUUID(),
+			ErrorMessage:    message,
+			ErrorRelatedTo:  relatedTo,
+			StatusCode:      http.StatusBadRequest,
+		})
+	}
+	checkEmail := func(email, field string) {
+		if _, err := mail.ParseAddress(email); err != nil {
+			add("mj-0013", fmt.Sprintf("%q is an invalid email address.", email), field)
+		}
+	}
+
+	if m.From == nil && m.TemplateID == 0 {
+		add("mj-0003", "Missing mandatory property.", "From")
+	} else if m.From != nil {
+		checkEmail(m.From.Email, "From.Email")
+	}
+	count := 0
+	for i, recipients := range []*mailjet.RecipientsV31{m.To, m.Cc, m.Bcc} {
+		name := []string{"To", "Cc", "Bcc"}[i]
+		if recipients == nil {
+			continue
+		}
+		for j, r := range *recipients {
+			checkEmail(r.Email, fmt.Sprintf("%s[%d].Email", name, j))
+			count++
+		}
+	}
+	if count == 0 {
+		add("send-0001", `At least "To", "Cc" or "Bcc" must be provided.`, "To", "Cc", "Bcc")
+	}
+	if m.TextPart == "" && m.HTMLPart == "" && m.TemplateID == 0 {
+		add("send-0003", `At least "HTMLPart", "TextPart" or "TemplateID" must be provided.`,
+			"HTMLPart", "TextPart", "TemplateID")
+	}
+	return errs
+}
+
+// deliver records a message object for each recipient. The caller must hold s.mu.
+func (s *Server) deliver(recipients *mailjet.RecipientsV31, subject, customID string) []mailjet.GeneratedMessageV31 {
+	if recipients == nil {
+		return nil
+	}
+	generated := make([]mailjet.GeneratedMessageV31, 0, len(*recipients))
+	for _, r := range *recipients {
+		id := s.record(r.Email, subject, customID)
+		generated = append(generated, mailjet.GeneratedMessageV31{
+			Email:       r.Email,
+			MessageUUID: newUUID(),
+			MessageID:   id,
+			MessageHref: fmt.Sprintf("%s/REST/message/%d", s.URL, id),
+		})
+	}
+	return generated
+}
+
+// record adds a message object and returns its ID. The caller must hold s.mu.
+func (s *Server) record(email, subject, customID string) int64 {
+	var contactID interface{}
+	if contact := s.tables["contact"].find(email); contact != nil {
+		contactID = contact["ID"]
+	}
+	id := s.nextID()
+	t := s.tables["message"]
+	t.objects = append(t.objects, object{
+		"ArrivedAt": s.timestamp(),
+		"ContactID": contactID,
+		"CustomID":  customID,
+		"ID":        id,
+		"Status":    "sent",
+		"Subject":   subject,
+	})
+	history := s.tables["messagehistory"]
+	history.objects = append(history.objects, object{
+		"ID": s.nextID(), "MessageID": id, "EventAt": s.now().Unix(), "EventType": "sent",
+		"State": "", "Comment": "", "Useragent": "",
+	})
+	return id
+}
+
+// handleSendV3 serves /v3/send and /v3/send/message.
+func (s *Server) handleSendV3(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Operation not allowed", "")
+		return
+	}
+	var data mailjet.InfoSendMail
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid json input", err.Error())
+		return
+	}
+	messages := data.Messages
+	if len(messages) == 0 {
+		messages = []mailjet.InfoSendMail{data}
+	}
+
+	recipients := make([][]string, len(messages))
+	for i := range messages {
+		emails, err := recipientsV3(&messages[i])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+		recipients[i] = emails
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res mailjet.SentResult
+	for i, m := range messages {
+		for _, email := range recipients[i] {
+			res.Sent = append(res.Sent, struct {
+				Email     string
+				MessageID int64
+			}{email, s.record(email, m.Subject, m.MjCustomID)})
+		}
+		s.messagesV3 = append(s.messagesV3, m)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// recipientsV3 validates a v3 message and returns the addresses of its recipients.
+func recipientsV3(m *mailjet.InfoSendMail) ([]string, error) {
+	if m.FromEmail == "" {
+		return nil, validationError("MJ03 A non-empty value is required for FromEmail")
+	}
+	if m.TextPart == "" && m.HTMLPart == "" && m.MjTemplateID == "" {
+		return nil, validationError("At least Text-part, Html-part or Mj-TemplateID must be provided")
+	}
+	var emails []string
+	for _, r := range m.Recipients {
+		emails = append(emails, r.Email)
+	}
+	for _, list := range []string{m.To, m.Cc, m.Bcc} {
+		if list == "" {
+			continue
+		}
+		addresses, err := mail.ParseAddressList(list)
+		if err != nil {
+			return nil, validationError(fmt.Sprintf("Invalid recipient list %q: %s", list, err))
+		}
+		for _, a := range addresses {
+			emails = append(emails, a.Address)
+		}
+	}
+	if len(emails) == 0 {
+		return nil, validationError("At least Recipients, To, Cc or Bcc must be provided")
+	}
+	return emails, nil
+}
@@ -0,0 +1,208 @@
+// Package fake provides an in-memory Mailjet API server for integration tests.
+//
+// The server implements the REST API of the core resources (contact, contactdata,
+// contacthistorydata, contactmetadata, contactslist, listrecipient, sender, template,
+// eventcallbackurl, campaigndraft, axtesting and the read-only campaign, campaignstatistics,
+// message and messagehistory), the csvimport, managemanycontacts and importlist jobs, the
+// deletion of contacts of the API v4, the Send API v3 and v3.1 and the DATA API, with the
+// filters, pagination and error responses of the real API:
+//
+//	srv := fake.NewServer()
+//	defer srv.Close()
+//	client := mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+// authErrorMessage is the message of the 401 responses of the API.
+const authErrorMessage = "API key authentication/authorization failure. You may be unauthorized to access the API or your API key may be expired. Visit API keys management section to check your keys."
+
+// Server is an in-memory Mailjet API.
+type Server struct {
+	// URL is the base URL to pass to mailjet.NewMailjetClient, e.g. "http://127.0.0.1:1234/v3".
+	URL string
+
+	server        *httptest.Server
+	apiKeyPublic  string
+	apiKeyPrivate string
+	now           func() time.Time
+
+	mu         sync.Mutex
+	lastID     int64
+	tables     map[string]*table
+	data       map[string][]*dataObject
+	jobs       map[int64]*contactsJob
+	drafts     map[string]*campaignDraft
+	messages   []mailjet.InfoMessagesV31
+	messagesV3 []mailjet.InfoSendMail
+	failures   []*failure
+}
+
+// failure is a response injected with FailNext.
+type failure struct {
+	method string
+	path   string
+	status int
+}
+
+// Options are functional options that configure the Server.
+type Options func(*Server)
+
+// WithCredentials sets the only API keys accepted by the server.
+// By default, any non-empty pair of keys is accepted.
+func WithCredentials(apiKeyPublic, apiKeyPrivate string) Options {
+	return func(s *Server) {
+		s.apiKeyPublic = apiKeyPublic
+		s.apiKeyPrivate = apiKeyPrivate
+	}
+}
+
+// WithClock sets the function returning the time used for the timestamps of the objects.
+func WithClock(now func() time.Time) Options {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// NewServer starts and returns a new Server. It should be closed with Close.
+func NewServer(options ...Options) *Server {
+	s := &Server{
+		now:    time.Now,
+		tables: newTables(),
+		data:   make(map[string][]*dataObject),
+		jobs:   make(map[int64]*contactsJob),
+		drafts: make(map[string]*campaignDraft),
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/REST/", s.handleREST)
+	mux.HandleFunc("/v3/DATA/", s.handleData)
+	mux.HandleFunc("/v3/send", s.handleSendV3)
+	mux.HandleFunc("/v3/send/message", s.handleSendV3)
+	mux.HandleFunc("/v3.1/send", s.handleSendV31)
+	mux.HandleFunc("/v4/contacts/", s.handleContactsV4)
+	s.server = httptest.NewServer(s.authenticate(mux))
+	s.URL = s.server.URL + "/v3"
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns an HTTP client configured for the server.
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+// FailNext makes the next request with this method on path, e.g. "/v3.1/send"
+// or "/v3/REST/contact", fail with the status code.
+// The response body has the shape of the errors of the real API.
+func (s *Server) FailNext(method, path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{method: method, path: path, status: status})
+}
+
+// Messages returns the messages accepted by the Send API v3.1, sandboxed ones excluded.
+func (s *Server) Messages() []mailjet.InfoMessagesV31 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]mailjet.InfoMessagesV31(nil), s.messages...)
+}
+
+// MessagesV3 returns the messages accepted by the Send API v3.
+func (s *Server) MessagesV3() []mailjet.InfoSendMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]mailjet.InfoSendMail(nil), s.messagesV3...)
+}
+
+// authenticate checks the API keys and the injected failures before calling next.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		public, private, ok := r.BasicAuth()
+		if !ok || public == "" || private == "" ||
+			(s.apiKeyPublic != "" && (public != s.apiKeyPublic || private != s.apiKeyPrivate)) {
+			writeError(w, http.StatusUnauthorized, authErrorMessage, "")
+			return
+		}
+		if status := s.injectedFailure(r); status != 0 {
+			writeError(w, status, http.StatusText(status), "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) injectedFailure(r *http.Request) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.failures {
+		if f.method == r.Method && strings.EqualFold(f.path, r.URL.Path) {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			return f.status
+		}
+	}
+	return 0
+}
+
+// nextID returns a new object ID. The caller must hold s.mu.
+func (s *Server) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+// timestamp returns the current time in the format of the API.
+func (s *Server) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339)
+}
+
+// writeJSON writes v with the status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error with the shape of mailjet.RequestError and mailjet.ErrorInfoV31.
+func writeError(w http.ResponseWriter, status int, message, info string) {
+	writeJSON(w, status, mailjet.ErrorInfoV31{
+		Identifier: newUUID(),
+		Info:       info,
+		Message:    message,
+		StatusCode: status,
+	})
+}
+
+// newUUID returns a random UUID, as used for the ErrorIdentifier and the MessageUUID.
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("fake: reading random bytes: %s", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
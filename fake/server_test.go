@@ -0,0 +1,239 @@
+package fake_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func newClient(t *testing.T, options ...fake.Options) (*fake.Server, *mailjet.Client) {
+	srv := fake.NewServer(options...)
+	t.Cleanup(srv.Close)
+	return srv, mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+}
+
+func requestError(t *testing.T, err error) mailjet.RequestError {
+	t.Helper()
+	var requestErr mailjet.RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("Expected a RequestError, got %v", err)
+	}
+	return requestErr
+}
+
+func TestServerREST(t *testing.T) {
+	_, client := newClient(t)
+
+	var contacts []resources.Contact
+	err := client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "passenger@mailjet.com", Name: "Passenger"},
+	}, &contacts)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(contacts) != 1 || contacts[0].ID == 0 || contacts[0].CreatedAt == nil {
+		t.Fatalf("Wrong contact: %+v", contacts)
+	}
+	contactID := contacts[0].ID
+
+	err = client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "PASSENGER@mailjet.com"},
+	}, nil)
+	if requestErr := requestError(t, err); requestErr.StatusCode != http.StatusBadRequest ||
+		!strings.HasPrefix(requestErr.ErrorMessage, "MJ18") {
+		t.Fatalf("Wrong duplicate error: %+v", requestErr)
+	}
+
+	err = client.Get(&mailjet.Request{Resource: "contact", AltID: "passenger@mailjet.com"}, &contacts)
+	if err != nil || contacts[0].ID != contactID {
+		t.Fatalf("Wrong contact by AltID: %+v (%v)", contacts, err)
+	}
+
+	err = client.Put(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact", ID: contactID},
+		Payload: &resources.Contact{Name: "Renamed"},
+	}, []string{"Name"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var lists []resources.Contactslist
+	err = client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contactslist"},
+		Payload: &resources.Contactslist{Name: "Passengers"},
+	}, &lists)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	err = client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "listrecipient"},
+		Payload: &resources.Listrecipient{ContactALT: "passenger@mailjet.com", ListID: lists[0].ID},
+	}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	count, _, err := client.List("contact", &contacts, mailjet.Filter("ContactsList", "1234"))
+	if err != nil || count != 0 {
+		t.Fatalf("Expected no contact in an unknown list, got %d (%v)", count, err)
+	}
+	_, _, err = client.List("contact", &contacts, mailjet.Filter("ContactsList", strconv.FormatInt(lists[0].ID, 10)))
+	if err != nil || len(contacts) != 1 || contacts[0].Name != "Renamed" {
+		t.Fatalf("Wrong contacts of the list: %+v (%v)", contacts, err)
+	}
+	err = client.Get(&mailjet.Request{Resource: "contactslist", ID: lists[0].ID}, &lists)
+	if err != nil || lists[0].SubscriberCount != 1 {
+		t.Fatalf("Wrong list: %+v (%v)", lists, err)
+	}
+
+	err = client.Delete(&mailjet.Request{Resource: "contactslist", ID: lists[0].ID})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	err = client.Get(&mailjet.Request{Resource: "contactslist", ID: lists[0].ID}, &lists)
+	if requestErr := requestError(t, err); requestErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %+v", requestErr)
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	srv, client := newClient(t)
+
+	for i := 0; i < 25; i++ {
+		contact := resources.Contact{Email: "passenger" + strconv.Itoa(i) + "@mailjet.com", IsExcludedFromCampaigns: i%5 == 0}
+		if _, err := srv.Seed("contact", contact); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		options   []mailjet.RequestOptions
+		wantCount int
+		wantTotal int
+		wantFirst string
+	}{
+		{"default limit", nil, 10, 25, "passenger0@mailjet.com"},
+		{"offset", []mailjet.RequestOptions{mailjet.Filter("Offset", "20")}, 5, 25, "passenger20@mailjet.com"},
+		{"sort", []mailjet.RequestOptions{mailjet.Sort("ID", mailjet.SortDesc), mailjet.Filter("Limit", "1")}, 1, 25, "passenger24@mailjet.com"},
+		{"filter", []mailjet.RequestOptions{mailjet.Filter("IsExcludedFromCampaigns", "true")}, 5, 5, "passenger0@mailjet.com"},
+		{"unknown filter", []mailjet.RequestOptions{mailjet.Filter("IsExcluded", "true"), mailjet.Filter("Limit", "100")}, 25, 25, "passenger0@mailjet.com"},
+		{"count only", []mailjet.RequestOptions{mailjet.Filter("countOnly", "1")}, 25, 25, ""},
+	}
+	for _, test := range tests {
+		var contacts []resources.Contact
+		count, total, err := client.List("contact", &contacts, test.options...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		if count != test.wantCount || total != test.wantTotal {
+			t.Errorf("%s: expected %d/%d, got %d/%d", test.name, test.wantCount, test.wantTotal, count, total)
+		}
+		if test.wantFirst != "" && (len(contacts) == 0 || contacts[0].Email != test.wantFirst) {
+			t.Errorf("%s: wrong first contact: %+v", test.name, contacts)
+		}
+	}
+}
+
+func TestServerSend(t *testing.T) {
+	srv, client := newClient(t)
+
+	messages := &mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{{
+		From:     &mailjet.RecipientV31{Email: "pilot@mailjet.com"},
+		To:       &mailjet.RecipientsV31{{Email: "passenger@mailjet.com"}},
+		Subject:  "Your flight",
+		TextPart: "Hello",
+		CustomID: "flight-1",
+	}}}
+	res, err := client.SendMailV31(messages)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(res.ResultsV31) != 1 || res.ResultsV31[0].To[0].MessageID == 0 {
+		t.Fatalf("Wrong result: %+v", res)
+	}
+	if sent := srv.Messages(); len(sent) != 1 || sent[0].CustomID != "flight-1" {
+		t.Fatalf("Wrong sent messages: %+v", sent)
+	}
+	var found []resources.Message
+	count, _, err := client.List("message", &found, mailjet.Filter("CustomID", "flight-1"))
+	if err != nil || count != 1 {
+		t.Fatalf("Expected the message to be listed, got %d (%v)", count, err)
+	}
+
+	messages.Info[0].To = &mailjet.RecipientsV31{{Email: "not an address"}}
+	_, err = client.SendMailV31(messages)
+	var feedbackErr *mailjet.APIFeedbackErrorsV31
+	if !errors.As(err, &feedbackErr) || feedbackErr.Messages[0].Errors[0].ErrorCode != "mj-0013" {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+
+	srv.FailNext(http.MethodPost, "/v3.1/send", http.StatusServiceUnavailable)
+	messages.Info[0].To = &mailjet.RecipientsV31{{Email: "passenger@mailjet.com"}}
+	_, err = client.SendMailV31(messages)
+	var infoErr *mailjet.ErrorInfoV31
+	if !errors.As(err, &infoErr) || infoErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the injected failure, got %v", err)
+	}
+
+	sent, err := client.SendMail(&mailjet.InfoSendMail{
+		FromEmail: "pilot@mailjet.com",
+		To:        "Passenger <passenger@mailjet.com>, other@mailjet.com",
+		Subject:   "Your flight",
+		TextPart:  "Hello",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(sent.Sent) != 2 || sent.Sent[1].Email != "other@mailjet.com" || len(srv.MessagesV3()) != 1 {
+		t.Fatalf("Wrong v3 result: %+v", sent)
+	}
+}
+
+func TestServerData(t *testing.T) {
+	srv, client := newClient(t)
+
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	csv := "email,name\npassenger@mailjet.com,Passenger\n"
+	err = client.PostData(&mailjet.FullDataRequest{
+		Info:    &mailjet.DataRequest{SourceType: "contactslist", SourceTypeID: ids[0], DataType: "CSVData", MimeType: "text:plain"},
+		Payload: csv,
+	}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	data, err := srv.Data("contactslist", ids[0], "CSVData", 0)
+	if err != nil || string(data) != csv {
+		t.Fatalf("Wrong uploaded data: %q (%v)", data, err)
+	}
+
+	err = client.PostData(&mailjet.FullDataRequest{
+		Info:    &mailjet.DataRequest{SourceType: "contactslist", SourceTypeID: 999, DataType: "CSVData", MimeType: "text:plain"},
+		Payload: csv,
+	}, nil)
+	if requestErr := requestError(t, err); requestErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %+v", requestErr)
+	}
+}
+
+func TestServerCredentials(t *testing.T) {
+	srv := fake.NewServer(fake.WithCredentials("public", "private"))
+	defer srv.Close()
+
+	client := mailjet.NewMailjetClient("public", "wrong", srv.URL)
+	_, _, err := client.List("contact", nil)
+	if requestErr := requestError(t, err); requestErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected 401, got %+v", requestErr)
+	}
+}
@@ -0,0 +1,321 @@
+package mailjet
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// commonFilters are accepted by every resource, in addition to the ones of its metadata.
+var commonFilters = map[string]bool{"Limit": true, "Offset": true, "Sort": true, "countOnly": true}
+
+func boolFilter(key string, value bool) RequestOptions {
+	return Filter(key, strconv.FormatBool(value))
+}
+
+func intFilter(key string, value int64) RequestOptions {
+	return Filter(key, strconv.FormatInt(value, 10))
+}
+
+func timeFilter(key string, value time.Time) RequestOptions {
+	return Filter(key, strconv.FormatInt(value.Unix(), 10))
+}
+
+// FromTS retrieves the objects from this time, for the resources having a FromTS filter
+// such as message, campaign or the statistics.
+func FromTS(t time.Time) RequestOptions { return timeFilter("FromTS", t) }
+
+// ToTS retrieves the objects until this time, for the resources having a ToTS filter.
+func ToTS(t time.Time) RequestOptions { return timeFilter("ToTS", t) }
+
+// Typed filters are written for the contact, contactdata, contactslist, listrecipient,
+// message, campaign and sender resources only. The filters of the other resources are
+// set with Filter, and checked with ValidateFilters.
+
+// ContactFilters are the filters of the contact resource, e.g.
+//
+//	mj.List("contact", &contacts, mailjet.ContactFilters.IsExcludedFromCampaigns(true))
+var ContactFilters contactFilters
+
+type contactFilters struct{}
+
+// Campaign retrieves the contacts which received this campaign.
+func (contactFilters) Campaign(id int64) RequestOptions { return intFilter("Campaign", id) }
+
+// ContactsList retrieves the contacts of this list.
+func (contactFilters) ContactsList(id int64) RequestOptions { return intFilter("ContactsList", id) }
+
+// IsExcludedFromCampaigns retrieves the contacts excluded, or not, from the campaigns.
+func (contactFilters) IsExcludedFromCampaigns(excluded bool) RequestOptions {
+	return boolFilter("IsExcludedFromCampaigns", excluded)
+}
+
+// ContactdataFilters are the filters of the contactdata resource.
+var ContactdataFilters contactdataFilters
+
+type contactdataFilters struct{}
+
+// Campaign retrieves the data of the contacts which received this campaign.
+func (contactdataFilters) Campaign(id int64) RequestOptions { return intFilter("Campaign", id) }
+
+// ContactsList retrieves the data of the contacts of this list.
+func (contactdataFilters) ContactsList(id int64) RequestOptions {
+	return intFilter("ContactsList", id)
+}
+
+// ContactslistFilters are the filters of the contactslist resource.
+var ContactslistFilters contactslistFilters
+
+type contactslistFilters struct{}
+
+// Address retrieves the list with this address.
+func (contactslistFilters) Address(address string) RequestOptions { return Filter("Address", address) }
+
+// ExcludeID excludes the list with this ID.
+func (contactslistFilters) ExcludeID(id int64) RequestOptions { return intFilter("ExcludeID", id) }
+
+// IsDeleted retrieves the deleted lists, or the other ones.
+func (contactslistFilters) IsDeleted(deleted bool) RequestOptions {
+	return boolFilter("IsDeleted", deleted)
+}
+
+// Name retrieves the lists with this name.
+func (contactslistFilters) Name(name string) RequestOptions { return Filter("Name", name) }
+
+// ListrecipientFilters are the filters of the listrecipient resource.
+var ListrecipientFilters listrecipientFilters
+
+type listrecipientFilters struct{}
+
+// Contact retrieves the subscriptions of this contact.
+func (listrecipientFilters) Contact(id int64) RequestOptions { return intFilter("Contact", id) }
+
+// ContactEmail retrieves the subscriptions of the contact with this address.
+func (listrecipientFilters) ContactEmail(email string) RequestOptions {
+	return Filter("ContactEmail", email)
+}
+
+// ContactsList retrieves the subscriptions to this list.
+func (listrecipientFilters) ContactsList(id int64) RequestOptions {
+	return intFilter("ContactsList", id)
+}
+
+// IgnoreDeleted ignores the subscriptions to deleted lists.
+func (listrecipientFilters) IgnoreDeleted(ignore bool) RequestOptions {
+	return boolFilter("IgnoreDeleted", ignore)
+}
+
+// ListName retrieves the subscriptions to the lists with this name.
+func (listrecipientFilters) ListName(name string) RequestOptions { return Filter("ListName", name) }
+
+// Unsub retrieves the unsubscribed subscriptions, or the active ones.
+func (listrecipientFilters) Unsub(unsubscribed bool) RequestOptions {
+	return boolFilter("Unsub", unsubscribed)
+}
+
+// MessageFilters are the filters of the message resource, e.g.
+//
+//	mj.List("message", &messages, mailjet.MessageFilters.FromTS(time.Now().Add(-time.Hour)))
+var MessageFilters messageFilters
+
+type messageFilters struct{}
+
+// Campaign retrieves the messages of this campaign.
+func (messageFilters) Campaign(id int64) RequestOptions { return intFilter("Campaign", id) }
+
+// Contact retrieves the messages sent to this contact.
+func (messageFilters) Contact(id int64) RequestOptions { return intFilter("Contact", id) }
+
+// CustomID retrieves the messages sent with this CustomID.
+func (messageFilters) CustomID(customID string) RequestOptions { return Filter("CustomID", customID) }
+
+// Destination retrieves the messages sent to this destination domain.
+func (messageFilters) Destination(id int64) RequestOptions { return intFilter("Destination", id) }
+
+// FromTS retrieves the messages sent from this time.
+func (messageFilters) FromTS(t time.Time) RequestOptions { return timeFilter("FromTS", t) }
+
+// ToTS retrieves the messages sent until this time.
+func (messageFilters) ToTS(t time.Time) RequestOptions { return timeFilter("ToTS", t) }
+
+// MessageStatus retrieves the messages with this status code.
+func (messageFilters) MessageStatus(status int64) RequestOptions {
+	return intFilter("MessageStatus", status)
+}
+
+// SenderID retrieves the messages sent by this sender.
+func (messageFilters) SenderID(id int64) RequestOptions { return intFilter("SenderID", id) }
+
+// ShowContactAlt includes the e-mail address of the contacts in the messages.
+func (messageFilters) ShowContactAlt(show bool) RequestOptions {
+	return boolFilter("ShowContactAlt", show)
+}
+
+// ShowCustomID includes the CustomID in the messages.
+func (messageFilters) ShowCustomID(show bool) RequestOptions { return boolFilter("ShowCustomID", show) }
+
+// ShowSubject includes the subject in the messages.
+func (messageFilters) ShowSubject(show bool) RequestOptions { return boolFilter("ShowSubject", show) }
+
+// CampaignFilters are the filters of the campaign resource.
+var CampaignFilters campaignFilters
+
+type campaignFilters struct{}
+
+// ContactsList retrieves the campaigns sent to this list.
+func (campaignFilters) ContactsList(id int64) RequestOptions { return intFilter("ContactsList", id) }
+
+// FromTS retrieves the campaigns sent from this time.
+func (campaignFilters) FromTS(t time.Time) RequestOptions { return timeFilter("FromTS", t) }
+
+// ToTS retrieves the campaigns sent until this time.
+func (campaignFilters) ToTS(t time.Time) RequestOptions { return timeFilter("ToTS", t) }
+
+// IsDeleted retrieves the deleted campaigns, or the other ones.
+func (campaignFilters) IsDeleted(deleted bool) RequestOptions {
+	return boolFilter("IsDeleted", deleted)
+}
+
+// IsStarred retrieves the starred campaigns, or the other ones.
+func (campaignFilters) IsStarred(starred bool) RequestOptions {
+	return boolFilter("IsStarred", starred)
+}
+
+// SenderFilters are the filters of the sender resource.
+var SenderFilters senderFilters
+
+type senderFilters struct{}
+
+// Domain retrieves the senders of this domain.
+func (senderFilters) Domain(domain string) RequestOptions { return Filter("Domain", domain) }
+
+// Email retrieves the sender with this address.
+func (senderFilters) Email(email string) RequestOptions { return Filter("Email", email) }
+
+// IsDomainSender retrieves the domain senders, or the address ones.
+func (senderFilters) IsDomainSender(domain bool) RequestOptions {
+	return boolFilter("IsDomainSender", domain)
+}
+
+// ShowDeleted includes the deleted senders.
+func (senderFilters) ShowDeleted(show bool) RequestOptions { return boolFilter("ShowDeleted", show) }
+
+// Status retrieves the senders with this status, e.g. "Active".
+func (senderFilters) Status(status string) RequestOptions { return Filter("Status", status) }
+
+// FilterError reports the filters of a request which do not match the metadata of the resource.
+type FilterError struct {
+	Resource string
+	// Unknown are the filters the resource does not have. The API ignores them.
+	Unknown []string
+	// Missing are the required filters not set.
+	Missing []string
+	// Invalid are the filters with a value not matching their data type, as "Name=value".
+	Invalid []string
+}
+
+func (e *FilterError) Error() string {
+	var problems []string
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		problems = append(problems, "invalid "+strings.Join(e.Invalid, ", "))
+	}
+	return fmt.Sprintf("mailjet: filters of %s: %s", e.Resource, strings.Join(problems, "; "))
+}
+
+// ValidateFilters checks the filters set by the options against the metadata of the resource,
+// as returned by the metadata resource. It returns a *FilterError for the unknown filters,
+// e.g. a misspelled "ContactsLIst", the missing required ones and the invalid values.
+func ValidateFilters(metadata *resources.Metadata, options ...RequestOptions) error {
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		return err
+	}
+	for _, option := range options {
+		option(req)
+	}
+	return validateQuery(metadata, req.URL.Query())
+}
+
+func validateQuery(metadata *resources.Metadata, query url.Values) error {
+	filters := make(map[string]resources.ResourceFilter, len(metadata.Filters))
+	for _, f := range metadata.Filters {
+		filters[f.Name] = f
+	}
+
+	e := &FilterError{Resource: metadata.Name}
+	for key, values := range query {
+		if commonFilters[key] {
+			continue
+		}
+		f, ok := filters[key]
+		if !ok {
+			unknown := key
+			for name := range filters {
+				if strings.EqualFold(name, key) {
+					unknown += " (did you mean " + name + "?)"
+				}
+			}
+			e.Unknown = append(e.Unknown, unknown)
+			continue
+		}
+		for _, value := range values {
+			if !validFilterValue(f.DataType, value) {
+				e.Invalid = append(e.Invalid, key+"="+value)
+			}
+		}
+	}
+	for _, f := range metadata.Filters {
+		if _, ok := query[f.Name]; f.IsRequired && !ok {
+			e.Missing = append(e.Missing, f.Name)
+		}
+	}
+
+	if len(e.Unknown)+len(e.Missing)+len(e.Invalid) == 0 {
+		return nil
+	}
+	sort.Strings(e.Unknown)
+	sort.Strings(e.Invalid)
+	return e
+}
+
+// validFilterValue checks a value against a data type of the metadata, e.g. TBoolean or TInt64.
+// Values of other data types are not checked.
+func validFilterValue(dataType, value string) bool {
+	switch strings.ToLower(strings.TrimPrefix(dataType, "T")) {
+	case "bool", "boolean":
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case "int", "int32", "int64", "integer", "smallint", "longint", "cardinal":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "double", "float", "single", "real":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	}
+	return true
+}
+
+// ValidateFilters checks the filters set by the options against the metadata of the resource
+// returned by the API. See the ValidateFilters function to check them against cached metadata.
+func (c *Client) ValidateFilters(resource string, options ...RequestOptions) error {
+	var metadata []resources.Metadata
+	if err := c.Get(&Request{Resource: "metadata", AltID: resource}, &metadata); err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		return fmt.Errorf("mailjet: no metadata for %s", resource)
+	}
+	return ValidateFilters(&metadata[0], options...)
+}
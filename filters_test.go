@@ -0,0 +1,87 @@
+package mailjet_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func query(options ...mailjet.RequestOptions) string {
+	req, _ := http.NewRequest(http.MethodGet, "https://api.mailjet.com/v3/REST/contact", nil)
+	for _, option := range options {
+		option(req)
+	}
+	return req.URL.RawQuery
+}
+
+func TestFilterEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []mailjet.RequestOptions
+		want    string
+	}{
+		{"plus in values", []mailjet.RequestOptions{
+			mailjet.ListrecipientFilters.ContactEmail("passenger+1@mailjet.com"),
+			mailjet.SenderFilters.Email("pilot+2@mailjet.com"),
+		}, "ContactEmail=passenger%2B1%40mailjet.com&Email=pilot%2B2%40mailjet.com"},
+		{"sort", []mailjet.RequestOptions{mailjet.Sort("ID", mailjet.SortDesc), mailjet.Filter("Limit", "1")}, "Limit=1&Sort=ID+DESC"},
+		{"legacy sort", []mailjet.RequestOptions{mailjet.Filter("Sort", "Name+DESC")}, "Sort=Name+DESC"},
+		{"typed", []mailjet.RequestOptions{
+			mailjet.ContactFilters.IsExcludedFromCampaigns(true),
+			mailjet.MessageFilters.FromTS(time.Unix(1700000000, 0)),
+		}, "FromTS=1700000000&IsExcludedFromCampaigns=true"},
+		{"time range", []mailjet.RequestOptions{
+			mailjet.FromTS(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)),
+			mailjet.ToTS(time.Date(2023, 11, 15, 0, 0, 0, 0, time.FixedZone("CET", 3600))),
+		}, "FromTS=1700000000&ToTS=1700002800"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := query(tc.options...); got != tc.want {
+				t.Errorf("Wrong query %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	metadata := &resources.Metadata{
+		Name: "contact",
+		Filters: []resources.ResourceFilter{
+			{Name: "Campaign", DataType: "TInt64"},
+			{Name: "ContactsList", DataType: "TInt64"},
+			{Name: "IsExcludedFromCampaigns", DataType: "TBoolean"},
+		},
+	}
+
+	err := mailjet.ValidateFilters(metadata,
+		mailjet.ContactFilters.ContactsList(84),
+		mailjet.ContactFilters.IsExcludedFromCampaigns(false),
+		mailjet.Filter("Limit", "10"),
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	err = mailjet.ValidateFilters(metadata, mailjet.Filter("ContactsLIst", "84"), mailjet.Filter("Campaign", "latest"))
+	var filterErr *mailjet.FilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("Expected a FilterError, got %v", err)
+	}
+	if len(filterErr.Unknown) != 1 || !strings.Contains(filterErr.Unknown[0], "did you mean ContactsList?") {
+		t.Errorf("Wrong unknown filters: %v", filterErr.Unknown)
+	}
+	if len(filterErr.Invalid) != 1 || filterErr.Invalid[0] != "Campaign=latest" {
+		t.Errorf("Wrong invalid filters: %v", filterErr.Invalid)
+	}
+
+	metadata.Filters = append(metadata.Filters, resources.ResourceFilter{Name: "Status", IsRequired: true})
+	err = mailjet.ValidateFilters(metadata)
+	if !errors.As(err, &filterErr) || len(filterErr.Missing) != 1 || filterErr.Missing[0] != "Status" {
+		t.Fatalf("Expected a missing filter, got %v", err)
+	}
+}
@@ -1,42 +1,286 @@
 // Package fixtures provid fake data so we can mock the `Client` struct in mailjet_client.go
+//
+// Fixtures are JSON arrays of objects, one per resource, addressable by resource name
+// and ID or AltID. Besides the default fixtures returned by New, they can be loaded
+// from a directory of <resource>.json files, e.g. embedded with embed.FS:
+//
+//	//go:embed testdata/fixtures
+//	var files embed.FS
+//
+//	fx, err := fixtures.Load(files, "testdata/fixtures")
 package fixtures
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
 )
 
+// Pagination of the list responses, as in the API.
+const (
+	DefaultLimit = 10
+	MaxLimit     = 1000
+)
+
+// ErrNotFound is returned for an unknown resource or object.
+var ErrNotFound = errors.New("not found")
+
+// altIDs are the properties addressing the objects of a resource in place of their ID.
+var altIDs = map[string]string{
+	"apikey":       "APIKey",
+	"contact":      "Email",
+	"contactslist": "Address",
+	"sender":       "Email",
+	"user":         "Username",
+}
+
 // Fixtures definition
 type Fixtures struct {
-	data map[interface{}][]byte
+	// objects are keyed by lower-case resource name, in file order.
+	objects map[string][]map[string]interface{}
 }
 
 // New loads fixtures in memory by iterating through its fixture method
 func New() *Fixtures {
-	f := new(Fixtures)
-	f.data = make(map[interface{}][]byte)
+	f := &Fixtures{objects: make(map[string][]map[string]interface{})}
 	fix := reflect.ValueOf(f)
 	for i := 0; i < fix.NumMethod(); i++ {
 		method := fix.Method(i)
 		if method.Type().NumIn() == 0 && method.Type().NumOut() == 2 {
 			values := method.Call([]reflect.Value{})
-			reflect.ValueOf(f.data).SetMapIndex(values[0], values[1])
+			resource, err := resourceOf(values[0].Interface())
+			if err != nil {
+				panic(err)
+			}
+			if err = f.addJSON(resource, values[1].Bytes()); err != nil {
+				panic(fmt.Sprintf("fixtures: %s: %s", method.Type(), err))
+			}
 		}
 	}
 
 	return f
 }
 
+// Load returns the fixtures of the <resource>.json files of dir in fsys.
+// Each file holds a JSON array of objects.
+func Load(fsys fs.FS, dir string) (*Fixtures, error) {
+	f := &Fixtures{objects: make(map[string][]map[string]interface{})}
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		resource := strings.TrimSuffix(path.Base(name), ".json")
+		if err = f.addJSON(resource, b); err != nil {
+			return nil, fmt.Errorf("fixtures: %s: %w", name, err)
+		}
+	}
+	return f, nil
+}
+
+// Add appends objects to a resource. Objects can be structs of the resources package or maps.
+func (f *Fixtures) Add(resource string, objects ...interface{}) error {
+	b, err := json.Marshal(objects)
+	if err != nil {
+		return err
+	}
+	return f.addJSON(resource, b)
+}
+
+func (f *Fixtures) addJSON(resource string, b []byte) error {
+	var objects []map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&objects); err != nil {
+		return err
+	}
+	resource = strings.ToLower(resource)
+	f.objects[resource] = append(f.objects[resource], objects...)
+	return nil
+}
+
+// Resources returns the sorted names of the resources having fixtures.
+func (f *Fixtures) Resources() []string {
+	names := make([]string, 0, len(f.objects))
+	for name := range f.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read stores in v the objects of the resource named after the type of v,
+// e.g. the contacts for a *[]resources.Contact.
 func (f *Fixtures) Read(v interface{}) error {
-	for t, val := range f.data {
-		if reflect.ValueOf(v).Type().String() == reflect.ValueOf(t).Type().String() {
-			return json.Unmarshal(val, v)
+	resource, err := resourceOf(v)
+	if err != nil {
+		return err
+	}
+	objects, ok := f.objects[resource]
+	if !ok {
+		return ErrNotFound
+	}
+	return decode(objects, v)
+}
+
+// Get stores in v the object of the resource with this ID or AltID.
+// v is a pointer to a struct, or to a slice as for Client.Get.
+func (f *Fixtures) Get(resource, id string, v interface{}) error {
+	resource = strings.ToLower(resource)
+	alt := altIDs[resource]
+	for _, o := range f.objects[resource] {
+		if matches(o["ID"], id) || (alt != "" && matches(o[alt], id)) {
+			return decode([]map[string]interface{}{o}, v)
 		}
 	}
-	return errors.New("not found")
+	return ErrNotFound
+}
+
+// List stores in v the objects of the resource selected by the query, and returns
+// the Count and Total of the response. The query supports Limit, Offset, Sort and
+// countOnly as the API does. Other parameters filter on the property of the same name;
+// they are ignored by the objects without this property, as the API ignores unknown filters.
+func (f *Fixtures) List(resource string, query url.Values, v interface{}) (count, total int, err error) {
+	objects, ok := f.objects[strings.ToLower(resource)]
+	if !ok {
+		return 0, 0, ErrNotFound
+	}
+
+	limit, offset := DefaultLimit, 0
+	sortBy := ""
+	countOnly := false
+	filters := make(map[string]string)
+	for key, values := range query {
+		value := values[0]
+		switch strings.ToLower(key) {
+		case "limit":
+			limit, _ = strconv.Atoi(value)
+			if limit <= 0 || limit > MaxLimit {
+				limit = MaxLimit
+			}
+		case "offset":
+			offset, _ = strconv.Atoi(value)
+		case "sort":
+			sortBy = value
+		case "countonly":
+			countOnly = value == "1" || strings.EqualFold(value, "true")
+		default:
+			filters[key] = value
+		}
+	}
+
+	var found []map[string]interface{}
+	for _, o := range objects {
+		if matchesFilters(o, filters) {
+			found = append(found, o)
+		}
+	}
+	sortObjects(found, sortBy)
+
+	total = len(found)
+	if countOnly {
+		return total, total, nil
+	}
+	if offset > len(found) {
+		offset = len(found)
+	}
+	found = found[offset:]
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	if v != nil {
+		if err = decode(found, v); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(found), total, nil
+}
+
+func matchesFilters(o map[string]interface{}, filters map[string]string) bool {
+	for key, value := range filters {
+		for property, v := range o {
+			if strings.EqualFold(property, key) && !matches(v, value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matches reports whether the property value v equals the query value.
+func matches(v interface{}, value string) bool {
+	if b, ok := v.(bool); ok {
+		parsed, err := strconv.ParseBool(value)
+		return err == nil && parsed == b
+	}
+	return v != nil && strings.EqualFold(fmt.Sprint(v), value)
+}
+
+// sortObjects sorts by a property, given as "Property" or "Property DESC".
+func sortObjects(objects []map[string]interface{}, sortBy string) {
+	fields := strings.Fields(strings.Replace(sortBy, "+", " ", -1))
+	if len(fields) == 0 {
+		return
+	}
+	desc := len(fields) > 1 && strings.EqualFold(fields[1], "DESC")
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := fmt.Sprint(objects[i][fields[0]]), fmt.Sprint(objects[j][fields[0]])
+		if desc {
+			a, b = b, a
+		}
+		fa, errA := strconv.ParseFloat(a, 64)
+		fb, errB := strconv.ParseFloat(b, 64)
+		if errA == nil && errB == nil {
+			return fa < fb
+		}
+		return a < b
+	})
+}
+
+// decode stores the objects in v, a pointer to a slice or to a single value.
+func decode(objects []map[string]interface{}, v interface{}) error {
+	if objects == nil {
+		objects = []map[string]interface{}{}
+	}
+	var data interface{} = objects
+	if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() != reflect.Slice {
+		if len(objects) == 0 {
+			return ErrNotFound
+		}
+		data = objects[0]
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// resourceOf returns the resource of a pointer to a struct of the resources package,
+// or to a slice of them.
+func resourceOf(v interface{}) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+		return "", fmt.Errorf("fixtures: no resource for %T", v)
+	}
+	return strings.ToLower(t.Name()), nil
 }
 
 // User fixture info
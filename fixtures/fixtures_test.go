@@ -0,0 +1,100 @@
+package fixtures_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/fixtures"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+var files = fstest.MapFS{
+	"testdata/contact.json": {Data: []byte(`[
+		{"ID": 1, "Email": "passenger1@mailjet.com", "IsExcludedFromCampaigns": false, "DeliveredCount": 3},
+		{"ID": 2, "Email": "passenger2@mailjet.com", "IsExcludedFromCampaigns": true, "DeliveredCount": 1},
+		{"ID": 3, "Email": "passenger3@mailjet.com", "IsExcludedFromCampaigns": false, "DeliveredCount": 2}
+	]`)},
+	"testdata/contactslist.json": {Data: []byte(`[{"ID": 84, "Address": "x7k2p", "Name": "Passengers"}]`)},
+	"testdata/README.md":         {Data: []byte(`not a fixture`)},
+}
+
+func TestLoad(t *testing.T) {
+	fx, err := fixtures.Load(files, "testdata")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if resources := fx.Resources(); len(resources) != 2 || resources[0] != "contact" || resources[1] != "contactslist" {
+		t.Fatalf("Wrong resources: %v", resources)
+	}
+
+	var contact resources.Contact
+	if err = fx.Get("contact", "passenger2@mailjet.com", &contact); err != nil || contact.ID != 2 {
+		t.Fatalf("Wrong contact by AltID: %+v (%v)", contact, err)
+	}
+	var lists []resources.Contactslist
+	if err = fx.Get("contactslist", "84", &lists); err != nil || len(lists) != 1 || lists[0].Name != "Passengers" {
+		t.Fatalf("Wrong list by ID: %+v (%v)", lists, err)
+	}
+	if err = fx.Get("contact", "4", &contact); !errors.Is(err, fixtures.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	var contacts []resources.Contact
+	if err = fx.Read(&contacts); err != nil || len(contacts) != 3 || contacts[0].ID != 1 {
+		t.Fatalf("Wrong contacts: %+v (%v)", contacts, err)
+	}
+
+	if _, err = fixtures.Load(fstest.MapFS{"bad/user.json": {Data: []byte(`{}`)}}, "bad"); err == nil {
+		t.Fatal("Expected an error for a file which is not an array")
+	}
+}
+
+func TestList(t *testing.T) {
+	fx, err := fixtures.Load(files, "testdata")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var contacts []resources.Contact
+	count, total, err := fx.List("contact", url.Values{
+		"IsExcludedFromCampaigns": {"false"},
+		"Sort":                    {"DeliveredCount DESC"},
+		"ContactsList":            {"84"},
+	}, &contacts)
+	if err != nil || count != 2 || total != 2 || contacts[0].ID != 1 || contacts[1].ID != 3 {
+		t.Fatalf("Wrong filtered list: %d/%d %+v (%v)", count, total, contacts, err)
+	}
+
+	count, total, err = fx.List("contact", url.Values{"Limit": {"1"}, "Offset": {"1"}}, &contacts)
+	if err != nil || count != 1 || total != 3 || contacts[0].ID != 2 {
+		t.Fatalf("Wrong page: %d/%d %+v (%v)", count, total, contacts, err)
+	}
+
+	count, total, err = fx.List("contact", url.Values{"countOnly": {"1"}}, nil)
+	if err != nil || count != 3 || total != 3 {
+		t.Fatalf("Wrong count: %d/%d (%v)", count, total, err)
+	}
+
+	if _, _, err = fx.List("sender", nil, &contacts); !errors.Is(err, fixtures.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	fx := fixtures.New()
+	for i := 0; i < 10; i++ {
+		var lists []resources.Contactslist
+		if err := fx.Read(&lists); err != nil || len(lists) != 1 || lists[0].ID != 84 {
+			t.Fatalf("Wrong default lists: %+v (%v)", lists, err)
+		}
+	}
+	if err := fx.Add("contact", resources.Contact{ID: 43, Email: "passenger@mailjet.com"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var contact resources.Contact
+	if err := fx.Get("Contact", "43", &contact); err != nil || contact.Email != "passenger@mailjet.com" {
+		t.Fatalf("Wrong added contact: %+v (%v)", contact, err)
+	}
+}
@@ -3,6 +3,7 @@ package mailjet
 import (
 	"encoding/csv"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync"
@@ -78,7 +79,8 @@ func (c *HTTPClient) SendMailV31(req *http.Request) (*http.Response, error) {
 	return res, err
 }
 
-// Read binds the response to the underlying http client
+// Read binds the response to the underlying http client.
+// A *[]byte receives the raw body and a *[][]string the records of a CSV body.
 func (c *HTTPClient) Read(response interface{}) HTTPClientInterface {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -112,13 +114,21 @@ func (c *HTTPClient) Call() (count, total int, err error) {
 		return count, total, fmt.Errorf("empty response")
 	}
 
+	if raw, ok := c.response.(*[]byte); ok {
+		*raw, err = ioutil.ReadAll(resp.Body)
+		return count, total, err
+	}
 	if c.response != nil {
 		if resp.Header["Content-Type"] != nil {
 			contentType := strings.ToLower(resp.Header["Content-Type"][0])
 			if strings.Contains(contentType, "application/json") {
 				return readJSONResult(resp.Body, c.response)
 			} else if strings.Contains(contentType, "text/csv") {
-				c.response, err = csv.NewReader(resp.Body).ReadAll()
+				var records [][]string
+				records, err = csv.NewReader(resp.Body).ReadAll()
+				if r, ok := c.response.(*[][]string); ok {
+					*r = records
+				}
 			}
 		}
 	}
@@ -0,0 +1,324 @@
+package mailjet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Expectation is a call expected by an HTTPClientMock, and the response to return.
+type Expectation struct {
+	method  string
+	path    string
+	query   map[string]string
+	headers map[string]string
+	body    interface{}
+	hasBody bool
+
+	data     interface{}
+	total    int
+	hasTotal bool
+	err      error
+
+	times int
+	calls int
+}
+
+// MockCall is a request received by an HTTPClientMock.
+type MockCall struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Expect registers an expected call with this method on a URL path ending with path,
+// e.g. "/DATA/contactslist/42/CSVData/text:plain".
+// Once an expectation is registered, the mock no longer returns the fixtures:
+// each call must match an expectation, which is used once unless Times is set.
+func (c *HTTPClientMock) Expect(method, path string) *Expectation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &Expectation{method: method, path: path, times: 1}
+	c.expectations = append(c.expectations, e)
+	return e
+}
+
+// ExpectGet registers an expected GET on a REST resource, e.g. "contact" or "contact/42".
+func (c *HTTPClientMock) ExpectGet(resource string) *Expectation {
+	return c.Expect(http.MethodGet, "/"+apiPath+"/"+resource)
+}
+
+// ExpectPost registers an expected POST on a REST resource, e.g. "contact" or "contact/42/managecontactslists".
+func (c *HTTPClientMock) ExpectPost(resource string) *Expectation {
+	return c.Expect(http.MethodPost, "/"+apiPath+"/"+resource)
+}
+
+// ExpectPut registers an expected PUT on a REST resource, e.g. "contact/42".
+func (c *HTTPClientMock) ExpectPut(resource string) *Expectation {
+	return c.Expect(http.MethodPut, "/"+apiPath+"/"+resource)
+}
+
+// ExpectDelete registers an expected DELETE on a REST resource, e.g. "contactslist/42".
+func (c *HTTPClientMock) ExpectDelete(resource string) *Expectation {
+	return c.Expect(http.MethodDelete, "/"+apiPath+"/"+resource)
+}
+
+// ExpectSendMail registers an expected call to the Send API v3.
+func (c *HTTPClientMock) ExpectSendMail() *Expectation {
+	return c.Expect(http.MethodPost, "/send/message")
+}
+
+// ExpectSendMailV31 registers an expected call to the Send API v3.1.
+func (c *HTTPClientMock) ExpectSendMailV31() *Expectation {
+	return c.Expect(http.MethodPost, ".1/send")
+}
+
+// WithQuery sets a query parameter the request must have, e.g. a filter.
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	if e.query == nil {
+		e.query = make(map[string]string)
+	}
+	e.query[key] = value
+	return e
+}
+
+// WithHeader sets a header the request must have.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	if e.headers == nil {
+		e.headers = make(map[string]string)
+	}
+	e.headers[key] = value
+	return e
+}
+
+// WithBody sets the payload the request must have. Structs are encoded as the Client does,
+// without the read_only fields, and JSON payloads are compared by value.
+func (e *Expectation) WithBody(payload interface{}) *Expectation {
+	e.body = payload
+	e.hasBody = true
+	return e
+}
+
+// Return sets the data of the response: a slice of resources for the REST API,
+// a *SentResult for the Send API v3, or a *ResultsV31 for the Send API v3.1.
+// Count and Total are the length of a slice, 1 otherwise.
+func (e *Expectation) Return(data interface{}) *Expectation {
+	e.data = data
+	return e
+}
+
+// ReturnTotal sets the Total of the response, when it differs from Count.
+func (e *Expectation) ReturnTotal(total int) *Expectation {
+	e.total = total
+	e.hasTotal = true
+	return e
+}
+
+// ReturnError sets the error returned by the call, e.g. a RequestError.
+// For the Send API v3.1, *APIFeedbackErrorsV31 and *ErrorInfoV31 errors are
+// returned as API responses, with their status code.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times sets the number of calls matching the expectation.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) String() string {
+	return fmt.Sprintf("%s %s (%d/%d calls)", e.method, e.path, e.calls, e.times)
+}
+
+// matches returns an error describing why the call does not match the expectation.
+func (e *Expectation) matches(call *MockCall, req *http.Request) error {
+	if call.Method != e.method || !strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), e.path) {
+		return fmt.Errorf("%s %s", call.Method, req.URL.Path)
+	}
+	query := req.URL.Query()
+	for key, value := range e.query {
+		if query.Get(key) != value {
+			return fmt.Errorf("query %s=%q", key, query.Get(key))
+		}
+	}
+	for key, value := range e.headers {
+		if call.Header.Get(key) != value {
+			return fmt.Errorf("header %s=%q", key, call.Header.Get(key))
+		}
+	}
+	if e.hasBody {
+		want, err := convertPayload(e.body, nil)
+		if err != nil {
+			return fmt.Errorf("encoding the expected body: %w", err)
+		}
+		if !sameJSON(want, call.Body) {
+			return fmt.Errorf("body %s, expected %s", call.Body, want)
+		}
+	}
+	return nil
+}
+
+// sameJSON reports whether both payloads are equal, JSON payloads being compared by value.
+func sameJSON(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// expected records the call and returns the expectation it matches.
+func (c *HTTPClientMock) expected(req *http.Request, headers map[string]string) (*Expectation, error) {
+	call := &MockCall{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	for key, value := range headers {
+		call.Header.Set(key, value)
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		call.Body = body
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, call)
+	var mismatches []string
+	for _, e := range c.expectations {
+		if e.calls >= e.times {
+			continue
+		}
+		err := e.matches(call, req)
+		if err == nil {
+			e.calls++
+			return e, nil
+		}
+		mismatches = append(mismatches, e.method+" "+e.path+": "+err.Error())
+	}
+	err := fmt.Errorf("mailjet: unexpected call %s %s", call.Method, call.URL)
+	if len(mismatches) > 0 {
+		err = fmt.Errorf("%w; pending expectations: %s", err, strings.Join(mismatches, "; "))
+	}
+	c.unexpected = append(c.unexpected, err)
+	return nil, err
+}
+
+// callExpected serves Call when expectations are registered.
+func (c *HTTPClientMock) callExpected() (int, int, error) {
+	if c.request == nil {
+		return 0, 0, errors.New("request is nil")
+	}
+	e, err := c.expected(c.request, c.headers)
+	if err != nil {
+		return 0, 0, err
+	}
+	if e.err != nil {
+		return 0, 0, e.err
+	}
+
+	count := 1
+	if v := reflect.ValueOf(e.data); v.Kind() == reflect.Slice {
+		count = v.Len()
+	} else if e.data == nil {
+		count = 0
+	}
+	total := count
+	if e.hasTotal {
+		total = e.total
+	}
+	if c.response == nil || e.data == nil {
+		return count, total, nil
+	}
+	body, err := json.Marshal(map[string]interface{}{"Count": count, "Data": e.data, "Total": total})
+	if err != nil {
+		return 0, 0, err
+	}
+	return readJSONResult(bytes.NewReader(body), c.response)
+}
+
+// sendMailV31Expected serves SendMailV31 when expectations are registered.
+func (c *HTTPClientMock) sendMailV31Expected(req *http.Request) (*http.Response, error) {
+	e, err := c.expected(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	status, payload := http.StatusOK, e.data
+	var feedbackErr *APIFeedbackErrorsV31
+	var infoErr *ErrorInfoV31
+	switch {
+	case errors.As(e.err, &feedbackErr):
+		status, payload = http.StatusBadRequest, feedbackErr
+	case errors.As(e.err, &infoErr):
+		status, payload = infoErr.StatusCode, infoErr
+	case e.err != nil:
+		return nil, e.err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// Calls returns the requests received by the mock since expectations were registered.
+func (c *HTTPClientMock) Calls() []MockCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := make([]MockCall, 0, len(c.calls))
+	for _, call := range c.calls {
+		calls = append(calls, *call)
+	}
+	return calls
+}
+
+// ExpectationsWereMet returns an error if an expectation has not been called
+// as many times as expected, or if an unexpected call has been received.
+func (c *HTTPClientMock) ExpectationsWereMet() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var problems []string
+	for _, err := range c.unexpected {
+		problems = append(problems, err.Error())
+	}
+	for _, e := range c.expectations {
+		if e.calls < e.times {
+			problems = append(problems, "missing call "+e.String())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// hasExpectations reports whether the mock is programmed with expectations.
+func (c *HTTPClientMock) hasExpectations() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.expectations) > 0
+}
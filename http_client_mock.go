@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/mailjet/mailjet-apiv3-go/v4/fixtures"
 )
@@ -19,8 +21,14 @@ type HTTPClientMock struct {
 	response        interface{}
 	validCreds      bool
 	fx              *fixtures.Fixtures
+	fxRouted        bool
 	CallFunc        func() (int, int, error)
 	SendMailV31Func func(req *http.Request) (*http.Response, error)
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []*MockCall
+	unexpected   []error
 }
 
 // NewhttpClientMock instanciate new httpClientMock
@@ -76,7 +84,16 @@ func (c *HTTPClientMock) With(headers map[string]string) HTTPClientInterface {
 }
 
 // Read allow you to bind the response received through the underlying http client
+// When expectations are registered, the response is the one of the matching expectation.
 func (c *HTTPClientMock) Read(response interface{}) HTTPClientInterface {
+	if c.hasExpectations() {
+		c.response = response
+		return c
+	}
+	if c.fxRouted {
+		c.response = response
+		return c
+	}
 	err := c.fx.Read(response)
 	if err != nil {
 		log.Println(fmt.Errorf("c.fx.Read: %w", err))
@@ -87,10 +104,72 @@ func (c *HTTPClientMock) Read(response interface{}) HTTPClientInterface {
 
 // SendMailV31 mock function
 func (c *HTTPClientMock) SendMailV31(req *http.Request) (*http.Response, error) {
+	if c.hasExpectations() {
+		return c.sendMailV31Expected(req)
+	}
 	return c.SendMailV31Func(req)
 }
 
 // Call the mailjet API
 func (c *HTTPClientMock) Call() (int, int, error) {
+	if c.hasExpectations() {
+		defer c.reset()
+		return c.callExpected()
+	}
+	if c.fxRouted {
+		defer c.reset()
+		return c.callFixtures()
+	}
 	return c.CallFunc()
 }
+
+// UseFixtures makes the mock answer the REST reads from these fixtures, by resource
+// name and ID or AltID of the request URL, with the filters and pagination of its query.
+// An unknown object gives a 404 RequestError. Other methods still use CallFunc.
+func (c *HTTPClientMock) UseFixtures(fx *fixtures.Fixtures) {
+	c.fx = fx
+	c.fxRouted = true
+}
+
+// callFixtures serves Call from the fixtures when UseFixtures has been called.
+func (c *HTTPClientMock) callFixtures() (int, int, error) {
+	if c.request == nil {
+		return 0, 0, errors.New("request is nil")
+	}
+	if c.request.Method != http.MethodGet {
+		return c.CallFunc()
+	}
+	i := strings.Index(c.request.URL.Path, "/"+apiPath+"/")
+	if i < 0 {
+		return c.CallFunc()
+	}
+	segments := strings.Split(strings.Trim(c.request.URL.Path[i+len(apiPath)+2:], "/"), "/")
+
+	var err error
+	count, total := 1, 1
+	switch len(segments) {
+	case 1:
+		count, total, err = c.fx.List(segments[0], c.request.URL.Query(), c.response)
+	case 2:
+		var v interface{} = c.response
+		if v == nil {
+			v = new(interface{})
+		}
+		err = c.fx.Get(segments[0], segments[1], v)
+	default:
+		return c.CallFunc()
+	}
+	if errors.Is(err, fixtures.ErrNotFound) {
+		return 0, 0, RequestError{StatusCode: http.StatusNotFound, ErrorMessage: "Object not found"}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return count, total, nil
+}
+
+func (c *HTTPClientMock) reset() {
+	c.headers = nil
+	c.request = nil
+	c.response = nil
+}
@@ -0,0 +1,150 @@
+package mailjet_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fixtures"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestHTTPClientMockExpectations(t *testing.T) {
+	httpClientMocked := mailjet.NewhttpClientMock(true)
+	m := mailjet.NewClient(httpClientMocked, mailjet.NewSMTPClientMock(true))
+
+	httpClientMocked.ExpectPost("contact").
+		WithBody(&resources.Contact{Email: "passenger@mailjet.com", IsExcludedFromCampaigns: true}).
+		Return([]resources.Contact{{ID: 42, Email: "passenger@mailjet.com"}})
+	httpClientMocked.ExpectGet("contact").
+		WithQuery("Limit", "2").
+		Return([]resources.Contact{{ID: 1}, {ID: 2}}).
+		ReturnTotal(12)
+	httpClientMocked.ExpectDelete("contactslist/84").
+		ReturnError(mailjet.RequestError{StatusCode: http.StatusNotFound, ErrorMessage: "Object not found"})
+	httpClientMocked.ExpectSendMailV31().
+		Return(&mailjet.ResultsV31{ResultsV31: []mailjet.ResultV31{{Status: "success"}}}).
+		Times(2)
+
+	var created []resources.Contact
+	err := m.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "passenger@mailjet.com", IsExcludedFromCampaigns: true},
+	}, &created)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(created) != 1 || created[0].ID != 42 {
+		t.Fatalf("Wrong created contact: %+v", created)
+	}
+
+	var contacts []resources.Contact
+	count, total, err := m.List("contact", &contacts, mailjet.Filter("Limit", "2"))
+	if err != nil || count != 2 || total != 12 || contacts[1].ID != 2 {
+		t.Fatalf("Wrong list: %d/%d %+v (%v)", count, total, contacts, err)
+	}
+
+	var requestErr mailjet.RequestError
+	err = m.Delete(&mailjet.Request{Resource: "contactslist", ID: 84})
+	if !errors.As(err, &requestErr) || requestErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the programmed error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := m.SendMailV31(&mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{{Subject: "Hello"}}})
+		if err != nil || res.ResultsV31[0].Status != "success" {
+			t.Fatalf("Wrong send result: %+v (%v)", res, err)
+		}
+	}
+
+	if err = httpClientMocked.ExpectationsWereMet(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	calls := httpClientMocked.Calls()
+	if len(calls) != 5 || calls[0].Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Wrong recorded calls: %+v", calls)
+	}
+}
+
+func TestHTTPClientMockUnmetExpectations(t *testing.T) {
+	httpClientMocked := mailjet.NewhttpClientMock(true)
+	m := mailjet.NewClient(httpClientMocked, mailjet.NewSMTPClientMock(true))
+
+	httpClientMocked.ExpectPost("contact").WithBody(&resources.Contact{Email: "passenger@mailjet.com"})
+	httpClientMocked.ExpectGet("sender")
+
+	err := m.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact"},
+		Payload: &resources.Contact{Email: "other@mailjet.com"},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "unexpected call POST") {
+		t.Fatalf("Expected an unexpected call error, got %v", err)
+	}
+
+	_, err = m.SendMailV31(&mailjet.MessagesV31{})
+	if err == nil {
+		t.Fatal("Expected an unexpected call error")
+	}
+
+	err = httpClientMocked.ExpectationsWereMet()
+	if err == nil {
+		t.Fatal("Expected unmet expectations")
+	}
+	for _, want := range []string{"unexpected call POST", "unexpected call POST https://api.mailjet.com/v3.1/send",
+		"missing call POST /REST/contact", "missing call GET /REST/sender"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestHTTPClientMockSendMailV31Errors(t *testing.T) {
+	httpClientMocked := mailjet.NewhttpClientMock(true)
+	m := mailjet.NewClient(httpClientMocked, mailjet.NewSMTPClientMock(true))
+
+	feedback := &mailjet.APIFeedbackErrorsV31{Messages: []mailjet.APIFeedbackErrorV31{{
+		Errors: []mailjet.APIErrorDetailsV31{{ErrorCode: "mj-0013", StatusCode: http.StatusBadRequest}},
+	}}}
+	httpClientMocked.ExpectSendMailV31().ReturnError(feedback)
+	httpClientMocked.ExpectSendMailV31().ReturnError(&mailjet.ErrorInfoV31{StatusCode: http.StatusServiceUnavailable})
+
+	_, err := m.SendMailV31(&mailjet.MessagesV31{})
+	var feedbackErr *mailjet.APIFeedbackErrorsV31
+	if !errors.As(err, &feedbackErr) || feedbackErr.Messages[0].Errors[0].ErrorCode != "mj-0013" {
+		t.Fatalf("Expected the programmed feedback error, got %v", err)
+	}
+	_, err = m.SendMailV31(&mailjet.MessagesV31{})
+	var infoErr *mailjet.ErrorInfoV31
+	if !errors.As(err, &infoErr) || infoErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the programmed server error, got %v", err)
+	}
+}
+
+func TestHTTPClientMockFixtures(t *testing.T) {
+	fx := fixtures.New()
+	if err := fx.Add("contact", resources.Contact{ID: 43, Email: "passenger@mailjet.com"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	httpClientMocked := mailjet.NewhttpClientMock(true)
+	httpClientMocked.UseFixtures(fx)
+	m := mailjet.NewClient(httpClientMocked, mailjet.NewSMTPClientMock(true))
+
+	var contacts []resources.Contact
+	count, total, err := m.List("contact", &contacts, mailjet.Filter("Limit", "1"), mailjet.Filter("Offset", "1"))
+	if err != nil || count != 1 || total != 2 || contacts[0].ID != 43 {
+		t.Fatalf("Wrong list: %d/%d %+v (%v)", count, total, contacts, err)
+	}
+
+	err = m.Get(&mailjet.Request{Resource: "contact", AltID: "passenger@mailjet.com"}, &contacts)
+	if err != nil || len(contacts) != 1 || contacts[0].ID != 43 {
+		t.Fatalf("Wrong contact: %+v (%v)", contacts, err)
+	}
+
+	var requestErr mailjet.RequestError
+	err = m.Get(&mailjet.Request{Resource: "contact", ID: 44}, &contacts)
+	if !errors.As(err, &requestErr) || requestErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a 404 error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package token signs and verifies the tokens of the links sent to contacts, such as the
+// confirmation and unsubscribe links: a JSON payload, with an optional expiry, encoded in
+// base64url and signed with HMAC-SHA256.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MinKeySize is the minimum size of a key, the size of the output of SHA-256.
+const MinKeySize = 32
+
+// Errors of Verify and CheckKey.
+var (
+	ErrInvalid  = errors.New("token: invalid")
+	ErrExpired  = errors.New("token: expired")
+	ErrShortKey = errors.New("token: the key is shorter than 32 bytes")
+)
+
+// CheckKey returns ErrShortKey when key is shorter than MinKeySize.
+func CheckKey(key []byte) error {
+	if len(key) < MinKeySize {
+		return ErrShortKey
+	}
+	return nil
+}
+
+type envelope struct {
+	Expires int64           `json:"exp,omitempty"`
+	Data    json.RawMessage `json:"d"`
+}
+
+// Sign returns a token of v, encoded in JSON, valid until expires. A zero expires never expires.
+func Sign(key []byte, v interface{}, expires time.Time) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	e := envelope{Data: data}
+	if !expires.IsZero() {
+		e.Expires = expires.Unix()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(key, encoded)), nil
+}
+
+// Verify checks the signature and the expiry of a token at now, and decodes its payload into v.
+func Verify(key []byte, token string, now time.Time, v interface{}) error {
+	dot := strings.IndexByte(token, '.')
+	if dot < 0 {
+		return ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(signature, sign(key, token[:dot])) {
+		return ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return ErrInvalid
+	}
+	var e envelope
+	if err = json.Unmarshal(payload, &e); err != nil {
+		return ErrInvalid
+	}
+	if e.Expires != 0 && now.Unix() > e.Expires {
+		return ErrExpired
+	}
+	if err = json.Unmarshal(e.Data, v); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,45 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+type claims struct {
+	Email  string
+	ListID int64
+}
+
+func TestToken(t *testing.T) {
+	key := []byte("secret")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tok, err := Sign(key, claims{"passenger@mailjet.com", 42}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var got claims
+	if err = Verify(key, tok, now, &got); err != nil || got != (claims{"passenger@mailjet.com", 42}) {
+		t.Fatalf("Wrong claims: %+v (%v)", got, err)
+	}
+	if err = Verify(key, tok, now.Add(2*time.Hour), &got); err != ErrExpired {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+	if err = Verify([]byte("other"), tok, now, &got); err != ErrInvalid {
+		t.Errorf("Expected ErrInvalid for another key, got %v", err)
+	}
+	if err = Verify(key, "x"+tok, now, &got); err != ErrInvalid {
+		t.Errorf("Expected ErrInvalid for a modified token, got %v", err)
+	}
+	if err = Verify(key, "garbage", now, &got); err != ErrInvalid {
+		t.Errorf("Expected ErrInvalid, got %v", err)
+	}
+
+	tok, err = Sign(key, claims{Email: "passenger@mailjet.com"}, time.Time{})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = Verify(key, tok, now.AddDate(10, 0, 0), &got); err != nil {
+		t.Errorf("Unexpected error for a token without expiry: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package mailjet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Asynchronous actions on contacts, which return a job to poll with WaitJob.
+const (
+	// ActionManageManyContacts is the action of contact and contactslist to
+	// add, update or subscribe many contacts at once.
+	ActionManageManyContacts = "managemanycontacts"
+	// ActionImportList is the action of contactslist to import the contacts of another list.
+	ActionImportList = "importlist"
+)
+
+// JobOptions are functional options of WaitJob and RunJob.
+type JobOptions func(*jobWaiter)
+
+type jobWaiter struct {
+	backoff  Backoff
+	progress func(resources.Contactsjob)
+	updates  chan<- resources.Contactsjob
+}
+
+// WithJobBackoff sets the schedule of the polls of the job, DefaultBackoff by default.
+func WithJobBackoff(backoff Backoff) JobOptions {
+	return func(w *jobWaiter) {
+		w.backoff = backoff
+	}
+}
+
+// WithJobProgress sets a function called with the job after each poll.
+func WithJobProgress(progress func(job resources.Contactsjob)) JobOptions {
+	return func(w *jobWaiter) {
+		w.progress = progress
+	}
+}
+
+// WithJobUpdates sends the job to updates after each poll. The waiter blocks until the
+// update is received or the context is done; the channel is not closed.
+func WithJobUpdates(updates chan<- resources.Contactsjob) JobOptions {
+	return func(w *jobWaiter) {
+		w.updates = updates
+	}
+}
+
+// JobError is returned by WaitJob when a job failed or has contacts in error.
+type JobError struct {
+	JobID int64
+	Job   resources.Contactsjob
+	// ErrorFile is the content of the error file of the job, as returned by the API.
+	ErrorFile []byte
+}
+
+func (e *JobError) Error() string {
+	msg := fmt.Sprintf("mailjet: job %d: %s", e.JobID, e.Job.Status)
+	if e.Job.Error != "" {
+		msg += ": " + e.Job.Error
+	}
+	return msg
+}
+
+// StartJob posts an asynchronous action, e.g. ActionManageManyContacts on contact with
+// a resources.ContactManagemanycontacts payload, and returns the ID of its job.
+func (c *Client) StartJob(ctx context.Context, fmr *FullRequest) (int64, error) {
+	var jobs []resources.Job
+	if err := c.Post(fmr, &jobs, WithContext(ctx)); err != nil {
+		return 0, err
+	}
+	if len(jobs) == 0 {
+		return 0, fmt.Errorf("mailjet: no job created by %s/%s", fmr.Info.Resource, fmr.Info.Action)
+	}
+	return jobs[0].JobID, nil
+}
+
+// WaitJob polls the job of an asynchronous action until it is done. action is the request
+// of the action which started the job, e.g. &Request{Resource: "contactslist", ID: listID,
+// Action: ActionManageManyContacts}. When the job failed or has contacts in error, it returns
+// the job and a *JobError with the content of its error file.
+func (c *Client) WaitJob(ctx context.Context, action *Request, jobID int64, options ...JobOptions) (*resources.Contactsjob, error) {
+	waiter := &jobWaiter{backoff: DefaultBackoff}
+	for _, option := range options {
+		option(waiter)
+	}
+
+	req := *action
+	req.ActionID = jobID
+	var job resources.Contactsjob
+	err := waiter.backoff.poll(ctx, func() (bool, error) {
+		var jobs []resources.Contactsjob
+		if err := c.Get(&req, &jobs, WithContext(ctx)); err != nil {
+			return false, err
+		}
+		if len(jobs) > 0 {
+			job = jobs[0]
+		}
+		if waiter.progress != nil {
+			waiter.progress(job)
+		}
+		if waiter.updates != nil {
+			select {
+			case waiter.updates <- job:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}
+		switch job.Status {
+		case JobStatusCompleted, JobStatusError, JobStatusAbort:
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return &job, err
+	}
+
+	if job.Status == JobStatusCompleted && job.Error == "" && job.ErrorFile == "" {
+		return &job, nil
+	}
+	jobErr := &JobError{JobID: jobID, Job: job}
+	if job.ErrorFile != "" {
+		if jobErr.ErrorFile, err = c.download(ctx, job.ErrorFile); err != nil {
+			return &job, fmt.Errorf("%s, fetching the error file: %w", jobErr, err)
+		}
+	}
+	return &job, jobErr
+}
+
+// RunJob starts an asynchronous action with StartJob and waits for its job with WaitJob.
+func (c *Client) RunJob(ctx context.Context, fmr *FullRequest, options ...JobOptions) (*resources.Contactsjob, error) {
+	jobID, err := c.StartJob(ctx, fmr)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitJob(ctx, fmr.Info, jobID, options...)
+}
+
+// download returns the body of a file of the API, given by URL or by path from the base URL.
+// The API keys are only sent to the host of the base URL: a file on another host is
+// downloaded without them.
+func (c *Client) download(ctx context.Context, location string) ([]byte, error) {
+	fileURL := location
+	if !strings.Contains(location, "://") {
+		fileURL = strings.TrimSuffix(c.apiBase, "/") + "/" + strings.TrimPrefix(location, "/")
+	}
+	req, err := createRequest("GET", fileURL, nil, nil, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if !c.isAPIHost(req.URL) {
+		return downloadPublic(c.httpClient.Client(), req)
+	}
+
+	var body []byte
+	c.Lock()
+	defer c.Unlock()
+	_, _, err = c.httpClient.Send(req).Read(&body).Call()
+	return body, err
+}
+
+// isAPIHost reports whether u has the scheme and host of the base URL of the API.
+func (c *Client) isAPIHost(u *url.URL) bool {
+	base, err := url.Parse(c.apiBase)
+	return err == nil && strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
+
+// downloadPublic returns the body of a file without the API keys.
+func downloadPublic(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("mailjet: downloading from %s: %s", req.URL.Host, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
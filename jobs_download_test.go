@@ -0,0 +1,32 @@
+package mailjet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadOnlySendsKeysToAPI(t *testing.T) {
+	var auth []bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, ok := r.BasicAuth()
+		auth = append(auth, ok)
+		w.Write([]byte("email,error\n"))
+	})
+	api := httptest.NewServer(handler)
+	defer api.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	client := NewMailjetClient("apiKeyPublic", "apiKeyPrivate", api.URL)
+	for _, location := range []string{"/DATA/BatchJob/1/CSVError/text:csv/LAST", other.URL + "/errors.csv"} {
+		body, err := client.download(context.Background(), location)
+		if err != nil || string(body) != "email,error\n" {
+			t.Fatalf("Wrong file %q: %q (%v)", location, body, err)
+		}
+	}
+	if len(auth) != 2 || !auth[0] || auth[1] {
+		t.Errorf("Expected the API keys on the API host only, got %v", auth)
+	}
+}
@@ -0,0 +1,111 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestRunJob(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"}, resources.Contactslist{Name: "Crew"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	updates := make(chan resources.Contactsjob, 10)
+	job, err := client.RunJob(context.Background(), &mailjet.FullRequest{
+		Info: &mailjet.Request{Resource: "contact", Action: mailjet.ActionManageManyContacts},
+		Payload: resources.ContactManagemanycontacts{
+			ContactsLists: []resources.ContactsListAction{{ListID: ids[0], Action: mailjet.ImportAddForce}},
+			Contacts: []resources.AddContactAction{
+				{Email: "passenger1@mailjet.com", Name: "P1"},
+				{Email: "passenger2@mailjet.com", Name: "P2"},
+			},
+		},
+	}, mailjet.WithJobBackoff(fastBackoff), mailjet.WithJobUpdates(updates))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if job.Status != mailjet.JobStatusCompleted || job.Count != 2 {
+		t.Fatalf("Wrong job: %+v", job)
+	}
+	close(updates)
+	var progress []string
+	for update := range updates {
+		progress = append(progress, update.Status)
+	}
+	if strings.Join(progress, ",") != "In Progress,Completed" {
+		t.Errorf("Wrong progress: %v", progress)
+	}
+
+	job, err = client.RunJob(context.Background(), &mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contactslist", ID: ids[1], Action: mailjet.ActionImportList},
+		Payload: resources.ContactslistImportList{Action: mailjet.ImportAddNoForce, ListID: ids[0]},
+	}, mailjet.WithJobBackoff(fastBackoff))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var recipients []resources.Listrecipient
+	count, _, err := client.List("listrecipient", &recipients, mailjet.ListrecipientFilters.ContactsList(ids[1]))
+	if err != nil || count != 2 {
+		t.Fatalf("Wrong recipients: %d (%v)", count, err)
+	}
+}
+
+func TestWaitJobErrors(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	action := &mailjet.Request{Resource: "contactslist", ID: ids[0], Action: mailjet.ActionManageManyContacts}
+	jobID, err := client.StartJob(context.Background(), &mailjet.FullRequest{
+		Info: action,
+		Payload: resources.ContactslistManageManyContacts{
+			Action: mailjet.ImportAddNoForce,
+			Contacts: []resources.AddContactAction{
+				{Email: "passenger1@mailjet.com"},
+				{Email: "not an address"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var progress []string
+	_, err = client.WaitJob(context.Background(), action, jobID, mailjet.WithJobBackoff(fastBackoff),
+		mailjet.WithJobProgress(func(job resources.Contactsjob) { progress = append(progress, job.Status) }))
+	var jobErr *mailjet.JobError
+	if !errors.As(err, &jobErr) {
+		t.Fatalf("Expected a JobError, got %v", err)
+	}
+	if jobErr.JobID != jobID || jobErr.Job.Error == "" {
+		t.Errorf("Wrong job: %+v", jobErr)
+	}
+	if !strings.HasPrefix(string(jobErr.ErrorFile), "email,error\nnot an address,") {
+		t.Errorf("Wrong error file: %q", jobErr.ErrorFile)
+	}
+	if strings.Join(progress, ",") != "In Progress,Completed" {
+		t.Errorf("Wrong progress: %v", progress)
+	}
+
+	jobID, err = client.StartJob(context.Background(), &mailjet.FullRequest{
+		Info:    action,
+		Payload: resources.ContactslistManageManyContacts{Action: mailjet.ImportAddNoForce},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = client.WaitJob(ctx, action, jobID, mailjet.WithJobBackoff(fastBackoff),
+		mailjet.WithJobProgress(func(resources.Contactsjob) { cancel() }))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a canceled wait, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package mailjet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// DefaultSyncMaxRemoval is the percentage of the recipients of a list above which SyncList
+// refuses to remove contacts.
+const DefaultSyncMaxRemoval = 10
+
+// syncPageSize is the number of objects read per page of a list.
+const syncPageSize = 1000
+
+// SyncContact is a contact of the desired state of a list.
+type SyncContact struct {
+	Email string
+	Name  string
+	// Properties are the contact properties to set. The other properties are left as is.
+	Properties map[string]interface{}
+}
+
+// SyncOptions are functional options of SyncList.
+type SyncOptions func(*listSync)
+
+type listSync struct {
+	dryRun         bool
+	maxRemoval     float64
+	addAction      string
+	removeAction   string
+	upsertOptions  []UpsertOptions
+	skipProperties bool
+}
+
+// WithSyncDryRun only computes the plan of the synchronization, without applying it.
+func WithSyncDryRun() SyncOptions {
+	return func(s *listSync) {
+		s.dryRun = true
+	}
+}
+
+// WithSyncMaxRemoval sets the percentage of the recipients of the list above which the
+// removals are refused, DefaultSyncMaxRemoval by default. 100 disables the check.
+func WithSyncMaxRemoval(percent float64) SyncOptions {
+	return func(s *listSync) {
+		s.maxRemoval = percent
+	}
+}
+
+// WithSyncAddAction sets the action applied to the added contacts, ImportAddNoForce by default.
+// ImportAddForce subscribes again the contacts which unsubscribed from the list.
+func WithSyncAddAction(action string) SyncOptions {
+	return func(s *listSync) {
+		s.addAction = action
+	}
+}
+
+// WithSyncRemoveAction sets the action applied to the contacts missing from the source,
+// ImportRemove by default, or ImportUnsub to unsubscribe them.
+func WithSyncRemoveAction(action string) SyncOptions {
+	return func(s *listSync) {
+		s.removeAction = action
+	}
+}
+
+// WithSyncUpsertOptions sets the options of the jobs applying the plan, e.g. WithUpsertConcurrency.
+func WithSyncUpsertOptions(options ...UpsertOptions) SyncOptions {
+	return func(s *listSync) {
+		s.upsertOptions = options
+	}
+}
+
+// WithSyncSkipProperties ignores the properties, to only synchronize the subscriptions.
+func WithSyncSkipProperties() SyncOptions {
+	return func(s *listSync) {
+		s.skipProperties = true
+	}
+}
+
+// SyncPlan is the difference between a list and its desired state.
+type SyncPlan struct {
+	ListID int64
+	// Recipients is the number of recipients of the list, subscribed or not.
+	Recipients int
+	// Add are the contacts to add to the list.
+	Add []SyncContact
+	// Update are the contacts of the list with other properties or name.
+	Update []SyncContact
+	// Remove are the addresses of the recipients to remove or unsubscribe.
+	Remove []string
+}
+
+// String returns a summary of the plan, followed by its changes, one per line.
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "list %d: %d to add, %d to update, %d to remove out of %d recipients\n",
+		p.ListID, len(p.Add), len(p.Update), len(p.Remove), p.Recipients)
+	for _, c := range p.Add {
+		fmt.Fprintf(&b, "+ %s\n", c.Email)
+	}
+	for _, c := range p.Update {
+		fmt.Fprintf(&b, "~ %s\n", c.Email)
+	}
+	for _, email := range p.Remove {
+		fmt.Fprintf(&b, "- %s\n", email)
+	}
+	return b.String()
+}
+
+// SyncResult is the result of SyncList.
+type SyncResult struct {
+	Plan SyncPlan
+	// Upserted is the report of the additions and updates, and Removed the one of the removals.
+	// They are nil in a dry run.
+	Upserted *UpsertReport
+	Removed  *UpsertReport
+}
+
+// SyncThresholdError is returned by SyncList when the plan removes too many recipients.
+type SyncThresholdError struct {
+	Remove     int
+	Recipients int
+	MaxRemoval float64
+}
+
+func (e *SyncThresholdError) Error() string {
+	return fmt.Sprintf("mailjet: sync refused: removing %d out of %d recipients is more than %g%%",
+		e.Remove, e.Recipients, e.MaxRemoval)
+}
+
+// syncRecipient is a recipient of the list, with its contact and properties.
+type syncRecipient struct {
+	email          string
+	name           string
+	isUnsubscribed bool
+	properties     map[string]string
+}
+
+// SyncList makes a list contain exactly the source contacts: it reads the recipients of the
+// list with their properties, compares them with the source by normalized e-mail address
+// (see NormalizeEmail), and applies the additions, property updates and removals with
+// UpsertContacts. Unsubscribed recipients which are in the source stay unsubscribed unless
+// the add action is ImportAddForce.
+// When the plan removes more than DefaultSyncMaxRemoval percent of the recipients, nothing
+// is applied and a *SyncThresholdError is returned with the plan.
+func (c *Client) SyncList(ctx context.Context, listID int64, source []SyncContact, options ...SyncOptions) (*SyncResult, error) {
+	s := &listSync{maxRemoval: DefaultSyncMaxRemoval, addAction: ImportAddNoForce, removeAction: ImportRemove}
+	for _, option := range options {
+		option(s)
+	}
+
+	recipients, err := c.syncRecipients(ctx, listID, !s.skipProperties)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Plan: s.plan(listID, recipients, source)}
+	plan := &result.Plan
+
+	if len(plan.Remove) > 0 && s.maxRemoval < 100 &&
+		float64(len(plan.Remove))*100 > s.maxRemoval*float64(plan.Recipients) {
+		return result, &SyncThresholdError{Remove: len(plan.Remove), Recipients: plan.Recipients, MaxRemoval: s.maxRemoval}
+	}
+	if s.dryRun {
+		return result, nil
+	}
+
+	var contacts []resources.AddContactAction
+	for _, list := range [][]SyncContact{plan.Add, plan.Update} {
+		for _, sc := range list {
+			contact := resources.AddContactAction{Email: sc.Email, Name: sc.Name}
+			if len(sc.Properties) > 0 && !s.skipProperties {
+				contact.Properties = syncProperties(sc.Properties)
+			}
+			contacts = append(contacts, contact)
+		}
+	}
+	if len(contacts) > 0 {
+		lists := []resources.ContactsListAction{{ListID: listID, Action: s.addAction}}
+		if result.Upserted, err = c.UpsertContacts(ctx, lists, ContactSlice(contacts), s.upsertOptions...); err != nil {
+			return result, err
+		}
+	}
+
+	if len(plan.Remove) > 0 {
+		contacts = contacts[:0]
+		for _, email := range plan.Remove {
+			contacts = append(contacts, resources.AddContactAction{Email: email})
+		}
+		lists := []resources.ContactsListAction{{ListID: listID, Action: s.removeAction}}
+		if result.Removed, err = c.UpsertContacts(ctx, lists, ContactSlice(contacts), s.upsertOptions...); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// plan compares the recipients of a list with the source.
+func (s *listSync) plan(listID int64, recipients map[string]*syncRecipient, source []SyncContact) SyncPlan {
+	plan := SyncPlan{ListID: listID, Recipients: len(recipients)}
+	wanted := make(map[string]bool, len(source))
+	for _, sc := range source {
+		key := NormalizeEmail(sc.Email)
+		if wanted[key] {
+			continue
+		}
+		wanted[key] = true
+
+		r, ok := recipients[key]
+		switch {
+		case !ok || (r.isUnsubscribed && s.addAction == ImportAddForce):
+			plan.Add = append(plan.Add, sc)
+		case sc.Name != "" && sc.Name != r.name:
+			plan.Update = append(plan.Update, sc)
+		case !s.skipProperties && propertiesDiffer(sc.Properties, r.properties):
+			plan.Update = append(plan.Update, sc)
+		}
+	}
+
+	for key, r := range recipients {
+		if wanted[key] || (r.isUnsubscribed && s.removeAction == ImportUnsub) {
+			continue
+		}
+		plan.Remove = append(plan.Remove, r.email)
+	}
+	sort.Strings(plan.Remove)
+	return plan
+}
+
+// syncProperties returns the properties to send, with the times formatted as contactValue
+// compares them, so that they are found unchanged by the next synchronization.
+func syncProperties(properties map[string]interface{}) map[string]interface{} {
+	sent := make(map[string]interface{}, len(properties))
+	for name, value := range properties {
+		switch value.(type) {
+		case time.Time, *time.Time, resources.RFC3339DateTime, *resources.RFC3339DateTime:
+			sent[name] = contactValue(value)
+		default:
+			sent[name] = value
+		}
+	}
+	return sent
+}
+
+// propertiesDiffer reports whether a wanted property has another value than the current one.
+func propertiesDiffer(wanted map[string]interface{}, current map[string]string) bool {
+	for name, value := range wanted {
+		if contactValue(value) != current[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// syncRecipients reads the recipients of a list, page by page, by normalized e-mail address.
+func (c *Client) syncRecipients(ctx context.Context, listID int64, withProperties bool) (map[string]*syncRecipient, error) {
+	byContact := make(map[int64]*syncRecipient)
+	err := listPages(ctx, "listrecipient", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Listrecipient
+		count, _, err := c.List("listrecipient", &page, append(filters, ListrecipientFilters.ContactsList(listID))...)
+		for _, r := range page {
+			byContact[r.ContactID] = &syncRecipient{isUnsubscribed: r.IsUnsubscribed}
+		}
+		return count, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = listPages(ctx, "contact", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Contact
+		count, _, err := c.List("contact", &page, append(filters, ContactFilters.ContactsList(listID))...)
+		for _, contact := range page {
+			if r := byContact[contact.ID]; r != nil {
+				r.email, r.name = contact.Email, contact.Name
+			}
+		}
+		return count, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if withProperties {
+		err = listPages(ctx, "contactdata", func(filters ...RequestOptions) (int, error) {
+			var page []struct {
+				ContactID int64
+				Data      []struct {
+					Name  string
+					Value interface{}
+				}
+			}
+			count, _, err := c.List("contactdata", &page, append(filters, ContactdataFilters.ContactsList(listID))...)
+			for _, d := range page {
+				r := byContact[d.ContactID]
+				if r == nil {
+					continue
+				}
+				r.properties = make(map[string]string, len(d.Data))
+				for _, p := range d.Data {
+					r.properties[p.Name] = contactValue(p.Value)
+				}
+			}
+			return count, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recipients := make(map[string]*syncRecipient, len(byContact))
+	for _, r := range byContact {
+		if r.email != "" {
+			recipients[NormalizeEmail(r.email)] = r
+		}
+	}
+	return recipients, nil
+}
+
+// listPages calls read with the filters of each page of a resource until a page is not full.
+func listPages(ctx context.Context, resource string, read func(page ...RequestOptions) (int, error)) error {
+	for offset := 0; ; offset += syncPageSize {
+		count, err := read(Filter("Limit", strconv.Itoa(syncPageSize)), Filter("Offset", strconv.Itoa(offset)), WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("mailjet: reading %s: %w", resource, err)
+		}
+		if count < syncPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := map[string]string{
+		" Passenger@MailJet.com ":     "passenger@mailjet.com",
+		"passenger@Bücher.example":    "passenger@xn--bcher-kva.example",
+		"passenger@münchen.de":        "passenger@xn--mnchen-3ya.de",
+		"passenger@例え.テスト":            "passenger@xn--r8jz45g.xn--zckzah",
+		"passenger@xn--mnchen-3ya.de": "passenger@xn--mnchen-3ya.de",
+		"not an address":              "not an address",
+	}
+	for email, want := range tests {
+		if got := mailjet.NormalizeEmail(email); got != want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestSyncList(t *testing.T) {
+	srv, client := newFakeClient(t)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("contactmetadata", resources.Contactmetadata{Name: "firstname", Datatype: "str"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for i := 1; i <= 10; i++ {
+		ids, err := srv.Seed("contact", resources.Contact{Email: fmt.Sprintf("Passenger%d@mailjet.com", i)})
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if _, err = srv.Seed("listrecipient", resources.Listrecipient{ContactID: ids[0], ListID: lists[0]}); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	var source []mailjet.SyncContact
+	for i := 1; i <= 9; i++ {
+		source = append(source, mailjet.SyncContact{Email: fmt.Sprintf("passenger%d@mailjet.com", i)})
+	}
+	source[1].Properties = map[string]interface{}{"firstname": "Jane"}
+	source = append(source, mailjet.SyncContact{Email: "passenger11@mailjet.com"})
+
+	result, err := client.SyncList(context.Background(), lists[0], source, mailjet.WithSyncDryRun())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want := "list 1: 1 to add, 1 to update, 1 to remove out of 10 recipients\n" +
+		"+ passenger11@mailjet.com\n~ passenger2@mailjet.com\n- Passenger10@mailjet.com\n"
+	if plan := result.Plan.String(); plan != want {
+		t.Fatalf("Wrong plan:\n%s\nwant:\n%s", plan, want)
+	}
+	if result.Upserted != nil || result.Removed != nil {
+		t.Fatalf("Dry run applied: %+v", result)
+	}
+
+	result, err = client.SyncList(context.Background(), lists[0], source,
+		mailjet.WithSyncUpsertOptions(mailjet.WithUpsertJobOptions(mailjet.WithJobBackoff(fastBackoff))))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if result.Upserted.Succeeded != 2 || result.Removed.Succeeded != 1 {
+		t.Fatalf("Wrong result: %+v %+v", result.Upserted, result.Removed)
+	}
+	var contacts []resources.Contact
+	count, _, err := client.List("contact", &contacts, mailjet.ContactFilters.ContactsList(lists[0]))
+	if err != nil || count != 10 {
+		t.Fatalf("Wrong contacts: %d (%v)", count, err)
+	}
+	for _, c := range contacts {
+		if strings.EqualFold(c.Email, "passenger10@mailjet.com") {
+			t.Errorf("Contact not removed: %+v", c)
+		}
+	}
+	var props struct {
+		FirstName string `mailjet:"firstname"`
+	}
+	if err = client.GetContactProperties(context.Background(), "passenger2@mailjet.com", &props); err != nil || props.FirstName != "Jane" {
+		t.Fatalf("Wrong properties: %+v (%v)", props, err)
+	}
+
+	result, err = client.SyncList(context.Background(), lists[0], source)
+	if err != nil || len(result.Plan.Add)+len(result.Plan.Update)+len(result.Plan.Remove) != 0 {
+		t.Fatalf("Expected a synchronized list, got %s (%v)", &result.Plan, err)
+	}
+
+	result, err = client.SyncList(context.Background(), lists[0], source[:5])
+	var thresholdErr *mailjet.SyncThresholdError
+	if !errors.As(err, &thresholdErr) || thresholdErr.Remove != 5 || len(result.Plan.Remove) != 5 {
+		t.Fatalf("Expected a SyncThresholdError, got %v", err)
+	}
+}
+
+func TestSyncListDatetime(t *testing.T) {
+	srv, client := newFakeClient(t)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = srv.Seed("contactmetadata", resources.Contactmetadata{Name: "lasttrip", Datatype: mailjet.ContactPropertyDatetime}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	lastTrip := time.Date(2024, 1, 2, 3, 4, 5, 600, time.FixedZone("CET", 3600))
+	source := []mailjet.SyncContact{{Email: "passenger@mailjet.com", Properties: map[string]interface{}{"lasttrip": lastTrip}}}
+	options := mailjet.WithSyncUpsertOptions(mailjet.WithUpsertJobOptions(mailjet.WithJobBackoff(fastBackoff)))
+
+	if _, err = client.SyncList(context.Background(), lists[0], source, options); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	result, err := client.SyncList(context.Background(), lists[0], source, options)
+	if err != nil || len(result.Plan.Add)+len(result.Plan.Update)+len(result.Plan.Remove) != 0 {
+		t.Fatalf("Expected an unchanged datetime, got %s (%v)", &result.Plan, err)
+	}
+}
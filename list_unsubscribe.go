@@ -0,0 +1,119 @@
+package mailjet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Headers of the unsubscribe links of a message (RFC 2369 and RFC 8058).
+const (
+	HeaderListUnsubscribe     = "List-Unsubscribe"
+	HeaderListUnsubscribePost = "List-Unsubscribe-Post"
+)
+
+// ListUnsubscribeOneClick is the value of the List-Unsubscribe-Post header, and the body of
+// the POST request sent to the unsubscribe URL by the mailbox providers (RFC 8058).
+const ListUnsubscribeOneClick = "List-Unsubscribe=One-Click"
+
+// listUnsubscribeHeaders returns the unsubscribe headers of an URL and a mailto address,
+// either of them being optional. One-click unsubscription is announced for HTTPS URLs only.
+func listUnsubscribeHeaders(url, mailto string) (map[string]string, error) {
+	if url == "" && mailto == "" {
+		return nil, errors.New("mailjet: List-Unsubscribe needs an URL or a mailto address")
+	}
+	var links []string
+	if mailto != "" {
+		if !strings.HasPrefix(mailto, "mailto:") {
+			mailto = "mailto:" + mailto
+		}
+		links = append(links, "<"+mailto+">")
+	}
+	if url != "" {
+		links = append(links, "<"+url+">")
+	}
+	headers := map[string]string{HeaderListUnsubscribe: strings.Join(links, ", ")}
+	if strings.HasPrefix(url, "https://") {
+		headers[HeaderListUnsubscribePost] = ListUnsubscribeOneClick
+	}
+	return headers, nil
+}
+
+// SetListUnsubscribe adds the List-Unsubscribe headers of an unsubscribe URL and of a mailto
+// address to the message. Either of them may be empty. The List-Unsubscribe-Post header of
+// one-click unsubscription is added when the URL uses HTTPS, as required by RFC 8058.
+func (m *InfoMessagesV31) SetListUnsubscribe(url, mailto string) error {
+	headers, err := listUnsubscribeHeaders(url, mailto)
+	if err != nil {
+		return err
+	}
+	if m.Headers == nil {
+		m.Headers = make(map[string]interface{})
+	}
+	delete(m.Headers, HeaderListUnsubscribePost)
+	for k, v := range headers {
+		m.Headers[k] = v
+	}
+	return nil
+}
+
+// SetListUnsubscribe adds the List-Unsubscribe headers of an unsubscribe URL and of a mailto
+// address to the mail, as InfoMessagesV31.SetListUnsubscribe does.
+func (info *InfoSMTP) SetListUnsubscribe(url, mailto string) error {
+	headers, err := listUnsubscribeHeaders(url, mailto)
+	if err != nil {
+		return err
+	}
+	if info.Header == nil {
+		info.Header = make(textproto.MIMEHeader)
+	}
+	info.Header.Del(HeaderListUnsubscribePost)
+	for k, v := range headers {
+		info.Header.Set(k, v)
+	}
+	return nil
+}
+
+// SubscriptionStatus is the unsubscription status of a contact: globally, with the
+// UnsubscribedAt and UnsubscribedBy of the contact, and for each of its lists.
+type SubscriptionStatus struct {
+	Contact resources.Contact
+	Lists   []resources.Listrecipient
+}
+
+// Unsubscribed returns the subscriptions the contact unsubscribed from.
+func (s *SubscriptionStatus) Unsubscribed() []resources.Listrecipient {
+	var unsubscribed []resources.Listrecipient
+	for _, r := range s.Lists {
+		if r.IsUnsubscribed {
+			unsubscribed = append(unsubscribed, r)
+		}
+	}
+	return unsubscribed
+}
+
+// SubscriptionStatus returns the unsubscription status of the contact with this e-mail address.
+func (c *Client) SubscriptionStatus(ctx context.Context, email string) (*SubscriptionStatus, error) {
+	var contacts []resources.Contact
+	if err := c.Get(&Request{Resource: "contact", AltID: email}, &contacts, WithContext(ctx)); err != nil {
+		return nil, fmt.Errorf("mailjet: reading contact %s: %w", email, err)
+	}
+	if len(contacts) == 0 {
+		return nil, fmt.Errorf("mailjet: no contact %s", email)
+	}
+	status := &SubscriptionStatus{Contact: contacts[0]}
+	err := listPages(ctx, "listrecipient", func(filters ...RequestOptions) (int, error) {
+		var page []resources.Listrecipient
+		count, _, err := c.List("listrecipient", &page, append(filters, ListrecipientFilters.Contact(status.Contact.ID))...)
+		status.Lists = append(status.Lists, page...)
+		return count, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,31 @@
+package mailjet_test
+
+import (
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+func TestSetListUnsubscribe(t *testing.T) {
+	var m mailjet.InfoMessagesV31
+	if err := m.SetListUnsubscribe("https://example.com/u?t=1", "unsubscribe@example.com"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if m.Headers[mailjet.HeaderListUnsubscribe] != "<mailto:unsubscribe@example.com>, <https://example.com/u?t=1>" ||
+		m.Headers[mailjet.HeaderListUnsubscribePost] != mailjet.ListUnsubscribeOneClick {
+		t.Errorf("Wrong headers: %v", m.Headers)
+	}
+
+	// One-click needs an HTTPS URL.
+	var info mailjet.InfoSMTP
+	if err := info.SetListUnsubscribe("http://example.com/u", ""); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if info.Header.Get(mailjet.HeaderListUnsubscribe) != "<http://example.com/u>" || info.Header.Get(mailjet.HeaderListUnsubscribePost) != "" {
+		t.Errorf("Wrong headers: %v", info.Header)
+	}
+
+	if err := m.SetListUnsubscribe("", ""); err == nil {
+		t.Error("Expected an error without URL nor mailto")
+	}
+}
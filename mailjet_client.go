@@ -8,12 +8,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/textproto"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -86,11 +86,16 @@ func (c *Client) SetClient(client *http.Client) {
 }
 
 // Filter applies a filter with the defined key and value.
+// Values are query-encoded, so a "+" in an e-mail address is kept as is.
+// For compatibility, the "+" of a Sort value such as "ID+DESC" stands for a space.
 func Filter(key, value string) RequestOptions {
+	if key == "Sort" {
+		value = strings.Replace(value, "+", " ", -1)
+	}
 	return func(req *http.Request) {
 		q := req.URL.Query()
 		q.Add(key, value)
-		req.URL.RawQuery = strings.Replace(q.Encode(), "%2B", "+", 1)
+		req.URL.RawQuery = q.Encode()
 	}
 }
 
@@ -121,7 +126,7 @@ func SetDebugOutput(w io.Writer) {
 // Sort applies the Sort filter to the request.
 func Sort(value string, order SortOrder) RequestOptions {
 	if order == SortDesc {
-		value += "+DESC"
+		value += " DESC"
 	}
 	return Filter("Sort", value)
 }
@@ -225,21 +230,38 @@ func (c *Client) SendMail(data *InfoSendMail, options ...RequestOptions) (res *S
 
 // SendMailSMTP send mail via SMTP.
 func (c *Client) SendMailSMTP(info *InfoSMTP) error {
-	return c.smtpClient.SendMail(
-		info.From,
-		info.Recipients,
-		buildMessage(info.Header, info.Content))
+	header, err := info.smtpHeader()
+	if err != nil {
+		return err
+	}
+	msg, err := buildMessage(header, info.Content)
+	if err != nil {
+		return err
+	}
+	return c.smtpClient.SendMail(info.From, info.Recipients, msg)
 }
 
-func buildMessage(header textproto.MIMEHeader, content []byte) []byte {
+// buildMessage writes the header, folding its long fields, and the content of a mail.
+// It returns an error when a field holds a CR or LF, or cannot be folded.
+func buildMessage(header textproto.MIMEHeader, content []byte) ([]byte, error) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	buff := bytes.NewBuffer(nil)
-	for key, values := range header {
-		buff.WriteString(fmt.Sprintf("%s: %s\r\n", key, strings.Join(values, ", ")))
+	for _, key := range keys {
+		field, err := foldHeader(key, strings.Join(header[key], ", "))
+		if err != nil {
+			return nil, err
+		}
+		buff.WriteString(field)
 	}
 	buff.WriteString("\r\n")
 	buff.Write(content)
 
-	return buff.Bytes()
+	return buff.Bytes(), nil
 }
 
 // SendMailV31 sends a mail to the send API v3.1
@@ -282,7 +304,11 @@ func (c *Client) SendMailV31(data *MessagesV31, options ...RequestOptions) (*Res
 
 		var errInfo ErrorInfoV31
 		if err := decoder.Decode(&errInfo); err != nil {
-			return nil, err
+			errInfo.Message = "unexpected server response"
+			errInfo.Info = "json decode error: " + err.Error()
+		}
+		if errInfo.StatusCode == 0 {
+			errInfo.StatusCode = r.StatusCode
 		}
 		return nil, &errInfo
 	}
@@ -146,6 +146,24 @@ type InfoSMTP struct {
 	Recipients []string
 	Header     textproto.MIMEHeader
 	Content    []byte
+	Options    *InfoSMTPOptions
+}
+
+// InfoSMTPOptions bundles the Send API v3.1 features available on the SMTP relay.
+// They are encoded in the X-Mailjet-* and X-MJ-* headers of the mail.
+type InfoSMTPOptions struct {
+	Priority               int
+	CustomCampaign         string
+	DeduplicateCampaign    bool
+	TrackClicks            string
+	TrackOpens             string
+	CustomID               string
+	Variables              map[string]interface{}
+	EventPayload           string
+	TemplateID             int
+	TemplateLanguage       bool
+	TemplateErrorReporting *RecipientV31
+	TemplateErrorDeliver   bool
 }
 
 /*
@@ -0,0 +1,420 @@
+// Package optin implements a double opt-in signup flow on top of a contacts list.
+//
+// Start sends a confirmation message with a template of the Send API v3.1. The template
+// receives the confirmation link in the "confirmation_link" variable; the link carries a
+// token signed with a secret key, holding the signup until it expires. The Flow is the
+// http.Handler of this link: a GET shows a page with a confirm button, as link scanners of
+// mailbox providers open the links of the messages they receive. Once its form is posted and
+// the token is verified, the Flow subscribes the contact to the list with the managecontact
+// action, and records the signup, with the IP addresses of the signup and of the
+// confirmation, in contactslistsignup. A signup is confirmed once: a contact already
+// subscribed, or who unsubscribed since the signup, is left as is.
+//
+//	flow, err := optin.NewFlow(client, secret, templateID, "https://example.com/confirm",
+//		optin.WithRedirects("https://example.com/welcome", "https://example.com/expired"))
+//	http.Handle("/confirm", flow)
+//	err := flow.Start(ctx, optin.Signup{Email: email, ListID: listID, SignupIP: ip})
+//
+// Tokens are signed, not encrypted: the signup they hold, including the properties, can be
+// read by the recipient of the message.
+package optin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/internal/token"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// DefaultTTL is how long a confirmation link is valid by default.
+const DefaultTTL = 48 * time.Hour
+
+// LinkVariable is the template variable holding the confirmation link.
+const LinkVariable = "confirmation_link"
+
+// TokenParam is the query parameter of the confirmation link holding the token.
+const TokenParam = "token"
+
+// DefaultSource is the Source of the contactslistsignup records.
+const DefaultSource = "optin"
+
+// Errors of Confirm.
+var (
+	ErrInvalidToken = errors.New("optin: invalid token")
+	ErrExpiredToken = errors.New("optin: expired token")
+	// ErrSubscribed is returned when the contact is already subscribed to the list.
+	ErrSubscribed = errors.New("optin: already subscribed")
+	// ErrUnsubscribed is returned when the contact unsubscribed from the list after the signup.
+	ErrUnsubscribed = errors.New("optin: unsubscribed after the signup")
+)
+
+// Signup is a subscription to a contacts list waiting for its confirmation.
+type Signup struct {
+	Email      string
+	Name       string `json:",omitempty"`
+	ListID     int64
+	Properties map[string]interface{} `json:",omitempty"`
+	// SignupIP is the IP address the signup was made from.
+	SignupIP string `json:",omitempty"`
+	// SignupAt is set by Start.
+	SignupAt int64 `json:",omitempty"`
+}
+
+// Flow sends the confirmation messages of the signups, and serves their confirmation links.
+type Flow struct {
+	client     *mailjet.Client
+	secret     []byte
+	templateID int
+	confirmURL string
+	from       *mailjet.RecipientV31
+	subject    string
+	variables  map[string]interface{}
+	ttl        time.Duration
+	action     string
+	source     string
+	successURL string
+	failureURL string
+	clientIP   func(r *http.Request) string
+	page       *template.Template
+	onConfirm  func(signup *resources.Contactslistsignup)
+	now        func() time.Time
+}
+
+// Options are functional options that configure the Flow.
+type Options func(*Flow)
+
+// WithFrom sets the sender of the confirmation messages, when the template does not set it.
+func WithFrom(from mailjet.RecipientV31) Options {
+	return func(f *Flow) {
+		f.from = &from
+	}
+}
+
+// WithSubject sets the subject of the confirmation messages, when the template does not set it.
+func WithSubject(subject string) Options {
+	return func(f *Flow) {
+		f.subject = subject
+	}
+}
+
+// WithVariables sets additional variables of the template.
+func WithVariables(variables map[string]interface{}) Options {
+	return func(f *Flow) {
+		f.variables = variables
+	}
+}
+
+// WithTTL sets how long a confirmation link is valid.
+func WithTTL(ttl time.Duration) Options {
+	return func(f *Flow) {
+		f.ttl = ttl
+	}
+}
+
+// WithForce subscribes confirmed contacts with the addforce action, which resubscribes
+// contacts who unsubscribed from the list, instead of addnoforce.
+func WithForce() Options {
+	return func(f *Flow) {
+		f.action = mailjet.ImportAddForce
+	}
+}
+
+// WithSource sets the Source of the contactslistsignup records.
+func WithSource(source string) Options {
+	return func(f *Flow) {
+		f.source = source
+	}
+}
+
+// WithRedirects sets the pages the confirmation link redirects to, when the confirmation
+// succeeds and when it fails. An empty URL stands for a plain text response.
+func WithRedirects(success, failure string) Options {
+	return func(f *Flow) {
+		f.successURL = success
+		f.failureURL = failure
+	}
+}
+
+// WithClientIP sets the function returning the IP address of a confirmation request,
+// e.g. reading X-Forwarded-For behind a trusted proxy. It defaults to the RemoteAddr host.
+func WithClientIP(clientIP func(r *http.Request) string) Options {
+	return func(f *Flow) {
+		f.clientIP = clientIP
+	}
+}
+
+// WithTemplate sets the template of the page of the confirmation link, which posts the
+// TokenParam field to the Flow. It is executed with a ConfirmPage.
+func WithTemplate(page *template.Template) Options {
+	return func(f *Flow) {
+		f.page = page
+	}
+}
+
+// WithConfirmHook sets a function called after each confirmed signup.
+func WithConfirmHook(hook func(signup *resources.Contactslistsignup)) Options {
+	return func(f *Flow) {
+		f.onConfirm = hook
+	}
+}
+
+// WithClock sets the function returning the current time.
+func WithClock(now func() time.Time) Options {
+	return func(f *Flow) {
+		f.now = now
+	}
+}
+
+// NewFlow returns a Flow sending the confirmation messages with the template templateID,
+// and signing their tokens with secret. confirmURL is the URL the Flow is served at.
+// It returns an error when secret is shorter than 32 bytes.
+func NewFlow(client *mailjet.Client, secret []byte, templateID int, confirmURL string, options ...Options) (*Flow, error) {
+	if err := token.CheckKey(secret); err != nil {
+		return nil, fmt.Errorf("optin: %w", err)
+	}
+	f := &Flow{
+		client:     client,
+		secret:     secret,
+		templateID: templateID,
+		confirmURL: confirmURL,
+		ttl:        DefaultTTL,
+		action:     mailjet.ImportAddNoForce,
+		source:     DefaultSource,
+		clientIP:   remoteIP,
+		page:       defaultPage,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		option(f)
+	}
+	return f, nil
+}
+
+// Link returns the confirmation link of a signup.
+func (f *Flow) Link(signup Signup) (string, error) {
+	if signup.Email == "" || signup.ListID == 0 {
+		return "", errors.New("optin: the signup needs an e-mail address and a list")
+	}
+	now := f.now()
+	if signup.SignupAt == 0 {
+		signup.SignupAt = now.Unix()
+	}
+	t, err := token.Sign(f.secret, signup, now.Add(f.ttl))
+	if err != nil {
+		return "", err
+	}
+	separator := "?"
+	if strings.Contains(f.confirmURL, "?") {
+		separator = "&"
+	}
+	return f.confirmURL + separator + TokenParam + "=" + url.QueryEscape(t), nil
+}
+
+// Start sends the confirmation message of a signup.
+func (f *Flow) Start(ctx context.Context, signup Signup) error {
+	link, err := f.Link(signup)
+	if err != nil {
+		return err
+	}
+	variables := map[string]interface{}{}
+	for k, v := range f.variables {
+		variables[k] = v
+	}
+	variables[LinkVariable] = link
+
+	message := mailjet.InfoMessagesV31{
+		From:             f.from,
+		To:               &mailjet.RecipientsV31{{Email: signup.Email, Name: signup.Name}},
+		Subject:          f.subject,
+		TemplateID:       f.templateID,
+		TemplateLanguage: true,
+		Variables:        variables,
+	}
+	_, err = f.client.SendMailV31(&mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{message}}, mailjet.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("optin: sending the confirmation to %s: %w", signup.Email, err)
+	}
+	return nil
+}
+
+// Verify returns the signup of a token. It returns ErrInvalidToken or ErrExpiredToken when
+// the token cannot be used.
+func (f *Flow) Verify(t string) (Signup, error) {
+	var signup Signup
+	switch err := token.Verify(f.secret, t, f.now(), &signup); err {
+	case nil:
+		return signup, nil
+	case token.ErrExpired:
+		return signup, ErrExpiredToken
+	default:
+		return signup, ErrInvalidToken
+	}
+}
+
+// Confirm verifies a token, subscribes its contact to the list and records the signup.
+// It returns ErrInvalidToken or ErrExpiredToken when the token cannot be used, and
+// ErrSubscribed or ErrUnsubscribed, without changing anything, when the contact is already
+// subscribed to the list or unsubscribed from it after the signup.
+func (f *Flow) Confirm(ctx context.Context, t, confirmIP string) (*resources.Contactslistsignup, error) {
+	signup, err := f.Verify(t)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.checkSubscription(ctx, signup); err != nil {
+		return nil, err
+	}
+
+	var contacts []struct {
+		ContactID int64
+	}
+	err = f.client.Post(&mailjet.FullRequest{
+		Info: &mailjet.Request{Resource: "contactslist", ID: signup.ListID, Action: "managecontact"},
+		Payload: resources.ContactslistManageContact{
+			Email:      signup.Email,
+			Name:       signup.Name,
+			Action:     f.action,
+			Properties: signup.Properties,
+		},
+	}, &contacts, mailjet.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("optin: subscribing %s to list %d: %w", signup.Email, signup.ListID, err)
+	}
+
+	record := &resources.Contactslistsignup{
+		ConfirmAt: resources.NewUnixTime(f.now()),
+		ConfirmIP: confirmIP,
+		Email:     signup.Email,
+		ListID:    signup.ListID,
+		SignupIP:  signup.SignupIP,
+		Source:    f.source,
+	}
+	if signup.SignupAt != 0 {
+		record.SignupAt = resources.NewUnixTime(time.Unix(signup.SignupAt, 0))
+	}
+	if len(contacts) > 0 {
+		record.ContactID = contacts[0].ContactID
+	}
+	var records []resources.Contactslistsignup
+	err = f.client.Post(&mailjet.FullRequest{Info: &mailjet.Request{Resource: "contactslistsignup"}, Payload: record}, &records, mailjet.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("optin: recording the signup of %s: %w", signup.Email, err)
+	}
+	if len(records) > 0 {
+		record = &records[0]
+	}
+	if f.onConfirm != nil {
+		f.onConfirm(record)
+	}
+	return record, nil
+}
+
+// checkSubscription returns ErrSubscribed when the contact of a signup is subscribed to its
+// list, and ErrUnsubscribed when the contact unsubscribed from it after the signup.
+func (f *Flow) checkSubscription(ctx context.Context, signup Signup) error {
+	var recipients []resources.Listrecipient
+	_, _, err := f.client.List("listrecipient", &recipients,
+		mailjet.Filter("ContactEmail", signup.Email),
+		mailjet.Filter("ContactsList", strconv.FormatInt(signup.ListID, 10)),
+		mailjet.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("optin: reading the subscription of %s to list %d: %w", signup.Email, signup.ListID, err)
+	}
+	for _, r := range recipients {
+		if r.ListID != 0 && r.ListID != signup.ListID {
+			continue
+		}
+		if !r.IsUnsubscribed {
+			return ErrSubscribed
+		}
+		// An unknown unsubscription time is taken as a recent one.
+		if r.UnsubscribedAt == nil || r.UnsubscribedAt.Unix() >= signup.SignupAt {
+			return ErrUnsubscribed
+		}
+	}
+	return nil
+}
+
+// ConfirmPage is the data of the template of the page of the confirmation link.
+type ConfirmPage struct {
+	Email string
+	Token string
+}
+
+var defaultPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Confirm your subscription</title></head>
+<body>
+<form method="post">
+<p>Confirm the subscription of {{.Email}}.</p>
+<input type="hidden" name="token" value="{{.Token}}">
+<p><button type="submit">Confirm</button></p>
+</form>
+</body></html>
+`))
+
+// ServeHTTP serves the confirmation links: a GET shows the page with the confirm button,
+// and a POST of its form confirms the signup of the token.
+func (f *Flow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		t := r.URL.Query().Get(TokenParam)
+		signup, err := f.Verify(t)
+		if err != nil {
+			f.fail(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err = f.page.Execute(w, ConfirmPage{Email: signup.Email, Token: t}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case http.MethodPost:
+		_, err := f.Confirm(r.Context(), r.FormValue(TokenParam), f.clientIP(r))
+		if err != nil && err != ErrSubscribed {
+			f.fail(w, r, err)
+			return
+		}
+		if f.successURL != "" {
+			http.Redirect(w, r, f.successURL, http.StatusSeeOther)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "Your subscription is confirmed.")
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// fail answers a confirmation link that cannot be confirmed.
+func (f *Flow) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case f.failureURL != "":
+		http.Redirect(w, r, f.failureURL, http.StatusSeeOther)
+	case err == ErrExpiredToken:
+		http.Error(w, "This confirmation link has expired.", http.StatusGone)
+	case err == ErrUnsubscribed:
+		http.Error(w, "This confirmation link is no longer valid.", http.StatusGone)
+	case err == ErrInvalidToken:
+		http.Error(w, "This confirmation link is invalid.", http.StatusBadRequest)
+	default:
+		http.Error(w, "Your subscription could not be confirmed, please try again later.", http.StatusBadGateway)
+	}
+}
+
+// remoteIP returns the host of the RemoteAddr of a request.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,134 @@
+package optin_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/optin"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+func TestFlow(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	srv := fake.NewServer(fake.WithClock(func() time.Time { return now }))
+	t.Cleanup(srv.Close)
+	client := mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "Newsletter"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var confirmed []*resources.Contactslistsignup
+	if _, err = optin.NewFlow(client, []byte("secret"), 42, "https://example.com/confirm"); err == nil {
+		t.Fatal("Expected error for a short secret")
+	}
+	flow, err := optin.NewFlow(client, []byte(secret), 42, "https://example.com/confirm?lang=en",
+		optin.WithFrom(mailjet.RecipientV31{Email: "news@mailjet.com"}),
+		optin.WithClock(func() time.Time { return now }),
+		optin.WithRedirects("https://example.com/welcome", ""),
+		optin.WithForce(),
+		optin.WithConfirmHook(func(signup *resources.Contactslistsignup) { confirmed = append(confirmed, signup) }),
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	err = flow.Start(context.Background(), optin.Signup{Email: "reader@mailjet.com", Name: "Reader", ListID: lists[0], SignupIP: "192.0.2.1"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	messages := srv.Messages()
+	if len(messages) != 1 || messages[0].TemplateID != 42 || !messages[0].TemplateLanguage {
+		t.Fatalf("Wrong messages: %+v", messages)
+	}
+	link, _ := messages[0].Variables[optin.LinkVariable].(string)
+	u, err := url.Parse(link)
+	if err != nil || u.Query().Get("lang") != "en" || u.Query().Get(optin.TokenParam) == "" {
+		t.Fatalf("Wrong link: %s (%v)", link, err)
+	}
+
+	// The token is checked before anything is subscribed.
+	tampered := httptest.NewRequest(http.MethodGet, "/confirm?token=x"+u.Query().Get(optin.TokenParam), nil)
+	w := httptest.NewRecorder()
+	flow.ServeHTTP(w, tampered)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Wrong status of a tampered token: %d", w.Code)
+	}
+
+	// A GET, as made by link scanners, only shows the confirm button.
+	w = httptest.NewRecorder()
+	flow.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/confirm?"+u.RawQuery, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<form method="post">`) || len(confirmed) != 0 {
+		t.Fatalf("Wrong response: %d %s", w.Code, w.Body)
+	}
+
+	w = confirm(flow, u)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://example.com/welcome" {
+		t.Fatalf("Wrong response: %d %s", w.Code, w.Body)
+	}
+	if len(confirmed) != 1 {
+		t.Fatalf("Wrong confirmations: %+v", confirmed)
+	}
+	signup := confirmed[0]
+	if signup.Email != "reader@mailjet.com" || signup.ListID != lists[0] || signup.ContactID == 0 ||
+		signup.SignupIP != "192.0.2.1" || signup.ConfirmIP != "198.51.100.7" || signup.Source != optin.DefaultSource ||
+		signup.ConfirmAt == nil || !signup.ConfirmAt.Equal(now) || signup.SignupAt == nil || !signup.SignupAt.Equal(now) {
+		t.Errorf("Wrong signup: %+v", signup)
+	}
+
+	var recipients []resources.Listrecipient
+	if _, _, err = client.List("listrecipient", &recipients, mailjet.Filter("ContactsList", strconv.FormatInt(lists[0], 10))); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(recipients) != 1 || recipients[0].ContactID != signup.ContactID || recipients[0].IsUnsubscribed {
+		t.Errorf("Wrong recipients: %+v", recipients)
+	}
+
+	// A second confirmation changes nothing.
+	w = confirm(flow, u)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://example.com/welcome" || len(confirmed) != 1 {
+		t.Fatalf("Wrong second confirmation: %d %s (%d)", w.Code, w.Body, len(confirmed))
+	}
+	var signups []resources.Contactslistsignup
+	if _, _, err = client.List("contactslistsignup", &signups); err != nil || len(signups) != 1 {
+		t.Fatalf("Wrong signups: %+v (%v)", signups, err)
+	}
+
+	// The link does not resubscribe a contact who unsubscribed since.
+	now = now.Add(time.Hour)
+	err = client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contactslist", ID: lists[0], Action: "managecontact"},
+		Payload: resources.ContactslistManageContact{Email: "reader@mailjet.com", Action: mailjet.ImportUnsub},
+	}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if w = confirm(flow, u); w.Code != http.StatusGone || len(confirmed) != 1 {
+		t.Errorf("Wrong status after an unsubscription: %d (%d)", w.Code, len(confirmed))
+	}
+
+	now = now.Add(optin.DefaultTTL + time.Minute)
+	if w = confirm(flow, u); w.Code != http.StatusGone {
+		t.Errorf("Wrong status of an expired token: %d", w.Code)
+	}
+}
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+// confirm posts the confirmation form of the link u.
+func confirm(flow *optin.Flow, u *url.URL) *httptest.ResponseRecorder {
+	form := url.Values{optin.TokenParam: {u.Query().Get(optin.TokenParam)}}
+	req := httptest.NewRequest(http.MethodPost, "/confirm", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "198.51.100.7:4242"
+	w := httptest.NewRecorder()
+	flow.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,211 @@
+package outbox
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Subdirectories of a FileStore.
+const (
+	pendingDir   = "pending"
+	deliveredDir = "delivered"
+	deadDir      = "dead"
+)
+
+// FileStore is a Store keeping each entry in a JSON file, so that pending
+// messages survive a restart. Files are written atomically.
+//
+//	<dir>/pending/<id>.json    entries waiting to be sent
+//	<dir>/delivered/<id>       markers of delivered entries
+//	<dir>/dead/<id>.json       dead-letter queue
+//
+// Leases are kept in memory: on restart, every pending entry is due again. The Leases
+// count of the entries is written when they are leased, so that an entry leased before
+// a crash is checked against Mailjet before being sent again.
+type FileStore struct {
+	dir    string
+	mu     sync.Mutex
+	leases map[string]time.Time
+}
+
+// NewFileStore returns a FileStore persisting entries in dir, which is created if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	for _, sub := range []string{pendingDir, deliveredDir, deadDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("outbox: creating store: %w", err)
+		}
+	}
+	return &FileStore{dir: dir, leases: make(map[string]time.Time)}, nil
+}
+
+// Put adds a pending entry.
+func (s *FileStore) Put(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, path := range []string{s.path(pendingDir, e.ID), s.path(deliveredDir, e.ID), s.path(deadDir, e.ID)} {
+		if _, err := os.Stat(path); err == nil {
+			return ErrDuplicate
+		}
+	}
+	return s.write(pendingDir, e)
+}
+
+// Lease returns up to n pending entries due at now.
+func (s *FileStore) Lease(now time.Time, n int, leaseFor time.Duration) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.readAll(pendingDir)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]*Entry, 0, n)
+	for _, e := range entries {
+		if e.NextAttemptAt.After(now) || s.leases[e.ID].After(now) {
+			continue
+		}
+		due = append(due, e)
+	}
+	sortEntries(due)
+	if len(due) > n {
+		due = due[:n]
+	}
+	for i, e := range due {
+		e.Leases++
+		if err = s.write(pendingDir, e); err != nil {
+			// The entries not written are left unleased.
+			return due[:i], err
+		}
+		s.leases[e.ID] = now.Add(leaseFor)
+	}
+	return due, nil
+}
+
+// Ack marks the entry as delivered.
+func (s *FileStore) Ack(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ioutil.WriteFile(s.path(deliveredDir, id), nil, 0o600); err != nil {
+		return err
+	}
+	delete(s.leases, id)
+	return s.remove(pendingDir, id)
+}
+
+// Retry updates the pending entry and releases its lease.
+func (s *FileStore) Retry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.path(pendingDir, e.ID)); err != nil {
+		return ErrNotFound
+	}
+	delete(s.leases, e.ID)
+	return s.write(pendingDir, e)
+}
+
+// Dead moves the entry to the dead-letter queue.
+func (s *FileStore) Dead(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.write(deadDir, e); err != nil {
+		return err
+	}
+	delete(s.leases, e.ID)
+	return s.remove(pendingDir, e.ID)
+}
+
+// DeadLetters returns the entries of the dead-letter queue.
+func (s *FileStore) DeadLetters() ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.readAll(deadDir)
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Pending returns the number of entries waiting to be sent.
+func (s *FileStore) Pending() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names, err := filepath.Glob(filepath.Join(s.dir, pendingDir, "*.json"))
+	return len(names), err
+}
+
+// path returns the file of the entry. IDs are hex encoded
+// since CustomIDs may contain characters not allowed in file names.
+func (s *FileStore) path(sub, id string) string {
+	name := hex.EncodeToString([]byte(id))
+	if sub != deliveredDir {
+		name += ".json"
+	}
+	return filepath.Join(s.dir, sub, name)
+}
+
+func (s *FileStore) write(sub string, e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Join(s.dir, sub), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(sub, e.ID))
+}
+
+func (s *FileStore) remove(sub, id string) error {
+	err := os.Remove(s.path(sub, id))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *FileStore) readAll(sub string) ([]*Entry, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, sub))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(files))
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(s.dir, sub, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err = json.Unmarshal(b, &e); err != nil {
+			return nil, fmt.Errorf("outbox: reading %s: %w", f.Name(), err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
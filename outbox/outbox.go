@@ -0,0 +1,353 @@
+// Package outbox provides a durable queue for messages sent with the Send API v3.1.
+//
+// Messages are enqueued into a Store and sent by a pool of workers, which retry
+// transient failures with backoff and move permanent failures to a dead-letter queue.
+// The CustomID of each message identifies it: a CustomID is enqueued at most once,
+// and before sending a message leased before, whose previous attempt may have reached
+// Mailjet even if the process stopped before recording it, the outbox checks whether a
+// message with this CustomID already exists.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Default settings of an Outbox.
+const (
+	DefaultWorkers      = 4
+	DefaultMaxAttempts  = 10
+	DefaultPollInterval = time.Second
+	DefaultLease        = 5 * time.Minute
+)
+
+// Outbox sends the messages of a Store.
+type Outbox struct {
+	store        Store
+	sender       mailjet.Sender
+	client       *mailjet.Client
+	workers      int
+	maxAttempts  int
+	pollInterval time.Duration
+	lease        time.Duration
+	backoff      func(attempt int) time.Duration
+	isPermanent  func(err error) bool
+	onDelivered  func(e *Entry, report *mailjet.SendReport)
+	onDeadLetter func(e *Entry, err error)
+	now          func() time.Time
+	wakeUp       chan struct{}
+}
+
+// Options are functional options that configure the Outbox.
+type Options func(*Outbox)
+
+// WithSender sets the Sender used to deliver the messages, e.g. a mailjet.FailoverSender.
+func WithSender(sender mailjet.Sender) Options {
+	return func(o *Outbox) {
+		o.sender = sender
+	}
+}
+
+// WithWorkers sets the number of messages sent concurrently.
+func WithWorkers(n int) Options {
+	return func(o *Outbox) {
+		o.workers = n
+	}
+}
+
+// WithMaxAttempts sets the number of attempts after which a message goes to the dead-letter queue.
+func WithMaxAttempts(n int) Options {
+	return func(o *Outbox) {
+		o.maxAttempts = n
+	}
+}
+
+// WithPollInterval sets how often the store is checked for due messages.
+func WithPollInterval(interval time.Duration) Options {
+	return func(o *Outbox) {
+		o.pollInterval = interval
+	}
+}
+
+// WithLease sets how long a message is hidden from other workers while being sent,
+// which is also the time given to each attempt.
+func WithLease(lease time.Duration) Options {
+	return func(o *Outbox) {
+		o.lease = lease
+	}
+}
+
+// WithBackoff sets the delay before the next attempt, given the number of attempts made.
+func WithBackoff(backoff func(attempt int) time.Duration) Options {
+	return func(o *Outbox) {
+		o.backoff = backoff
+	}
+}
+
+// WithPermanentError sets the function deciding whether an error is permanent,
+// sending the message straight to the dead-letter queue.
+func WithPermanentError(isPermanent func(err error) bool) Options {
+	return func(o *Outbox) {
+		o.isPermanent = isPermanent
+	}
+}
+
+// WithDeliveredHook sets a function called after each delivered message.
+// The report is nil when a previous attempt is found to have reached Mailjet.
+func WithDeliveredHook(hook func(e *Entry, report *mailjet.SendReport)) Options {
+	return func(o *Outbox) {
+		o.onDelivered = hook
+	}
+}
+
+// WithDeadLetterHook sets a function called when a message goes to the dead-letter queue.
+func WithDeadLetterHook(hook func(e *Entry, err error)) Options {
+	return func(o *Outbox) {
+		o.onDeadLetter = hook
+	}
+}
+
+// New returns an Outbox sending the messages of store with the Send API v3.1 of client.
+func New(client *mailjet.Client, store Store, options ...Options) *Outbox {
+	o := &Outbox{
+		store:        store,
+		sender:       mailjet.NewSenderV31(client),
+		client:       client,
+		workers:      DefaultWorkers,
+		maxAttempts:  DefaultMaxAttempts,
+		pollInterval: DefaultPollInterval,
+		lease:        DefaultLease,
+		backoff:      ExponentialBackoff(time.Second, 10*time.Minute),
+		isPermanent:  IsPermanentError,
+		now:          time.Now,
+		wakeUp:       make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// ExponentialBackoff returns a backoff doubling from base up to max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+// IsPermanentError reports whether err is a refusal of the message by the API,
+// which would fail again if retried: a 4xx response other than 408 and 429.
+func IsPermanentError(err error) bool {
+	var feedbackErr *mailjet.APIFeedbackErrorsV31
+	if errors.As(err, &feedbackErr) {
+		return true
+	}
+	status := 0
+	var infoErr *mailjet.ErrorInfoV31
+	var requestErr mailjet.RequestError
+	if errors.As(err, &infoErr) {
+		status = infoErr.StatusCode
+	} else if errors.As(err, &requestErr) {
+		status = requestErr.StatusCode
+	}
+	return status >= 400 && status < 500 &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// Enqueue stores the message to be sent and returns its ID.
+// A random CustomID is set on the message if it has none.
+// It returns ErrDuplicate if a message with the same CustomID has already been enqueued.
+func (o *Outbox) Enqueue(message *mailjet.InfoMessagesV31) (string, error) {
+	if message == nil {
+		return "", errors.New("outbox: message is nil")
+	}
+	m := *message
+	if m.CustomID == "" {
+		id, err := randomID()
+		if err != nil {
+			return "", err
+		}
+		m.CustomID = id
+	}
+
+	now := o.now()
+	err := o.store.Put(&Entry{ID: m.CustomID, Message: m, CreatedAt: now, NextAttemptAt: now})
+	if err != nil {
+		return "", err
+	}
+	select {
+	case o.wakeUp <- struct{}{}:
+	default:
+	}
+	return m.CustomID, nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Run sends the messages of the store until ctx is done.
+// No more messages are leased than there are idle workers, so that no lease
+// runs out while its message waits for a worker.
+func (o *Outbox) Run(ctx context.Context) error {
+	idle := make(chan struct{}, o.workers)
+	for i := 0; i < o.workers; i++ {
+		idle <- struct{}{}
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		n := 1
+		for more := true; more && n < o.workers; {
+			select {
+			case <-idle:
+				n++
+			default:
+				more = false
+			}
+		}
+
+		leased, err := o.store.Lease(o.now(), n, o.lease)
+		if err != nil {
+			leased = nil
+		}
+		for _, e := range leased {
+			wg.Add(1)
+			go func(e *Entry) {
+				defer wg.Done()
+				o.process(ctx, e)
+				idle <- struct{}{}
+			}(e)
+		}
+		for i := len(leased); i < n; i++ {
+			idle <- struct{}{}
+		}
+		if err != nil {
+			return err
+		}
+		if len(leased) == n {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-o.wakeUp:
+		}
+	}
+}
+
+// Drain sends the due messages until none is left, and returns the number of pending ones.
+// Messages waiting for a retry are left in the store.
+func (o *Outbox) Drain(ctx context.Context) (int, error) {
+	for {
+		leased, err := o.store.Lease(o.now(), o.workers, o.lease)
+		if err != nil {
+			return 0, err
+		}
+		if len(leased) == 0 {
+			return o.store.Pending()
+		}
+
+		var wg sync.WaitGroup
+		for _, e := range leased {
+			wg.Add(1)
+			go func(e *Entry) {
+				defer wg.Done()
+				o.process(ctx, e)
+			}(e)
+		}
+		wg.Wait()
+		if err = ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// process sends the entry and records the outcome in the store.
+// The attempt is given the time of the lease, after which the entry could be
+// leased again and sent twice.
+func (o *Outbox) process(ctx context.Context, e *Entry) {
+	sendCtx, cancel := context.WithTimeout(ctx, o.lease)
+	defer cancel()
+
+	if e.Attempts > 0 || e.Leases > 1 {
+		if delivered, err := o.alreadyDelivered(sendCtx, e.ID); err == nil && delivered {
+			_ = o.store.Ack(e.ID)
+			if o.onDelivered != nil {
+				o.onDelivered(e, nil)
+			}
+			return
+		}
+	}
+
+	report, err := o.sender.Send(sendCtx, &e.Message)
+	e.Attempts++
+	if err == nil {
+		_ = o.store.Ack(e.ID)
+		if o.onDelivered != nil {
+			o.onDelivered(e, report)
+		}
+		return
+	}
+
+	e.LastError = err.Error()
+	if ctx.Err() != nil {
+		// The attempt has been interrupted by the shutdown, not by the API.
+		e.Attempts--
+		_ = o.store.Retry(e)
+		return
+	}
+	if o.isPermanent(err) || e.Attempts >= o.maxAttempts {
+		_ = o.store.Dead(e)
+		if o.onDeadLetter != nil {
+			o.onDeadLetter(e, err)
+		}
+		return
+	}
+	e.NextAttemptAt = o.now().Add(o.backoff(e.Attempts))
+	_ = o.store.Retry(e)
+}
+
+// alreadyDelivered reports whether Mailjet knows a message with this CustomID,
+// meaning that a previous attempt reported as failed did reach the API.
+func (o *Outbox) alreadyDelivered(ctx context.Context, customID string) (bool, error) {
+	if o.client == nil {
+		return false, nil
+	}
+	var messages []resources.Message
+	count, _, err := o.client.List("message", &messages,
+		mailjet.Filter("CustomID", customID), mailjet.Filter("Limit", "1"), mailjet.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
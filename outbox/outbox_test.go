@@ -0,0 +1,413 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/outbox"
+)
+
+// fakeAPI answers /v3.1/send with the scripted status codes of each CustomID
+// and reports the sent messages on /v3/REST/message.
+type fakeAPI struct {
+	mu       sync.Mutex
+	statuses map[string][]int
+	sent     map[string]int
+	calls    map[string]int
+}
+
+func newFakeAPI(t *testing.T, statuses map[string][]int) (*fakeAPI, *mailjet.Client) {
+	api := &fakeAPI{statuses: statuses, sent: make(map[string]int), calls: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3.1/send", api.send)
+	mux.HandleFunc("/v3/REST/message", api.messages)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return api, mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", server.URL+"/v3")
+}
+
+func (api *fakeAPI) send(w http.ResponseWriter, r *http.Request) {
+	var data mailjet.MessagesV31
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	id := data.Info[0].CustomID
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.calls[id]++
+	status := http.StatusOK
+	if scripted := api.statuses[id]; len(scripted) > 0 {
+		status, api.statuses[id] = scripted[0], scripted[1:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case status == http.StatusOK:
+		api.sent[id]++
+		fmt.Fprintf(w, `{"Messages":[{"Status":"success","CustomID":%q}]}`, id)
+	case status == http.StatusAccepted:
+		// The message is accepted but the response is lost.
+		api.sent[id]++
+		w.WriteHeader(http.StatusBadGateway)
+	case status == http.StatusBadRequest:
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"Messages":[{"Status":"error","Errors":[{"ErrorCode":"mj-0013","StatusCode":400}]}]}`)
+	default:
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"ErrorMessage":"Internal Server Error","StatusCode":%d}`, status)
+	}
+}
+
+func (api *fakeAPI) messages(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	count := api.sent[r.URL.Query().Get("CustomID")]
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"Count":%d,"Data":[],"Total":%d}`, count, count)
+}
+
+func (api *fakeAPI) stats(id string) (calls, sent int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.calls[id], api.sent[id]
+}
+
+func message(customID string) *mailjet.InfoMessagesV31 {
+	return &mailjet.InfoMessagesV31{
+		From:     &mailjet.RecipientV31{Email: "pilot@mailjet.com"},
+		To:       &mailjet.RecipientsV31{{Email: "passenger@mailjet.com"}},
+		Subject:  "Outbox testing",
+		TextPart: "Hello",
+		CustomID: customID,
+	}
+}
+
+func stores(t *testing.T) map[string]func() outbox.Store {
+	return map[string]func() outbox.Store{
+		"memory": func() outbox.Store { return outbox.NewMemoryStore() },
+		"file": func() outbox.Store {
+			dir, err := ioutil.TempDir("", "outbox")
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			t.Cleanup(func() { os.RemoveAll(dir) })
+			store, err := outbox.NewFileStore(dir)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			return store
+		},
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	for name, newStore := range stores(t) {
+		newStore := newStore
+		t.Run(name, func(t *testing.T) {
+			api, client := newFakeAPI(t, map[string][]int{
+				"retried":   {http.StatusInternalServerError, http.StatusServiceUnavailable},
+				"refused":   {http.StatusBadRequest},
+				"exhausted": {500, 500, 500, 500},
+				"lost":      {http.StatusAccepted},
+			})
+			store := newStore()
+
+			var mu sync.Mutex
+			var dead []string
+			box := outbox.New(client, store,
+				outbox.WithWorkers(2),
+				outbox.WithMaxAttempts(3),
+				outbox.WithBackoff(func(int) time.Duration { return 0 }),
+				outbox.WithDeadLetterHook(func(e *outbox.Entry, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					dead = append(dead, e.ID)
+				}),
+			)
+
+			for _, id := range []string{"ok", "retried", "refused", "exhausted", "lost"} {
+				if _, err := box.Enqueue(message(id)); err != nil {
+					t.Fatal("Unexpected error:", err)
+				}
+			}
+			if _, err := box.Enqueue(message("ok")); !errors.Is(err, outbox.ErrDuplicate) {
+				t.Fatalf("Expected ErrDuplicate, got %v", err)
+			}
+			generated, err := box.Enqueue(message(""))
+			if err != nil || generated == "" {
+				t.Fatalf("Expected a generated CustomID, got %q (%v)", generated, err)
+			}
+
+			pending, err := box.Drain(context.Background())
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if pending != 0 {
+				t.Fatalf("Expected no pending message, got %d", pending)
+			}
+
+			for id, want := range map[string][2]int{
+				"ok":        {1, 1},
+				"retried":   {3, 1},
+				"refused":   {1, 0},
+				"exhausted": {3, 0},
+				"lost":      {1, 1},
+				generated:   {1, 1},
+			} {
+				if calls, sent := api.stats(id); calls != want[0] || sent != want[1] {
+					t.Errorf("%s: expected %d calls and %d sent, got %d and %d", id, want[0], want[1], calls, sent)
+				}
+			}
+
+			letters, err := store.DeadLetters()
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if len(letters) != 2 || len(dead) != 2 {
+				t.Fatalf("Expected 2 dead letters, got %d (%v)", len(letters), dead)
+			}
+			for _, e := range letters {
+				if e.LastError == "" || (e.ID != "refused" && e.ID != "exhausted") {
+					t.Errorf("Unexpected dead letter: %+v", e)
+				}
+			}
+			if _, err := box.Enqueue(message("refused")); !errors.Is(err, outbox.ErrDuplicate) {
+				t.Fatalf("Dead letters must not be enqueued again, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOutboxRun(t *testing.T) {
+	api, client := newFakeAPI(t, nil)
+
+	delivered := make(chan string, 10)
+	box := outbox.New(client, outbox.NewMemoryStore(),
+		outbox.WithPollInterval(10*time.Millisecond),
+		outbox.WithDeliveredHook(func(e *outbox.Entry, report *mailjet.SendReport) {
+			delivered <- e.ID
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- box.Run(ctx) }()
+
+	for i := 0; i < 5; i++ {
+		if _, err := box.Enqueue(message(fmt.Sprint("run-", i))); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case <-delivered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for delivery")
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if calls, _ := api.stats("run-0"); calls != 1 {
+		t.Fatalf("Expected one call, got %d", calls)
+	}
+}
+
+// blockingSender holds the messages until they are released, and records
+// the deadline given to each attempt.
+type blockingSender struct {
+	release   chan struct{}
+	mu        sync.Mutex
+	deadlines []time.Time
+}
+
+func (s *blockingSender) Send(ctx context.Context, message *mailjet.InfoMessagesV31) (*mailjet.SendReport, error) {
+	deadline, _ := ctx.Deadline()
+	s.mu.Lock()
+	s.deadlines = append(s.deadlines, deadline)
+	s.mu.Unlock()
+	select {
+	case <-s.release:
+		return &mailjet.SendReport{Transport: mailjet.TransportV31}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// countingStore records the most entries leased at once and not yet settled.
+type countingStore struct {
+	*outbox.MemoryStore
+	mu             sync.Mutex
+	leased, maxOut int
+}
+
+func (s *countingStore) Lease(now time.Time, n int, leaseFor time.Duration) ([]*outbox.Entry, error) {
+	entries, err := s.MemoryStore.Lease(now, n, leaseFor)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leased += len(entries)
+	if s.leased > s.maxOut {
+		s.maxOut = s.leased
+	}
+	return entries, err
+}
+
+func (s *countingStore) settle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leased--
+}
+
+func (s *countingStore) Ack(id string) error {
+	s.settle()
+	return s.MemoryStore.Ack(id)
+}
+
+func (s *countingStore) Retry(e *outbox.Entry) error {
+	s.settle()
+	return s.MemoryStore.Retry(e)
+}
+
+func TestOutboxRunLeasesIdleWorkers(t *testing.T) {
+	store := &countingStore{MemoryStore: outbox.NewMemoryStore()}
+	sender := &blockingSender{release: make(chan struct{})}
+	delivered := make(chan string, 10)
+	box := outbox.New(nil, store,
+		outbox.WithSender(sender),
+		outbox.WithWorkers(2),
+		outbox.WithLease(time.Minute),
+		outbox.WithPollInterval(10*time.Millisecond),
+		outbox.WithDeliveredHook(func(e *outbox.Entry, report *mailjet.SendReport) {
+			delivered <- e.ID
+		}),
+	)
+	for i := 0; i < 6; i++ {
+		if _, err := box.Enqueue(message(fmt.Sprint("idle-", i))); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- box.Run(ctx) }()
+	for i := 0; i < 6; i++ {
+		time.Sleep(10 * time.Millisecond)
+		sender.release <- struct{}{}
+		<-delivered
+	}
+	cancel()
+	<-done
+
+	if store.maxOut > 2 {
+		t.Errorf("Expected at most 2 leased messages, got %d", store.maxOut)
+	}
+	for _, deadline := range sender.deadlines {
+		if deadline.IsZero() || time.Until(deadline) > time.Minute {
+			t.Fatalf("Expected the attempts to be bound by the lease, got %v", deadline)
+		}
+	}
+}
+
+func TestOutboxLeaseTimeout(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	box := outbox.New(nil, store,
+		outbox.WithSender(&blockingSender{release: make(chan struct{})}),
+		outbox.WithLease(20*time.Millisecond),
+	)
+	if _, err := box.Enqueue(message("slow")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	pending, err := box.Drain(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if pending != 1 {
+		t.Fatalf("Expected the message to wait for a retry, got %d pending", pending)
+	}
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := outbox.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	now := time.Now()
+	if err = store.Put(&outbox.Entry{ID: "a/b", Message: *message("a/b"), CreatedAt: now}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if leased, _ := store.Lease(now, 10, time.Hour); len(leased) != 1 {
+		t.Fatalf("Expected one leased entry, got %d", len(leased))
+	}
+
+	restarted, err := outbox.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	leased, err := restarted.Lease(now, 10, time.Hour)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(leased) != 1 || leased[0].Message.Subject != "Outbox testing" {
+		t.Fatalf("Expected the entry to survive the restart, got %+v", leased)
+	}
+}
+
+func TestOutboxCrashBeforeAck(t *testing.T) {
+	api, client := newFakeAPI(t, nil)
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := outbox.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err = outbox.New(client, store).Enqueue(message("crashed")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	// The process leases the entry and sends it, then stops before the Ack.
+	leased, err := store.Lease(time.Now(), 1, time.Hour)
+	if err != nil || len(leased) != 1 {
+		t.Fatalf("Expected one leased entry, got %d (%v)", len(leased), err)
+	}
+	if _, err = mailjet.NewSenderV31(client).Send(context.Background(), &leased[0].Message); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	restarted, err := outbox.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var reports []*mailjet.SendReport
+	box := outbox.New(client, restarted, outbox.WithDeliveredHook(func(e *outbox.Entry, report *mailjet.SendReport) {
+		reports = append(reports, report)
+	}))
+	pending, err := box.Drain(context.Background())
+	if err != nil || pending != 0 {
+		t.Fatalf("Expected no pending message, got %d (%v)", pending, err)
+	}
+	if calls, sent := api.stats("crashed"); calls != 1 || sent != 1 || len(reports) != 1 || reports[0] != nil {
+		t.Errorf("Expected the message not to be sent again, got %d calls and %d sent (%v)", calls, sent, reports)
+	}
+}
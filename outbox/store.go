@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+// Errors returned by the stores.
+var (
+	ErrDuplicate = errors.New("outbox: duplicate entry")
+	ErrNotFound  = errors.New("outbox: entry not found")
+)
+
+// Entry is a message waiting to be sent. ID is the CustomID of the message.
+type Entry struct {
+	ID       string
+	Message  mailjet.InfoMessagesV31
+	Attempts int
+	// Leases is the number of times the entry has been leased, this lease included.
+	// An entry leased before may have reached Mailjet, even without a recorded attempt.
+	Leases        int `json:",omitempty"`
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	LastError     string `json:",omitempty"`
+}
+
+// Store persists the entries of the outbox.
+// IDs of delivered and dead entries are remembered so that they are never enqueued again.
+type Store interface {
+	// Put adds a pending entry. It returns ErrDuplicate if the ID is already known.
+	Put(e *Entry) error
+	// Lease returns up to n pending entries due at now, and hides them
+	// from other calls to Lease until leaseFor has elapsed. It increments the
+	// Leases of the entries, and persists it before returning them.
+	Lease(now time.Time, n int, leaseFor time.Duration) ([]*Entry, error)
+	// Ack marks the entry as delivered.
+	Ack(id string) error
+	// Retry updates the pending entry and releases its lease.
+	Retry(e *Entry) error
+	// Dead moves the entry to the dead-letter queue.
+	Dead(e *Entry) error
+	// DeadLetters returns the entries of the dead-letter queue.
+	DeadLetters() ([]*Entry, error)
+	// Pending returns the number of entries waiting to be sent.
+	Pending() (int, error)
+}
+
+// MemoryStore is a Store keeping the entries in memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	pending   map[string]*Entry
+	leases    map[string]time.Time
+	delivered map[string]bool
+	dead      map[string]*Entry
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		pending:   make(map[string]*Entry),
+		leases:    make(map[string]time.Time),
+		delivered: make(map[string]bool),
+		dead:      make(map[string]*Entry),
+	}
+}
+
+// Put adds a pending entry.
+func (s *MemoryStore) Put(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.known(e.ID) {
+		return ErrDuplicate
+	}
+	s.pending[e.ID] = copyEntry(e)
+	return nil
+}
+
+func (s *MemoryStore) known(id string) bool {
+	_, pending := s.pending[id]
+	_, dead := s.dead[id]
+	return pending || dead || s.delivered[id]
+}
+
+// Lease returns up to n pending entries due at now.
+func (s *MemoryStore) Lease(now time.Time, n int, leaseFor time.Duration) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]*Entry, 0, n)
+	for id, e := range s.pending {
+		if e.NextAttemptAt.After(now) || s.leases[id].After(now) {
+			continue
+		}
+		due = append(due, e)
+	}
+	sortEntries(due)
+	if len(due) > n {
+		due = due[:n]
+	}
+
+	leased := make([]*Entry, 0, len(due))
+	for _, e := range due {
+		e.Leases++
+		s.leases[e.ID] = now.Add(leaseFor)
+		leased = append(leased, copyEntry(e))
+	}
+	return leased, nil
+}
+
+// Ack marks the entry as delivered.
+func (s *MemoryStore) Ack(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pending[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.pending, id)
+	delete(s.leases, id)
+	s.delivered[id] = true
+	return nil
+}
+
+// Retry updates the pending entry and releases its lease.
+func (s *MemoryStore) Retry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pending[e.ID]; !ok {
+		return ErrNotFound
+	}
+	s.pending[e.ID] = copyEntry(e)
+	delete(s.leases, e.ID)
+	return nil
+}
+
+// Dead moves the entry to the dead-letter queue.
+func (s *MemoryStore) Dead(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pending[e.ID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.pending, e.ID)
+	delete(s.leases, e.ID)
+	s.dead[e.ID] = copyEntry(e)
+	return nil
+}
+
+// DeadLetters returns the entries of the dead-letter queue.
+func (s *MemoryStore) DeadLetters() ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(s.dead))
+	for _, e := range s.dead {
+		entries = append(entries, copyEntry(e))
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Pending returns the number of entries waiting to be sent.
+func (s *MemoryStore) Pending() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending), nil
+}
+
+func copyEntry(e *Entry) *Entry {
+	c := *e
+	return &c
+}
+
+// sortEntries orders the entries by creation date, oldest first.
+func sortEntries(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+}
@@ -0,0 +1,51 @@
+package mailjet
+
+import (
+	"context"
+	"time"
+)
+
+// Backoff is the schedule of the polls of an asynchronous job: the first poll waits
+// Initial, and each next one waits Factor times longer, up to Max. An unset Initial
+// waits DefaultBackoff.Initial.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+}
+
+// DefaultBackoff polls a job after 1s, 2s, 4s... up to every 30s.
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 30 * time.Second, Factor: 2}
+
+// next returns the wait after d.
+func (b Backoff) next(d time.Duration) time.Duration {
+	if b.Factor > 1 {
+		d = time.Duration(float64(d) * b.Factor)
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// poll calls check with the waits of the backoff until it is done, fails or ctx is done.
+func (b Backoff) poll(ctx context.Context, check func() (done bool, err error)) error {
+	wait := b.Initial
+	if wait <= 0 {
+		wait = DefaultBackoff.Initial
+	}
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		done, err := check()
+		if done || err != nil {
+			return err
+		}
+		wait = b.next(wait)
+	}
+}
@@ -0,0 +1,21 @@
+package mailjet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffPollUnsetInitial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	checks := 0
+	err := Backoff{Max: time.Minute}.poll(ctx, func() (bool, error) {
+		checks++
+		return false, nil
+	})
+	if err != context.DeadlineExceeded || checks != 0 {
+		t.Fatalf("Expected no poll before DefaultBackoff.Initial, got %d (%v)", checks, err)
+	}
+}
@@ -0,0 +1,5 @@
+package resources
+
+// Report how the structs of this package drift from the API, using a snapshot of the
+// metadata resource saved as metadata.json (see cmd/mjgen for how it is saved).
+//go:generate go run ../cmd/mjgen -metadata metadata.json -diff .
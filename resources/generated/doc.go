@@ -0,0 +1,7 @@
+// Package generated holds the resource structs, filters and actions generated by
+// cmd/mjgen from a snapshot of the Mailjet metadata resource, saved as
+// resources/metadata.json. They use the time types of the resources package, whose
+// hand-written structs they can be compared with.
+package generated
+
+//go:generate go run ../../cmd/mjgen -metadata ../metadata.json -pkg generated -o resources_gen.go
@@ -2,33 +2,28 @@
 // not a mandatory package.
 package resources
 
-import (
-	"bytes"
-	"time"
-)
-
 //
 // Resources Properties
 //
 
 // Aggregategraphstatistics: Aggregated campaign statistics grouped over intervals.
 type Aggregategraphstatistics struct {
-	BlockedCount        float64 `mailjet:"read_only"`
-	BlockedStdDev       float64 `mailjet:"read_only"`
-	BouncedCount        float64 `mailjet:"read_only"`
-	BouncedStdDev       float64 `mailjet:"read_only"`
-	CampaignAggregateID int     `mailjet:"read_only"`
-	ClickedCount        float64 `mailjet:"read_only"`
-	ClickedStdDev       float64 `mailjet:"read_only"`
-	OpenedCount         float64 `mailjet:"read_only"`
-	OpenedStdDev        float64 `mailjet:"read_only"`
-	RefTimestamp        int     `mailjet:"read_only"`
-	SentCount           float64 `mailjet:"read_only"`
-	SentStdDev          float64 `mailjet:"read_only"`
-	SpamComplaintCount  float64 `mailjet:"read_only"`
-	SpamcomplaintStdDev float64 `mailjet:"read_only"`
-	UnsubscribedCount   float64 `mailjet:"read_only"`
-	UnsubscribedStdDev  float64 `mailjet:"read_only"`
+	BlockedCount        float64   `mailjet:"read_only"`
+	BlockedStdDev       float64   `mailjet:"read_only"`
+	BouncedCount        float64   `mailjet:"read_only"`
+	BouncedStdDev       float64   `mailjet:"read_only"`
+	CampaignAggregateID int       `mailjet:"read_only"`
+	ClickedCount        float64   `mailjet:"read_only"`
+	ClickedStdDev       float64   `mailjet:"read_only"`
+	OpenedCount         float64   `mailjet:"read_only"`
+	OpenedStdDev        float64   `mailjet:"read_only"`
+	RefTimestamp        *UnixTime `mailjet:"read_only"`
+	SentCount           float64   `mailjet:"read_only"`
+	SentStdDev          float64   `mailjet:"read_only"`
+	SpamComplaintCount  float64   `mailjet:"read_only"`
+	SpamcomplaintStdDev float64   `mailjet:"read_only"`
+	UnsubscribedCount   float64   `mailjet:"read_only"`
+	UnsubscribedStdDev  float64   `mailjet:"read_only"`
 }
 
 // Apikey: Manage your Mailjet API Keys.
@@ -67,16 +62,16 @@ type Apikeyaccess struct {
 
 // Apikeytotals: Global counts for an API Key, since its creation.
 type Apikeytotals struct {
-	BlockedCount       int64 `mailjet:"read_only"`
-	BouncedCount       int64 `mailjet:"read_only"`
-	ClickedCount       int64 `mailjet:"read_only"`
-	DeliveredCount     int64 `mailjet:"read_only"`
-	LastActivity       int64 `mailjet:"read_only"`
-	OpenedCount        int64 `mailjet:"read_only"`
-	ProcessedCount     int64 `mailjet:"read_only"`
-	QueuedCount        int64 `mailjet:"read_only"`
-	SpamcomplaintCount int64 `mailjet:"read_only"`
-	UnsubscribedCount  int64 `mailjet:"read_only"`
+	BlockedCount       int64     `mailjet:"read_only"`
+	BouncedCount       int64     `mailjet:"read_only"`
+	ClickedCount       int64     `mailjet:"read_only"`
+	DeliveredCount     int64     `mailjet:"read_only"`
+	LastActivity       *UnixTime `mailjet:"read_only"`
+	OpenedCount        int64     `mailjet:"read_only"`
+	ProcessedCount     int64     `mailjet:"read_only"`
+	QueuedCount        int64     `mailjet:"read_only"`
+	SpamcomplaintCount int64     `mailjet:"read_only"`
+	UnsubscribedCount  int64     `mailjet:"read_only"`
 }
 
 // Apitoken: Access token for API, used to give access to an API Key in conjunction with our IFrame API.
@@ -127,24 +122,24 @@ type Axtesting struct {
 
 // Batchjob: Batch jobs running on the Mailjet infrastructure.
 type Batchjob struct {
-	AliveAt     int64  `json:",omitempty"`
-	APIKeyID    int64  `json:",omitempty"`
-	APIKeyALT   string `json:",omitempty"`
-	Blocksize   int    `json:",omitempty"`
-	Count       int    `json:",omitempty"`
-	Current     int    `json:",omitempty"`
+	AliveAt     *UnixTime `json:",omitempty"`
+	APIKeyID    int64     `json:",omitempty"`
+	APIKeyALT   string    `json:",omitempty"`
+	Blocksize   int       `json:",omitempty"`
+	Count       int       `json:",omitempty"`
+	Current     int       `json:",omitempty"`
 	Data        *BaseData
-	Errcount    int   `json:",omitempty"`
-	ErrTreshold int   `json:",omitempty"`
-	ID          int64 `mailjet:"read_only"`
-	JobEnd      int64 `json:",omitempty"`
-	JobStart    int64 `json:",omitempty"`
+	Errcount    int       `json:",omitempty"`
+	ErrTreshold int       `json:",omitempty"`
+	ID          int64     `mailjet:"read_only"`
+	JobEnd      *UnixTime `json:",omitempty"`
+	JobStart    *UnixTime `json:",omitempty"`
 	JobType     string
-	Method      string `json:",omitempty"`
-	RefID       int64  `json:"RefID,omitempty"`
-	RequestAt   int64  `json:",omitempty"`
-	Status      string `json:",omitempty"`
-	Throttle    int    `json:",omitempty"`
+	Method      string    `json:",omitempty"`
+	RefID       int64     `json:"RefID,omitempty"`
+	RequestAt   *UnixTime `json:",omitempty"`
+	Status      string    `json:",omitempty"`
+	Throttle    int       `json:",omitempty"`
 }
 
 type Contactsjob struct {
@@ -152,8 +147,8 @@ type Contactsjob struct {
 	Error     string
 	ErrorFile string
 	Status    string
-	JobStart  string
-	JobEnd    string
+	JobStart  *RFC3339DateTime
+	JobEnd    *RFC3339DateTime
 }
 
 // Bouncestatistics: Statistics on the bounces generated by emails sent on a given API Key.
@@ -271,29 +266,29 @@ type CampaigndraftDetailcontent struct {
 
 // Campaigngraphstatistics: API Campaign statistics grouped over intervals
 type Campaigngraphstatistics struct {
-	Clickcount int64 `mailjet:"read_only"`
-	ID         int64 `mailjet:"read_only"`
-	Opencount  int64 `mailjet:"read_only"`
-	Spamcount  int64 `mailjet:"read_only"`
-	Tick       int64 `mailjet:"read_only"`
-	Unsubcount int64 `mailjet:"read_only"`
+	Clickcount int64     `mailjet:"read_only"`
+	ID         int64     `mailjet:"read_only"`
+	Opencount  int64     `mailjet:"read_only"`
+	Spamcount  int64     `mailjet:"read_only"`
+	Tick       *UnixTime `mailjet:"read_only"`
+	Unsubcount int64     `mailjet:"read_only"`
 }
 
 // Campaignoverview: Returns a list of campaigns, including the AX campaigns
 type Campaignoverview struct {
-	ClickedCount   int64  `mailjet:"read_only"`
-	DeliveredCount int64  `mailjet:"read_only"`
-	EditMode       string `mailjet:"read_only"`
-	EditType       string `mailjet:"read_only"`
-	ID             int64  `mailjet:"read_only"`
-	IDType         string `mailjet:"read_only"`
-	OpenedCount    int64  `mailjet:"read_only"`
-	ProcessedCount int64  `mailjet:"read_only"`
-	SendTimeStart  int64  `mailjet:"read_only"`
-	Starred        bool   `mailjet:"read_only"`
-	Status         int    `mailjet:"read_only"`
-	Subject        string `mailjet:"read_only"`
-	Title          string `mailjet:"read_only"`
+	ClickedCount   int64     `mailjet:"read_only"`
+	DeliveredCount int64     `mailjet:"read_only"`
+	EditMode       string    `mailjet:"read_only"`
+	EditType       string    `mailjet:"read_only"`
+	ID             int64     `mailjet:"read_only"`
+	IDType         string    `mailjet:"read_only"`
+	OpenedCount    int64     `mailjet:"read_only"`
+	ProcessedCount int64     `mailjet:"read_only"`
+	SendTimeStart  *UnixTime `mailjet:"read_only"`
+	Starred        bool      `mailjet:"read_only"`
+	Status         int       `mailjet:"read_only"`
+	Subject        string    `mailjet:"read_only"`
+	Title          string    `mailjet:"read_only"`
 }
 
 // Campaignstatistics: Statistics related to emails processed by Mailjet, grouped in a Campaign.
@@ -321,14 +316,14 @@ type Campaignstatistics struct {
 
 // Clickstatistics: Click statistics for messages.
 type Clickstatistics struct {
-	ClickedAt    string `mailjet:"read_only"`
-	ClickedDelay int64  `mailjet:"read_only"`
-	ContactID    int64  `mailjet:"read_only"`
-	ContactALT   string `mailjet:"read_only"`
-	ID           int64  `mailjet:"read_only"`
-	MessageID    int64  `mailjet:"read_only"`
-	URL          string `json:"Url" mailjet:"read_only"`
-	UserAgent    string `mailjet:"read_only"`
+	ClickedAt    *RFC3339DateTime `mailjet:"read_only"`
+	ClickedDelay int64            `mailjet:"read_only"`
+	ContactID    int64            `mailjet:"read_only"`
+	ContactALT   string           `mailjet:"read_only"`
+	ID           int64            `mailjet:"read_only"`
+	MessageID    int64            `mailjet:"read_only"`
+	URL          string           `json:"Url" mailjet:"read_only"`
+	UserAgent    string           `mailjet:"read_only"`
 }
 
 // Contact: Manage the details of a Contact.
@@ -437,17 +432,17 @@ type ContactslistImportList struct {
 
 // Contactslistsignup: Contacts list signup request.
 type Contactslistsignup struct {
-	ConfirmAt  int64  `json:",omitempty"`
-	ConfirmIP  string `json:"ConfirmIp"`
-	ContactID  int64  `json:",omitempty"`
-	ContactALT string `json:",omitempty"`
+	ConfirmAt  *UnixTime `json:",omitempty"`
+	ConfirmIP  string    `json:"ConfirmIp"`
+	ContactID  int64     `json:",omitempty"`
+	ContactALT string    `json:",omitempty"`
 	Email      string
-	ID         int64  `mailjet:"read_only"`
-	ListID     int64  `json:",omitempty"`
-	ListALT    string `json:",omitempty"`
-	SignupAt   int64  `json:",omitempty"`
-	SignupIP   string `json:"SignupIp,omitempty"`
-	SignupKey  string `json:",omitempty"`
+	ID         int64     `mailjet:"read_only"`
+	ListID     int64     `json:",omitempty"`
+	ListALT    string    `json:",omitempty"`
+	SignupAt   *UnixTime `json:",omitempty"`
+	SignupIP   string    `json:"SignupIp,omitempty"`
+	SignupKey  string    `json:",omitempty"`
 	Source     string
 	SourceId   int64 `json:",omitempty"`
 }
@@ -558,17 +553,17 @@ type Geostatistics struct {
 
 // Graphstatistics: API Campaign/message/click statistics grouped over intervals.
 type Graphstatistics struct {
-	BlockedCount       int64  `mailjet:"read_only"`
-	BouncedCount       int64  `mailjet:"read_only"`
-	ClickedCount       int64  `mailjet:"read_only"`
-	DeliveredCount     int64  `mailjet:"read_only"`
-	OpenedCount        int64  `mailjet:"read_only"`
-	ProcessedCount     int64  `mailjet:"read_only"`
-	QueuedCount        int64  `mailjet:"read_only"`
-	RefTimestamp       string `mailjet:"read_only"`
-	SendtimeStart      int64  `mailjet:"read_only"`
-	SpamcomplaintCount int64  `mailjet:"read_only"`
-	UnsubscribedCount  int64  `mailjet:"read_only"`
+	BlockedCount       int64     `mailjet:"read_only"`
+	BouncedCount       int64     `mailjet:"read_only"`
+	ClickedCount       int64     `mailjet:"read_only"`
+	DeliveredCount     int64     `mailjet:"read_only"`
+	OpenedCount        int64     `mailjet:"read_only"`
+	ProcessedCount     int64     `mailjet:"read_only"`
+	QueuedCount        int64     `mailjet:"read_only"`
+	RefTimestamp       *UnixTime `mailjet:"read_only"`
+	SendtimeStart      *UnixTime `mailjet:"read_only"`
+	SpamcomplaintCount int64     `mailjet:"read_only"`
+	UnsubscribedCount  int64     `mailjet:"read_only"`
 }
 
 // Listrecipient: Manage the relationship between a contact and a contactslists.
@@ -650,11 +645,11 @@ type Message struct {
 
 // Messagehistory: Event history of a message.
 type Messagehistory struct {
-	Comment   string `mailjet:"read_only"`
-	EventAt   int64  `mailjet:"read_only"`
-	EventType string `mailjet:"read_only"`
-	State     string `mailjet:"read_only"`
-	UserAgent string `json:"Useragent" mailjet:"read_only"`
+	Comment   string    `mailjet:"read_only"`
+	EventAt   *UnixTime `mailjet:"read_only"`
+	EventType string    `mailjet:"read_only"`
+	State     string    `mailjet:"read_only"`
+	UserAgent string    `json:"Useragent" mailjet:"read_only"`
 }
 
 // Messageinformation: API Key campaign/message information.
@@ -1076,13 +1071,13 @@ type Useragentstatistics struct {
 // Widgets are small registration forms that you may include on your website to ease the process of subscribing to a Contacts List.
 // Mailjet widget definitions.
 type Widget struct {
-	CreatedAt  int64  `json:",omitempty"`
-	FromID     int64  `json:",omitempty"`
-	FromALT    string `json:",omitempty"`
-	ID         int64  `mailjet:"read_only"`
-	IsActive   bool   `json:",omitempty"`
-	ListID     int64  `json:",omitempty"`
-	ListALT    string `json:",omitempty"`
+	CreatedAt  *UnixTime `json:",omitempty"`
+	FromID     int64     `json:",omitempty"`
+	FromALT    string    `json:",omitempty"`
+	ID         int64     `mailjet:"read_only"`
+	IsActive   bool      `json:",omitempty"`
+	ListID     int64     `json:",omitempty"`
+	ListALT    string    `json:",omitempty"`
 	Locale     string
 	Name       string           `json:",omitempty"`
 	Replyto    string           `json:",omitempty"`
@@ -1193,22 +1188,3 @@ type Recipient struct {
 	Email string
 	Name  string
 }
-
-type RFC3339DateTime struct {
-	time.Time
-}
-
-func (dt *RFC3339DateTime) UnmarshalJSON(b []byte) (err error) {
-	b = bytes.Trim(b, `" `)
-	if b == nil {
-		return nil
-	}
-
-	dt.Time, err = time.Parse(time.RFC3339, string(b))
-
-	return err
-}
-
-func (dt *RFC3339DateTime) MarshalJSON() ([]byte, error) {
-	return []byte(dt.Format(`"` + time.RFC3339 + `"`)), nil
-}
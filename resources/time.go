@@ -0,0 +1,98 @@
+package resources
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// The API returns times as RFC3339 strings or as Unix timestamps, depending on the
+// resource. Both RFC3339DateTime and UnixTime accept all of them, as well as empty
+// strings, null and 0 for an unset time, and differ only by how they are sent back.
+
+// RFC3339DateTime is a time sent as an RFC3339 string, or null when zero.
+type RFC3339DateTime struct {
+	time.Time
+}
+
+// UnmarshalJSON parses an RFC3339 string, a Unix timestamp, an empty string or null.
+func (dt *RFC3339DateTime) UnmarshalJSON(b []byte) (err error) {
+	dt.Time, err = parseTime(b)
+	return err
+}
+
+// MarshalJSON returns the RFC3339 string of the time, or null when it is zero or nil.
+func (dt *RFC3339DateTime) MarshalJSON() ([]byte, error) {
+	if dt == nil || dt.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(dt.Format(`"` + time.RFC3339 + `"`)), nil
+}
+
+// UnixTime is a time sent as a Unix timestamp in seconds, or 0 when zero.
+type UnixTime struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Unix timestamp, an RFC3339 string, an empty string or null.
+func (t *UnixTime) UnmarshalJSON(b []byte) (err error) {
+	t.Time, err = parseTime(b)
+	return err
+}
+
+// MarshalJSON returns the Unix timestamp of the time, or 0 when it is zero or nil.
+func (t *UnixTime) MarshalJSON() ([]byte, error) {
+	if t == nil || t.IsZero() {
+		return []byte("0"), nil
+	}
+	return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+}
+
+// NewRFC3339DateTime returns a *RFC3339DateTime, e.g. for the time properties of a payload.
+func NewRFC3339DateTime(t time.Time) *RFC3339DateTime {
+	return &RFC3339DateTime{t}
+}
+
+// NewUnixTime returns a *UnixTime, e.g. for the time properties of a payload.
+func NewUnixTime(t time.Time) *UnixTime {
+	return &UnixTime{t}
+}
+
+// timeLayouts are the layouts of the time strings returned by the API.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// parseTime parses a JSON time. The zero time stands for empty strings, null and 0.
+func parseTime(b []byte) (time.Time, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return time.Time{}, nil
+	}
+	if b[0] == '"' {
+		s, err := strconv.Unquote(string(b))
+		if err != nil {
+			return time.Time{}, err
+		}
+		b = bytes.TrimSpace([]byte(s))
+		if len(b) == 0 {
+			return time.Time{}, nil
+		}
+	}
+
+	s := string(b)
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if sec == 0 {
+			return time.Time{}, nil
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	t, err := time.Parse(timeLayouts[0], s)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts[1:] {
+		if t, lerr := time.Parse(layout, s); lerr == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
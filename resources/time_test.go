@@ -2,6 +2,7 @@ package resources
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -63,3 +64,61 @@ func TestUnmarshalBrokenJSON(t *testing.T) {
 		t.Error("error expected")
 	}
 }
+
+func TestUnmarshalTimes(t *testing.T) {
+	want := time.Date(2016, 10, 14, 12, 42, 5, 0, time.UTC)
+	for _, data := range []string{`"2016-10-14T12:42:05Z"`, `1476448925`, `"1476448925"`, `"2016-10-14T12:42:05"`} {
+		var dt RFC3339DateTime
+		if err := dt.UnmarshalJSON([]byte(data)); err != nil || !dt.Equal(want) {
+			t.Errorf("RFC3339DateTime %s: got %v (%v)", data, dt, err)
+		}
+		var ut UnixTime
+		if err := ut.UnmarshalJSON([]byte(data)); err != nil || !ut.Equal(want) {
+			t.Errorf("UnixTime %s: got %v (%v)", data, ut, err)
+		}
+	}
+
+	for _, data := range []string{`null`, `""`, `" "`, `0`, `"0"`} {
+		dt := RFC3339DateTime{want}
+		if err := dt.UnmarshalJSON([]byte(data)); err != nil || !dt.IsZero() {
+			t.Errorf("RFC3339DateTime %s: got %v (%v)", data, dt, err)
+		}
+	}
+}
+
+func TestTimesInResources(t *testing.T) {
+	var job struct {
+		Batchjob
+		Contactsjob Contactsjob
+	}
+	data := []byte(`{"JobStart": 1476448925, "JobEnd": 0, "AliveAt": null,
+		"Contactsjob": {"JobStart": "2016-10-14T12:42:05Z", "JobEnd": ""}}`)
+	if err := json.Unmarshal(data, &job); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if job.JobStart.Unix() != 1476448925 || !job.JobEnd.IsZero() || job.AliveAt != nil {
+		t.Errorf("wrong batch job times: %v %v %v", job.JobStart, job.JobEnd, job.AliveAt)
+	}
+	if job.Contactsjob.JobStart.Unix() != 1476448925 || !job.Contactsjob.JobEnd.IsZero() {
+		t.Errorf("wrong contacts job times: %v %v", job.Contactsjob.JobStart, job.Contactsjob.JobEnd)
+	}
+
+	b, err := json.Marshal(Batchjob{JobStart: NewUnixTime(time.Unix(1476448925, 0)), JobEnd: &UnixTime{}})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if !bytes.Contains(b, []byte(`"JobEnd":0,"JobStart":1476448925`)) {
+		t.Errorf("wrong batch job: %s", b)
+	}
+}
+
+func TestMarshalNil(t *testing.T) {
+	var dt *RFC3339DateTime
+	if b, err := dt.MarshalJSON(); err != nil || string(b) != "null" {
+		t.Errorf("expected null, got %s (%v)", b, err)
+	}
+	var ut *UnixTime
+	if b, err := ut.MarshalJSON(); err != nil || string(b) != "0" {
+		t.Errorf("expected 0, got %s (%v)", b, err)
+	}
+}
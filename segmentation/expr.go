@@ -0,0 +1,248 @@
+// Package segmentation builds, parses and validates the segmentation expressions of the
+// contactfilter resource, such as `(age>30) AND (country="FR")`:
+//
+//	expr := segmentation.Prop("age").Gt(30).And(segmentation.Prop("country").Eq("FR"))
+//	filter := resources.Contactfilter{Name: "French adults", Expression: expr.String()}
+//
+// Parse reads an existing expression, and Validate checks the properties and values of an
+// expression against the contactmetadata of the account.
+package segmentation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operators of the comparisons.
+const (
+	OpEq = "="
+	OpNe = "!="
+	OpLt = "<"
+	OpLe = "<="
+	OpGt = ">"
+	OpGe = ">="
+)
+
+// Operators of the logical expressions.
+const (
+	OpAnd = "AND"
+	OpOr  = "OR"
+)
+
+// Expr is a node of a segmentation expression: a *Comparison, a *Logical, a *NotExpr or a *Call.
+// Its String method returns the expression in the syntax of the API.
+type Expr interface {
+	String() string
+	And(other Expr) Expr
+	Or(other Expr) Expr
+	Not() Expr
+}
+
+// Property is a contact property, as an operand of a comparison or an argument of a call.
+type Property string
+
+// Prop returns the contact property with this name.
+func Prop(name string) Property {
+	return Property(name)
+}
+
+// Eq returns the comparison "property = value".
+func (p Property) Eq(value interface{}) *Comparison { return compare(p, OpEq, value) }
+
+// Ne returns the comparison "property != value".
+func (p Property) Ne(value interface{}) *Comparison { return compare(p, OpNe, value) }
+
+// Lt returns the comparison "property < value".
+func (p Property) Lt(value interface{}) *Comparison { return compare(p, OpLt, value) }
+
+// Le returns the comparison "property <= value".
+func (p Property) Le(value interface{}) *Comparison { return compare(p, OpLe, value) }
+
+// Gt returns the comparison "property > value".
+func (p Property) Gt(value interface{}) *Comparison { return compare(p, OpGt, value) }
+
+// Ge returns the comparison "property >= value".
+func (p Property) Ge(value interface{}) *Comparison { return compare(p, OpGe, value) }
+
+func compare(p Property, op string, value interface{}) *Comparison {
+	return &Comparison{Property: p, Op: op, Value: literal(value)}
+}
+
+// literal returns the value of a literal: a string, an int64, a float64 or a bool.
+// Times are RFC 3339 strings.
+func literal(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// formatLiteral returns a literal in the syntax of the API, strings being double-quoted
+// and floats having a decimal point.
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		f := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(f, ".eEnN") {
+			f += ".0"
+		}
+		return f
+	case Property:
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// Comparison compares a contact property with a literal value.
+type Comparison struct {
+	Property Property
+	Op       string
+	// Value is a string, an int64, a float64 or a bool.
+	Value interface{}
+}
+
+func (c *Comparison) String() string {
+	return "(" + string(c.Property) + c.Op + formatLiteral(c.Value) + ")"
+}
+
+// And returns "c AND other".
+func (c *Comparison) And(other Expr) Expr { return And(c, other) }
+
+// Or returns "c OR other".
+func (c *Comparison) Or(other Expr) Expr { return Or(c, other) }
+
+// Not returns "NOT c".
+func (c *Comparison) Not() Expr { return Not(c) }
+
+// Logical is the conjunction (AND) or disjunction (OR) of expressions.
+type Logical struct {
+	Op    string
+	Exprs []Expr
+}
+
+func (l *Logical) String() string {
+	parts := make([]string, len(l.Exprs))
+	for i, e := range l.Exprs {
+		parts[i] = e.String()
+		if _, nested := e.(*Logical); nested {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+l.Op+" ")
+}
+
+// And returns "l AND other".
+func (l *Logical) And(other Expr) Expr { return And(l, other) }
+
+// Or returns "l OR other".
+func (l *Logical) Or(other Expr) Expr { return Or(l, other) }
+
+// Not returns "NOT (l)".
+func (l *Logical) Not() Expr { return Not(l) }
+
+// And returns the conjunction of expressions. Nested conjunctions are flattened.
+func And(exprs ...Expr) Expr { return logical(OpAnd, exprs) }
+
+// Or returns the disjunction of expressions. Nested disjunctions are flattened.
+func Or(exprs ...Expr) Expr { return logical(OpOr, exprs) }
+
+func logical(op string, exprs []Expr) Expr {
+	var flat []Expr
+	for _, e := range exprs {
+		if l, ok := e.(*Logical); ok && l.Op == op {
+			flat = append(flat, l.Exprs...)
+		} else if e != nil {
+			flat = append(flat, e)
+		}
+	}
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return &Logical{Op: op, Exprs: flat}
+}
+
+// NotExpr is the negation of an expression.
+type NotExpr struct {
+	Expr Expr
+}
+
+func (n *NotExpr) String() string {
+	if _, ok := n.Expr.(*Logical); ok {
+		return "NOT (" + n.Expr.String() + ")"
+	}
+	return "NOT " + n.Expr.String()
+}
+
+// And returns "n AND other".
+func (n *NotExpr) And(other Expr) Expr { return And(n, other) }
+
+// Or returns "n OR other".
+func (n *NotExpr) Or(other Expr) Expr { return Or(n, other) }
+
+// Not returns the negated expression.
+func (n *NotExpr) Not() Expr { return n.Expr }
+
+// Not returns the negation of an expression.
+func Not(e Expr) Expr {
+	return &NotExpr{Expr: e}
+}
+
+// Call is a call of a function of the segmentation, such as IsInPreviousDays(signup,30).
+type Call struct {
+	Func string
+	// Args are Property values and literals.
+	Args []interface{}
+}
+
+// Func returns the call of a function with arguments, which are Property values and literals.
+func Func(name string, args ...interface{}) *Call {
+	c := &Call{Func: name}
+	for _, arg := range args {
+		if p, ok := arg.(Property); ok {
+			c.Args = append(c.Args, p)
+		} else {
+			c.Args = append(c.Args, literal(arg))
+		}
+	}
+	return c
+}
+
+func (c *Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = formatLiteral(arg)
+	}
+	return c.Func + "(" + strings.Join(args, ",") + ")"
+}
+
+// And returns "c AND other".
+func (c *Call) And(other Expr) Expr { return And(c, other) }
+
+// Or returns "c OR other".
+func (c *Call) Or(other Expr) Expr { return Or(c, other) }
+
+// Not returns "NOT c".
+func (c *Call) Not() Expr { return Not(c) }
@@ -0,0 +1,315 @@
+package segmentation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError reports an invalid expression.
+type SyntaxError struct {
+	// Offset is the byte offset of the error in the expression.
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("segmentation: offset %d: %s", e.Offset, e.Msg)
+}
+
+// Kinds of tokens.
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind   int
+	text   string
+	offset int
+}
+
+// lex splits an expression into tokens.
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			raw := s[i : i+1]
+			if i+1 < len(s) && (s[i+1] == '=' || (c == '<' && s[i+1] == '>')) {
+				raw = s[i : i+2]
+			}
+			op := raw
+			switch raw {
+			case "!":
+				return nil, &SyntaxError{Offset: i, Msg: "unexpected !"}
+			case "<>":
+				op = OpNe
+			case "==":
+				op = OpEq
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(raw)
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(s) && s[end] != c {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, &SyntaxError{Offset: i, Msg: "unterminated string"}
+			}
+			text := s[i+1 : end]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(s[i : end+1])
+				if err != nil {
+					return nil, &SyntaxError{Offset: i, Msg: "invalid string " + s[i:end+1]}
+				}
+				text = unquoted
+			} else {
+				text = strings.Replace(text, `\'`, `'`, -1)
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = end + 1
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && (s[end] == '.' || (s[end] >= '0' && s[end] <= '9')) {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:end], i})
+			i = end
+		case isIdentRune(decodeRune(s[i:]), false):
+			end := i
+			for end < len(s) {
+				r := decodeRune(s[end:])
+				if !isIdentRune(r, true) {
+					break
+				}
+				end += utf8.RuneLen(r)
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:end], i})
+			i = end
+		default:
+			if r := decodeRune(s[i:]); r != utf8.RuneError {
+				return nil, &SyntaxError{Offset: i, Msg: fmt.Sprintf("unexpected %q", r)}
+			}
+			return nil, &SyntaxError{Offset: i, Msg: "invalid UTF-8"}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(s)}), nil
+}
+
+// decodeRune returns the first rune of s, utf8.RuneError when it is not valid UTF-8.
+func decodeRune(s string) rune {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && size <= 1 {
+		return utf8.RuneError
+	}
+	return r
+}
+
+// isIdentRune reports whether r can be part of a property name: a letter or an underscore,
+// and after the first rune a digit or a dot.
+func isIdentRune(r rune, inside bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return inside && (r == '.' || unicode.IsDigit(r))
+}
+
+// parser is a recursive descent parser of the tokens of an expression.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a segmentation expression, e.g. `(age>30) AND (country="FR")`.
+// AND binds tighter than OR, and the keywords are case-insensitive.
+func Parse(expression string) (Expr, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return &SyntaxError{Offset: t.offset, Msg: "unexpected end of expression"}
+	}
+	return &SyntaxError{Offset: t.offset, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+func (p *parser) or() (Expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{e}
+	for p.keyword(OpOr) {
+		if e, err = p.and(); err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+	}
+	return Or(exprs...), nil
+}
+
+func (p *parser) and() (Expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{e}
+	for p.keyword(OpAnd) {
+		if e, err = p.unary(); err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+	}
+	return And(exprs...), nil
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.keyword("NOT") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(e), nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.unexpected(closing)
+		}
+		return e, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			p.next()
+			return p.call(t.text)
+		}
+		op := p.next()
+		if op.kind != tokenOp {
+			return nil, p.unexpected(op)
+		}
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		if _, isProperty := value.(Property); isProperty {
+			return nil, &SyntaxError{Offset: op.offset + len(op.text), Msg: "expected a value"}
+		}
+		return &Comparison{Property: Property(t.text), Op: op.text, Value: value}, nil
+	}
+	return nil, p.unexpected(t)
+}
+
+func (p *parser) call(name string) (Expr, error) {
+	c := &Call{Func: name}
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return c, nil
+	}
+	for {
+		arg, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		c.Args = append(c.Args, arg)
+		switch t := p.next(); t.kind {
+		case tokenComma:
+		case tokenRParen:
+			return c, nil
+		default:
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+// literal parses a value: a string, a number, true, false, or a property.
+func (p *parser) literal() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{Offset: t.offset, Msg: "invalid number " + t.text}
+		}
+		return f, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return Property(t.text), nil
+	}
+	return nil, p.unexpected(t)
+}
@@ -0,0 +1,101 @@
+package segmentation_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+	"github.com/mailjet/mailjet-apiv3-go/v4/segmentation"
+)
+
+func TestBuilder(t *testing.T) {
+	signup := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr segmentation.Expr
+		want string
+	}{
+		{segmentation.Prop("age").Gt(30).And(segmentation.Prop("country").Eq("FR")), `(age>30) AND (country="FR")`},
+		{segmentation.Or(segmentation.Prop("vip").Eq(true), segmentation.Prop("score").Ge(4.5)), `(vip=true) OR (score>=4.5)`},
+		{segmentation.Prop("age").Gt(30).And(segmentation.Prop("a").Eq(1).Or(segmentation.Prop("b").Ne("x"))), `(age>30) AND ((a=1) OR (b!="x"))`},
+		{segmentation.Prop("country").Eq("FR").Not(), `NOT (country="FR")`},
+		{segmentation.Prop("signup").Ge(signup).And(segmentation.Func("IsInPreviousDays", segmentation.Prop("last_order"), 30)), `(signup>="2024-05-01T00:00:00Z") AND IsInPreviousDays(last_order,30)`},
+		{segmentation.Prop("ratio").Lt(2.0), `(ratio<2.0)`},
+	}
+	for _, test := range tests {
+		if got := test.expr.String(); got != test.want {
+			t.Errorf("String() = %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		`(age>30) AND (country="FR")`:                     `(age>30) AND (country="FR")`,
+		`age > 30 and country = 'FR' or vip = TRUE`:       `((age>30) AND (country="FR")) OR (vip=true)`,
+		`not (a=1 OR b<>2)`:                               `NOT ((a=1) OR (b!=2))`,
+		`((score >= -1.5))`:                               `(score>=-1.5)`,
+		`IsInPreviousDays(last_order, 30) AND (x="a\"b")`: `IsInPreviousDays(last_order,30) AND (x="a\"b")`,
+		`(prénom="Zoé")`:                                  `(prénom="Zoé")`,
+	}
+	for expression, want := range tests {
+		e, err := segmentation.Parse(expression)
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected error: %v", expression, err)
+			continue
+		}
+		if got := e.String(); got != want {
+			t.Errorf("Parse(%s) = %s, want %s", expression, got, want)
+		}
+		again, err := segmentation.Parse(e.String())
+		if err != nil || !reflect.DeepEqual(again, e) {
+			t.Errorf("Round trip of %s: %#v (%v)", expression, again, err)
+		}
+	}
+
+	errorTests := map[string]int{
+		`(age>30`:            7,
+		`age>`:               4,
+		`age>30 AND`:         10,
+		`age 30`:             4,
+		`(country="FR) OR x`: 9,
+		`age=other`:          4,
+		`age # 30`:           4,
+		`âge € 30`:           5,
+		"age=\xff":           4,
+	}
+	for expression, offset := range errorTests {
+		_, err := segmentation.Parse(expression)
+		var syntaxErr *segmentation.SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Offset != offset {
+			t.Errorf("Parse(%s): expected a SyntaxError at %d, got %v", expression, offset, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	metadata := []resources.Contactmetadata{
+		{Name: "age", Datatype: "int"},
+		{Name: "country", Datatype: "str"},
+		{Name: "score", Datatype: "float"},
+		{Name: "vip", Datatype: "bool"},
+		{Name: "signup", Datatype: "datetime"},
+	}
+	e, _ := segmentation.Parse(`(age>30) AND (score>=4) AND (vip=false) AND (signup>"2024-05-01") AND IsInPreviousDays(signup,30)`)
+	if err := segmentation.Validate(e, metadata); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	e, _ = segmentation.Parse(`(Age>30) AND (country=33) AND (vip="yes") AND IsInPreviousDays(last_order,30)`)
+	err := segmentation.Validate(e, metadata)
+	var validationErr *segmentation.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	wantUnknown := []string{"Age (did you mean age?)", "last_order"}
+	wantInvalid := []string{`(country=33) (str)`, `(vip="yes") (bool)`}
+	if !reflect.DeepEqual(validationErr.Unknown, wantUnknown) || !reflect.DeepEqual(validationErr.Invalid, wantInvalid) {
+		t.Errorf("Wrong error: %s", err)
+	}
+}
@@ -0,0 +1,119 @@
+package segmentation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// ValidationError reports the properties of an expression which are not defined in
+// contactmetadata, or compared with a value of another datatype.
+type ValidationError struct {
+	// Unknown are the properties not defined.
+	Unknown []string
+	// Invalid are the comparisons with a value not matching the datatype of the property,
+	// as "(age>"thirty") (int)".
+	Invalid []string
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		problems = append(problems, "invalid "+strings.Join(e.Invalid, ", "))
+	}
+	return "segmentation: " + strings.Join(problems, "; ")
+}
+
+// Validate checks the properties of an expression against the contactmetadata of the
+// account, and the values compared with them against their datatypes. It returns a
+// *ValidationError for the unknown properties, e.g. a misspelled "Age", and the invalid values.
+func Validate(e Expr, metadata []resources.Contactmetadata) error {
+	datatypes := make(map[string]string, len(metadata))
+	for _, m := range metadata {
+		datatypes[m.Name] = m.Datatype
+	}
+
+	v := &ValidationError{}
+	unknown := make(map[Property]bool)
+	checkProperty := func(p Property) (string, bool) {
+		datatype, ok := datatypes[string(p)]
+		if !ok && !unknown[p] {
+			unknown[p] = true
+			name := string(p)
+			for defined := range datatypes {
+				if strings.EqualFold(defined, name) {
+					name += " (did you mean " + defined + "?)"
+				}
+			}
+			v.Unknown = append(v.Unknown, name)
+		}
+		return datatype, ok
+	}
+
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch n := e.(type) {
+		case *Comparison:
+			if datatype, ok := checkProperty(n.Property); ok && !validValue(datatype, n.Value) {
+				v.Invalid = append(v.Invalid, fmt.Sprintf("%s (%s)", n, datatype))
+			}
+		case *Logical:
+			for _, e := range n.Exprs {
+				walk(e)
+			}
+		case *NotExpr:
+			walk(n.Expr)
+		case *Call:
+			for _, arg := range n.Args {
+				if p, ok := arg.(Property); ok {
+					checkProperty(p)
+				}
+			}
+		}
+	}
+	walk(e)
+
+	if len(v.Unknown)+len(v.Invalid) == 0 {
+		return nil
+	}
+	return v
+}
+
+// validValue reports whether a literal is a value of a contact property datatype.
+func validValue(datatype string, value interface{}) bool {
+	switch datatype {
+	case "str":
+		_, ok := value.(string)
+		return ok
+	case "int":
+		_, ok := value.(int64)
+		return ok
+	case "float":
+		switch value.(type) {
+		case int64, float64:
+			return true
+		}
+		return false
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "datetime":
+		switch v := value.(type) {
+		case int64:
+			return true
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+				if _, err := time.Parse(layout, v); err == nil {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
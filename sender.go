@@ -0,0 +1,203 @@
+package mailjet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Transport identifies the way a message has been delivered to Mailjet.
+type Transport string
+
+// These are the available transports.
+const (
+	TransportV3   = Transport("send-v3")
+	TransportV31  = Transport("send-v3.1")
+	TransportSMTP = Transport("smtp")
+)
+
+// SendReport describes how a message has been delivered.
+type SendReport struct {
+	Transport Transport
+	// Result is set when the message has been sent with the Send API v3.1.
+	Result *ResultV31
+	// Sent is set when the message has been sent with the Send API v3.
+	Sent *SentResult
+	// FailoverCause is the error of the primary transport when the message
+	// has been delivered by the fallback of a FailoverSender.
+	FailoverCause error
+}
+
+// Sender sends one message regardless of the underlying transport.
+type Sender interface {
+	Send(ctx context.Context, message *InfoMessagesV31) (*SendReport, error)
+}
+
+// SenderV3 sends messages with the Send API v3.
+type SenderV3 struct {
+	client *Client
+}
+
+// NewSenderV3 returns a Sender using the Send API v3.
+func NewSenderV3(client *Client) *SenderV3 {
+	return &SenderV3{client: client}
+}
+
+// Send converts the message and sends it with the Send API v3.
+// TrackOpens, TrackClicks, MonitoringCategory and StatisticsContactsListID are not supported.
+func (s *SenderV3) Send(ctx context.Context, message *InfoMessagesV31) (*SendReport, error) {
+	data, err := sendMailFromV31(message)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.SendMail(data, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &SendReport{Transport: TransportV3, Sent: res}, nil
+}
+
+// SenderV31 sends messages with the Send API v3.1.
+type SenderV31 struct {
+	client *Client
+}
+
+// NewSenderV31 returns a Sender using the Send API v3.1.
+func NewSenderV31(client *Client) *SenderV31 {
+	return &SenderV31{client: client}
+}
+
+// Send sends the message with the Send API v3.1.
+func (s *SenderV31) Send(ctx context.Context, message *InfoMessagesV31) (*SendReport, error) {
+	if message == nil {
+		return nil, errors.New("message is nil")
+	}
+	res, err := s.client.SendMailV31(&MessagesV31{Info: []InfoMessagesV31{*message}}, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	report := &SendReport{Transport: TransportV31}
+	if len(res.ResultsV31) > 0 {
+		report.Result = &res.ResultsV31[0]
+	}
+	return report, nil
+}
+
+// SenderSMTP sends messages through the SMTP relay.
+type SenderSMTP struct {
+	client *Client
+}
+
+// NewSenderSMTP returns a Sender using the SMTP client.
+func NewSenderSMTP(client *Client) *SenderSMTP {
+	return &SenderSMTP{client: client}
+}
+
+// Send converts the message to MIME and sends it through the SMTP relay.
+// The context is only checked before sending.
+func (s *SenderSMTP) Send(ctx context.Context, message *InfoMessagesV31) (*SendReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := InfoMessagesV31ToSMTP(message)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.client.SendMailSMTP(info); err != nil {
+		return nil, err
+	}
+	return &SendReport{Transport: TransportSMTP}, nil
+}
+
+// FailoverSender sends messages with Primary, and with Fallback
+// when Primary fails with a server error or times out.
+//
+// After a timeout, the message may have been accepted by Primary before the response was
+// lost, and is then sent twice. Set NoFailoverOnTimeout to return the timeout instead.
+type FailoverSender struct {
+	Primary  Sender
+	Fallback Sender
+	// PrimaryTimeout bounds the time given to Primary, if set.
+	PrimaryTimeout time.Duration
+	// NoFailoverOnTimeout returns the timeouts of Primary instead of using Fallback,
+	// so that no message is sent twice.
+	NoFailoverOnTimeout bool
+	// ShouldFailover decides whether Fallback is used after the error of Primary.
+	// IsServerError, and IsTimeout unless NoFailoverOnTimeout is set, are used when nil.
+	ShouldFailover func(err error) bool
+}
+
+// NewFailoverSender returns a FailoverSender sending with the Send API v3.1
+// and falling back to SMTP.
+func NewFailoverSender(client *Client) *FailoverSender {
+	return &FailoverSender{
+		Primary:  NewSenderV31(client),
+		Fallback: NewSenderSMTP(client),
+	}
+}
+
+// Send sends the message and reports which transport delivered it.
+func (s *FailoverSender) Send(ctx context.Context, message *InfoMessagesV31) (*SendReport, error) {
+	primaryCtx := ctx
+	if s.PrimaryTimeout > 0 {
+		var cancel context.CancelFunc
+		primaryCtx, cancel = context.WithTimeout(ctx, s.PrimaryTimeout)
+		defer cancel()
+	}
+
+	report, err := s.Primary.Send(primaryCtx, message)
+	if err == nil {
+		return report, nil
+	}
+	shouldFailover := s.ShouldFailover
+	if shouldFailover == nil {
+		shouldFailover = func(err error) bool {
+			return IsServerError(err) || (!s.NoFailoverOnTimeout && IsTimeout(err))
+		}
+	}
+	if ctx.Err() != nil || !shouldFailover(err) {
+		return nil, err
+	}
+
+	report, fallbackErr := s.Fallback.Send(ctx, message)
+	if fallbackErr != nil {
+		return nil, &FailoverError{PrimaryErr: err, FallbackErr: fallbackErr}
+	}
+	report.FailoverCause = err
+	return report, nil
+}
+
+// FailoverError is returned when both transports of a FailoverSender failed.
+type FailoverError struct {
+	PrimaryErr  error
+	FallbackErr error
+}
+
+func (e *FailoverError) Error() string {
+	return "primary transport: " + e.PrimaryErr.Error() + "; fallback transport: " + e.FallbackErr.Error()
+}
+
+// Unwrap returns the error of the fallback transport.
+func (e *FailoverError) Unwrap() error {
+	return e.FallbackErr
+}
+
+// IsServerError reports whether err is a 5xx response of the API.
+func IsServerError(err error) bool {
+	var infoErr *ErrorInfoV31
+	if errors.As(err, &infoErr) {
+		return infoErr.StatusCode >= 500
+	}
+	var requestErr RequestError
+	return errors.As(err, &requestErr) && requestErr.StatusCode >= 500
+}
+
+// IsTimeout reports whether err is a timeout, after which the outcome of a request is unknown.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
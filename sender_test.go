@@ -0,0 +1,238 @@
+package mailjet_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+type recordingSMTPClient struct {
+	from string
+	to   []string
+	msg  []byte
+	err  error
+}
+
+func (c *recordingSMTPClient) SendMail(from string, to []string, msg []byte) error {
+	c.from, c.to, c.msg = from, to, msg
+	return c.err
+}
+
+func fullMessage() *mailjet.InfoMessagesV31 {
+	return &mailjet.InfoMessagesV31{
+		From:    &mailjet.RecipientV31{Email: "pilot@mailjet.com", Name: "Mailjet Pilot"},
+		ReplyTo: &mailjet.RecipientV31{Email: "support@mailjet.com"},
+		To:      &mailjet.RecipientsV31{{Email: "passenger1@mailjet.com", Name: "Passenger 1"}},
+		Cc:      &mailjet.RecipientsV31{{Email: "passenger2@mailjet.com"}},
+		Bcc:     &mailjet.RecipientsV31{{Email: "passenger3@mailjet.com"}},
+		Attachments: &mailjet.AttachmentsV31{{
+			ContentType:   "text/plain",
+			Filename:      "test.txt",
+			Base64Content: base64.StdEncoding.EncodeToString([]byte("An attached file")),
+		}},
+		InlinedAttachments: &mailjet.InlinedAttachmentsV31{{
+			AttachmentV31: mailjet.AttachmentV31{
+				ContentType:   "image/png",
+				Filename:      "logo.png",
+				Base64Content: base64.StdEncoding.EncodeToString([]byte("not really a png")),
+			},
+			ContentID: "id1",
+		}},
+		Subject:              "Vol à destination de Paris",
+		TextPart:             "Dear passenger, welcome to Mailjet!",
+		HTMLPart:             `<h3>Dear passenger, welcome to <img src="cid:id1"> Mailjet!</h3>`,
+		CustomID:             "AppGettingStartedTest",
+		CustomCampaign:       "onboarding",
+		DeduplicateCampaign:  true,
+		TrackOpens:           mailjet.TrackDisabled,
+		Variables:            map[string]interface{}{"day": "Monday"},
+		EventPayload:         "Eticket,1234,row,15,seat,B",
+		TemplateErrorDeliver: true,
+		Headers:              map[string]interface{}{"X-My-Header": "my value"},
+	}
+}
+
+func TestInfoMessagesV31SMTPRoundTrip(t *testing.T) {
+	message := fullMessage()
+
+	info, err := mailjet.InfoMessagesV31ToSMTP(message)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	wantRecipients := []string{"passenger1@mailjet.com", "passenger2@mailjet.com", "passenger3@mailjet.com"}
+	if !reflect.DeepEqual(info.Recipients, wantRecipients) {
+		t.Fatalf("Wrong recipients: %v", info.Recipients)
+	}
+	if info.Header.Get("Bcc") != "" {
+		t.Fatal("Bcc must not be visible in the headers")
+	}
+	if info.Options.CustomID != message.CustomID || info.Options.TrackOpens != mailjet.TrackDisabled {
+		t.Fatalf("Wrong options: %+v", info.Options)
+	}
+
+	got, err := mailjet.InfoSMTPToMessagesV31(info)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !reflect.DeepEqual(got, message) {
+		want, _ := json.Marshal(message)
+		res, _ := json.Marshal(got)
+		t.Fatalf("Round trip mismatch:\nwant %s\n got %s", want, res)
+	}
+}
+
+func TestInfoMessagesV31ToSMTPHeaderInjection(t *testing.T) {
+	message := fullMessage()
+	message.Headers = map[string]interface{}{"X-My-Header": "my value\r\nBcc: victim@example.com"}
+	if _, err := mailjet.InfoMessagesV31ToSMTP(message); err == nil {
+		t.Error("Expected error for a header with CRLF")
+	}
+	if _, err := mailjet.NewSenderV3(nil).Send(context.Background(), message); err == nil {
+		t.Error("Expected error for a header with CRLF")
+	}
+}
+
+func TestSenderV3(t *testing.T) {
+	teardown := fakeServer()
+	defer teardown()
+
+	mux.HandleFunc("/v3/send/message", func(w http.ResponseWriter, r *http.Request) {
+		var data mailjet.InfoSendMail
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			t.Fatal("Invalid body:", err)
+		}
+		if data.FromEmail != "pilot@mailjet.com" || data.To != `"Passenger 1" <passenger1@mailjet.com>` ||
+			data.MjCustomID != "AppGettingStartedTest" || data.Headers["Reply-To"] != "<support@mailjet.com>" {
+			t.Errorf("Wrong payload: %+v", data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"Sent":[{"Email":"passenger1@mailjet.com","MessageID":42}]}`)
+	})
+
+	report, err := mailjet.NewSenderV3(client).Send(context.Background(), fullMessage())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if report.Transport != mailjet.TransportV3 || report.Sent == nil || report.Sent.Sent[0].MessageID != 42 {
+		t.Fatalf("Wrong report: %+v", report)
+	}
+}
+
+func TestFailoverSender(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		response      string
+		delay         time.Duration
+		noOnTimeout   bool
+		wantTransport mailjet.Transport
+		wantErr       bool
+	}{
+		{
+			name:          "primary succeeds",
+			status:        http.StatusOK,
+			response:      `{"Messages":[{"Status":"success"}]}`,
+			wantTransport: mailjet.TransportV31,
+		},
+		{
+			name:          "server error",
+			status:        http.StatusServiceUnavailable,
+			response:      `<html>Service Unavailable</html>`,
+			wantTransport: mailjet.TransportSMTP,
+		},
+		{
+			name:          "timeout",
+			status:        http.StatusOK,
+			response:      `{"Messages":[{"Status":"success"}]}`,
+			delay:         300 * time.Millisecond,
+			wantTransport: mailjet.TransportSMTP,
+		},
+		{
+			name:        "timeout without failover",
+			status:      http.StatusOK,
+			response:    `{"Messages":[{"Status":"success"}]}`,
+			delay:       300 * time.Millisecond,
+			noOnTimeout: true,
+			wantErr:     true,
+		},
+		{
+			name:     "client error",
+			status:   http.StatusBadRequest,
+			response: `{"Messages":[{"Status":"error","Errors":[{"ErrorCode":"mj-0013","StatusCode":400}]}]}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			teardown := fakeServer()
+			defer teardown()
+
+			mux.HandleFunc("/v3.1/send", func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(test.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(test.status)
+				fmt.Fprint(w, test.response)
+			})
+
+			smtpClient := &recordingSMTPClient{}
+			cl := mailjet.NewClient(mailjet.NewHTTPClient("apiKeyPublic", "apiKeyPrivate"), smtpClient, server.URL+"/v3")
+
+			sender := mailjet.NewFailoverSender(cl)
+			sender.PrimaryTimeout = 100 * time.Millisecond
+			sender.NoFailoverOnTimeout = test.noOnTimeout
+
+			report, err := sender.Send(context.Background(), fullMessage())
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("Expected error")
+				}
+				if smtpClient.msg != nil {
+					t.Fatal("The error must not fail over")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if report.Transport != test.wantTransport {
+				t.Fatalf("Wrong transport: %s", report.Transport)
+			}
+			if test.wantTransport == mailjet.TransportSMTP {
+				if report.FailoverCause == nil || smtpClient.msg == nil {
+					t.Fatalf("Expected the message to be sent via SMTP: %+v", report)
+				}
+			}
+		})
+	}
+
+	t.Run("both transports fail", func(t *testing.T) {
+		teardown := fakeServer()
+		defer teardown()
+
+		mux.HandleFunc("/v3.1/send", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		smtpErr := errors.New("smtp send error")
+		cl := mailjet.NewClient(mailjet.NewHTTPClient("apiKeyPublic", "apiKeyPrivate"),
+			&recordingSMTPClient{err: smtpErr}, server.URL+"/v3")
+
+		_, err := mailjet.NewFailoverSender(cl).Send(context.Background(), fullMessage())
+		var failoverErr *mailjet.FailoverError
+		if !errors.As(err, &failoverErr) || !errors.Is(err, smtpErr) || !mailjet.IsServerError(failoverErr.PrimaryErr) {
+			t.Fatalf("Wrong error: %v", err)
+		}
+	})
+}
@@ -1,37 +1,562 @@
 package mailjet
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // SMTPClient is the wrapper for smtp
 type SMTPClient struct {
-	host string
-	auth smtp.Auth
+	host           string
+	port           int
+	username       string
+	password       string
+	auth           smtp.Auth
+	authMechanism  SMTPAuthMechanism
+	tlsMode        SMTPTLSMode
+	tlsConfig      *tls.Config
+	dialTimeout    time.Duration
+	commandTimeout time.Duration
+	localName      string
+	poolSize       int
+	idleTimeout    time.Duration
+	healthCheck    time.Duration
+	pool           *smtpPool
+	// netDial opens the TCP connections, net.Dialer.Dial by default.
+	netDial func(network, address string) (net.Conn, error)
 }
 
 // Hostname and port for the SMTP client.
+// The Mailjet relay also listens on ports 25 and 2525 (STARTTLS) and 465 (implicit TLS).
 const (
 	HostSMTP = "in-v3.mailjet.com"
 	PortSMTP = 587
 )
 
+// SMTPTLSMode defines how the SMTP client secures the connection.
+type SMTPTLSMode int
+
+// These are the possible TLS modes.
+const (
+	SMTPStartTLS         = SMTPTLSMode(iota) // Upgrade with STARTTLS when the server offers it.
+	SMTPStartTLSRequired                     // Fail if the server does not offer STARTTLS.
+	SMTPImplicitTLS                          // Negotiate TLS as soon as the connection is open.
+)
+
+// SMTPAuthMechanism is the SASL mechanism used to authenticate on the SMTP server.
+type SMTPAuthMechanism string
+
+// These are the supported authentication mechanisms.
+const (
+	SMTPAuthPlain   = SMTPAuthMechanism("PLAIN")
+	SMTPAuthLogin   = SMTPAuthMechanism("LOGIN")
+	SMTPAuthCRAMMD5 = SMTPAuthMechanism("CRAM-MD5")
+)
+
+// SMTPClientOptions are functional options that configure the SMTP client.
+type SMTPClientOptions func(*SMTPClient)
+
+// WithSMTPHost sets the hostname of the SMTP server.
+func WithSMTPHost(host string) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.host = host
+	}
+}
+
+// WithSMTPPort sets the port of the SMTP server.
+func WithSMTPPort(port int) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.port = port
+	}
+}
+
+// WithSMTPTLSMode sets how the connection is secured.
+func WithSMTPTLSMode(mode SMTPTLSMode) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.tlsMode = mode
+	}
+}
+
+// WithSMTPTLSConfig sets the TLS configuration used for STARTTLS and implicit TLS.
+// ServerName defaults to the SMTP host when left empty.
+func WithSMTPTLSConfig(config *tls.Config) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.tlsConfig = config
+	}
+}
+
+// WithSMTPDialTimeout sets the maximum amount of time a dial waits for a connection.
+// With WithSMTPPool, it is also how long a message waits for a pooled session.
+func WithSMTPDialTimeout(timeout time.Duration) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.dialTimeout = timeout
+	}
+}
+
+// WithSMTPCommandTimeout sets the maximum amount of time to wait
+// for each read or write on the connection.
+func WithSMTPCommandTimeout(timeout time.Duration) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.commandTimeout = timeout
+	}
+}
+
+// WithSMTPLocalName sets the hostname sent with the EHLO/HELO command.
+func WithSMTPLocalName(name string) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.localName = name
+	}
+}
+
+// WithSMTPAuthMechanism sets the mechanism used to authenticate with the API keys.
+func WithSMTPAuthMechanism(mechanism SMTPAuthMechanism) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.authMechanism = mechanism
+	}
+}
+
+// WithSMTPAuth sets a custom authentication, overriding the API keys based one.
+func WithSMTPAuth(auth smtp.Auth) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.auth = auth
+	}
+}
+
+// WithSMTPPool keeps up to size authenticated sessions open and reuses them
+// between messages, capping the number of concurrent connections to size.
+func WithSMTPPool(size int) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.poolSize = size
+	}
+}
+
+// WithSMTPPoolIdleTimeout sets how long a pooled session may stay idle before being closed.
+func WithSMTPPoolIdleTimeout(timeout time.Duration) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.idleTimeout = timeout
+	}
+}
+
+// WithSMTPPoolHealthCheck sets after how long of inactivity a pooled session
+// is checked with a NOOP before being reused.
+func WithSMTPPoolHealthCheck(after time.Duration) SMTPClientOptions {
+	return func(s *SMTPClient) {
+		s.healthCheck = after
+	}
+}
+
 // NewSMTPClient returns a new smtp client wrapper
-func NewSMTPClient(apiKeyPublic, apiKeyPrivate string) *SMTPClient {
-	auth := smtp.PlainAuth(
-		"",
-		apiKeyPublic,
-		apiKeyPrivate,
-		HostSMTP,
-	)
-	return &SMTPClient{
-		host: fmt.Sprintf("%s:%d", HostSMTP, PortSMTP),
-		auth: auth,
+func NewSMTPClient(apiKeyPublic, apiKeyPrivate string, options ...SMTPClientOptions) *SMTPClient {
+	s := &SMTPClient{
+		host:          HostSMTP,
+		port:          PortSMTP,
+		username:      apiKeyPublic,
+		password:      apiKeyPrivate,
+		authMechanism: SMTPAuthPlain,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if s.auth == nil {
+		s.auth = s.buildAuth()
 	}
+	if s.poolSize > 0 {
+		s.pool = newSMTPPool(s.poolSize, s.idleTimeout, s.healthCheck, s.dialTimeout, s.dial)
+	}
+	return s
 }
 
-// SendMail wraps smtp.SendMail
+// buildAuth returns the smtp.Auth matching the configured mechanism.
+func (s *SMTPClient) buildAuth() smtp.Auth {
+	switch s.authMechanism {
+	case SMTPAuthLogin:
+		return &loginAuth{username: s.username, password: s.password, host: s.host}
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(s.username, s.password)
+	default:
+		return smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+}
+
+// Addr returns the address of the SMTP server.
+func (s SMTPClient) Addr() string {
+	return net.JoinHostPort(s.host, strconv.Itoa(s.port))
+}
+
+// SendMail sends the message through a new SMTP session,
+// or through a pooled one when WithSMTPPool is set.
 func (s SMTPClient) SendMail(from string, to []string, msg []byte) error {
-	return smtp.SendMail(s.host, s.auth, from, to, msg)
+	if s.pool != nil {
+		return s.pool.send(func(c *smtp.Client) error {
+			return s.sendMessage(c, from, to, msg)
+		})
+	}
+
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err = s.sendMessage(c, from, to, msg); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// dial opens an authenticated session with the SMTP server.
+// The command timeout applies to the TCP connection, under TLS, so that net/smtp sees
+// the *tls.Conn of implicit TLS and allows authenticating over it.
+func (s SMTPClient) dial() (*smtp.Client, error) {
+	netDial := s.netDial
+	if netDial == nil {
+		netDial = (&net.Dialer{Timeout: s.dialTimeout}).Dial
+	}
+	conn, err := netDial("tcp", s.Addr())
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial %s: %w", s.Addr(), err)
+	}
+	if s.commandTimeout > 0 {
+		conn = &timeoutConn{Conn: conn, timeout: s.commandTimeout}
+	}
+	if s.tlsMode == SMTPImplicitTLS {
+		if conn, err = s.handshake(conn); err != nil {
+			return nil, fmt.Errorf("smtp dial %s: %w", s.Addr(), err)
+		}
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = s.hello(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake negotiates TLS on a new connection, within the dial timeout.
+func (s SMTPClient) handshake(conn net.Conn) (net.Conn, error) {
+	tlsConn := tls.Client(conn, s.tlsConfigForHost())
+	if s.dialTimeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(s.dialTimeout)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// hello greets the server, upgrades the connection if needed and authenticates.
+func (s SMTPClient) hello(c *smtp.Client) error {
+	localName := s.localName
+	if localName == "" {
+		localName = "localhost"
+	}
+	if err := c.Hello(localName); err != nil {
+		return err
+	}
+	if s.tlsMode != SMTPImplicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(s.tlsConfigForHost()); err != nil {
+				return err
+			}
+		} else if s.tlsMode == SMTPStartTLSRequired {
+			return errors.New("smtp: server doesn't support STARTTLS")
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessage runs a MAIL/RCPT/DATA transaction on an established session.
+func (s SMTPClient) sendMessage(c *smtp.Client, from string, to []string, msg []byte) error {
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Close ends the pooled sessions. The client can not be used afterwards.
+func (s SMTPClient) Close() error {
+	if s.pool == nil {
+		return nil
+	}
+	return s.pool.close()
+}
+
+func (s SMTPClient) tlsConfigForHost() *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.tlsConfig != nil {
+		config = s.tlsConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = s.host
+	}
+	return config
+}
+
+// smtpPoolAttempts is the number of sessions tried for a message
+// when the server answers with a transient error.
+const smtpPoolAttempts = 2
+
+// DefaultSMTPPoolHealthCheck is the inactivity after which
+// a pooled session is checked before being reused.
+var DefaultSMTPPoolHealthCheck = 30 * time.Second
+
+// DefaultSMTPPoolWait is how long a message waits for a pooled session
+// when no dial timeout is set.
+var DefaultSMTPPoolWait = 30 * time.Second
+
+var (
+	errSMTPPoolClosed    = errors.New("smtp: pool is closed")
+	errSMTPPoolExhausted = errors.New("smtp: timeout waiting for a pooled session")
+)
+
+// smtpPool keeps authenticated SMTP sessions for reuse.
+type smtpPool struct {
+	dial        func() (*smtp.Client, error)
+	slots       chan struct{}
+	done        chan struct{}
+	idleTimeout time.Duration
+	healthCheck time.Duration
+	wait        time.Duration
+
+	mu     sync.Mutex
+	idle   []*pooledSMTPConn
+	closed bool
+}
+
+type pooledSMTPConn struct {
+	*smtp.Client
+	lastUsed time.Time
+}
+
+func newSMTPPool(size int, idleTimeout, healthCheck, wait time.Duration,
+	dial func() (*smtp.Client, error)) *smtpPool {
+	if healthCheck <= 0 {
+		healthCheck = DefaultSMTPPoolHealthCheck
+	}
+	if wait <= 0 {
+		wait = DefaultSMTPPoolWait
+	}
+	return &smtpPool{
+		dial:        dial,
+		slots:       make(chan struct{}, size),
+		done:        make(chan struct{}),
+		idleTimeout: idleTimeout,
+		healthCheck: healthCheck,
+		wait:        wait,
+	}
+}
+
+// send runs fn on a pooled session. The session is reset and kept
+// after success or a permanent error, and replaced after a transient
+// (4xx, 421) or connection error, in which case fn is retried.
+func (p *smtpPool) send(fn func(*smtp.Client) error) (err error) {
+	for attempt := 0; attempt < smtpPoolAttempts; attempt++ {
+		var c *pooledSMTPConn
+		c, err = p.get()
+		if err != nil {
+			return err
+		}
+
+		err = fn(c.Client)
+		if err == nil || isPermanentSMTPError(err) {
+			p.put(c, c.Reset() == nil)
+			return err
+		}
+		p.put(c, false)
+	}
+	return err
+}
+
+// get returns an idle session, or a new one when none is available.
+// It waits up to p.wait while the maximum number of sessions are in use,
+// and fails as soon as the pool is closed.
+func (p *smtpPool) get() (*pooledSMTPConn, error) {
+	timer := time.NewTimer(p.wait)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.done:
+		return nil, errSMTPPoolClosed
+	case <-timer.C:
+		return nil, errSMTPPoolExhausted
+	}
+
+	for {
+		c, err := p.popIdle()
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		if c == nil {
+			break
+		}
+		idle := time.Since(c.lastUsed)
+		if p.idleTimeout > 0 && idle > p.idleTimeout {
+			c.Close()
+			continue
+		}
+		if idle > p.healthCheck && c.Noop() != nil {
+			c.Close()
+			continue
+		}
+		return c, nil
+	}
+
+	client, err := p.dial()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return &pooledSMTPConn{Client: client}, nil
+}
+
+func (p *smtpPool) popIdle() (*pooledSMTPConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errSMTPPoolClosed
+	}
+	if len(p.idle) == 0 {
+		return nil, nil
+	}
+	c := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return c, nil
+}
+
+// put releases the session, keeping it for reuse when healthy.
+func (p *smtpPool) put(c *pooledSMTPConn, healthy bool) {
+	defer func() { <-p.slots }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !healthy || p.closed {
+		c.Close()
+		return
+	}
+	c.lastUsed = time.Now()
+	p.idle = append(p.idle, c)
+}
+
+func (p *smtpPool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+	}
+	var err error
+	for _, c := range p.idle {
+		if qerr := c.Quit(); qerr != nil && err == nil {
+			err = qerr
+		}
+	}
+	p.idle = nil
+	return err
+}
+
+// isPermanentSMTPError reports whether err is a 5xx reply, meaning the
+// message was refused but the session is still usable.
+func isPermanentSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
+
+// timeoutConn extends the connection deadline before each read and write.
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+// loginAuth implements the LOGIN authentication mechanism.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return string(SMTPAuthLogin), nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
 }
@@ -0,0 +1,317 @@
+package mailjet
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/smtpfake"
+)
+
+func newTestSMTPServer(t *testing.T, options ...smtpfake.Options) *smtpfake.Server {
+	t.Helper()
+	server, err := smtpfake.NewServer(options...)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+// testSMTPClient returns a client of the server trusting its certificate.
+func testSMTPClient(server *smtpfake.Server, options ...SMTPClientOptions) *SMTPClient {
+	return NewSMTPClient("public", "private", append([]SMTPClientOptions{
+		WithSMTPHost(server.Host()),
+		WithSMTPPort(server.Port()),
+		WithSMTPTLSConfig(server.ClientTLSConfig()),
+	}, options...)...)
+}
+
+func TestSMTPClientDefaults(t *testing.T) {
+	s := NewSMTPClient("public", "private")
+	if s.Addr() != "in-v3.mailjet.com:587" {
+		t.Fatal("Wrong address:", s.Addr())
+	}
+}
+
+func TestSMTPClientSendMail(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverOptions []smtpfake.Options
+		options       []SMTPClientOptions
+		wantAuth      string
+		wantTLS       bool
+		wantErr       bool
+	}{
+		{
+			name:          "plain connection",
+			serverOptions: []smtpfake.Options{smtpfake.WithoutSTARTTLS()},
+			wantAuth:      "PLAIN public:private",
+		},
+		{
+			name:     "opportunistic STARTTLS",
+			wantAuth: "PLAIN public:private",
+			wantTLS:  true,
+		},
+		{
+			name:     "STARTTLS",
+			options:  []SMTPClientOptions{WithSMTPTLSMode(SMTPStartTLSRequired)},
+			wantAuth: "PLAIN public:private",
+			wantTLS:  true,
+		},
+		{
+			name:          "STARTTLS required but not offered",
+			serverOptions: []smtpfake.Options{smtpfake.WithoutSTARTTLS()},
+			options:       []SMTPClientOptions{WithSMTPTLSMode(SMTPStartTLSRequired)},
+			wantErr:       true,
+		},
+		{
+			name:          "implicit TLS",
+			serverOptions: []smtpfake.Options{smtpfake.WithImplicitTLS()},
+			options:       []SMTPClientOptions{WithSMTPTLSMode(SMTPImplicitTLS)},
+			wantAuth:      "PLAIN public:private",
+			wantTLS:       true,
+		},
+		{
+			name:     "LOGIN mechanism",
+			options:  []SMTPClientOptions{WithSMTPAuthMechanism(SMTPAuthLogin)},
+			wantAuth: "LOGIN public:private",
+			wantTLS:  true,
+		},
+		{
+			name:          "wrong credentials",
+			serverOptions: []smtpfake.Options{smtpfake.WithCredentials("public", "other")},
+			wantErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestSMTPServer(t, test.serverOptions...)
+			client := testSMTPClient(server, append(test.options, WithSMTPLocalName("client.example.com"))...)
+
+			err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: test\r\n\r\nhello\r\n"))
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			messages := server.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("Expected one message, got %d", len(messages))
+			}
+			m := messages[0]
+			if m.Helo != "client.example.com" {
+				t.Errorf("Wrong EHLO name: %q", m.Helo)
+			}
+			if m.Auth != test.wantAuth || m.TLS != test.wantTLS {
+				t.Errorf("Wrong session: auth %q, TLS %t", m.Auth, m.TLS)
+			}
+			if m.From != "from@example.com" || len(m.Recipients) != 1 || m.Recipients[0] != "to@example.com" ||
+				m.Subject() != "test" || m.Text() != "hello\n" {
+				t.Fatalf("Wrong message: %+v", m)
+			}
+		})
+	}
+}
+
+func TestSMTPClientTimeouts(t *testing.T) {
+	server := newTestSMTPServer(t, smtpfake.WithDelay(200*time.Millisecond))
+
+	client := testSMTPClient(server,
+		WithSMTPDialTimeout(time.Second),
+		WithSMTPCommandTimeout(50*time.Millisecond),
+	)
+	err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello"))
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Expected a timeout, got: %v", err)
+	}
+
+	unreachable := NewSMTPClient("public", "private",
+		WithSMTPHost("localhost"),
+		WithSMTPPort(closedPort(t)),
+		WithSMTPDialTimeout(time.Second),
+	)
+	if err := unreachable.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err == nil {
+		t.Fatal("Expected dial error")
+	}
+}
+
+func TestSMTPClientImplicitTLSCommandTimeout(t *testing.T) {
+	server := newTestSMTPServer(t, smtpfake.WithImplicitTLS())
+
+	for _, mechanism := range []SMTPAuthMechanism{SMTPAuthPlain, SMTPAuthLogin} {
+		// The auth mechanisms refuse unencrypted connections to hosts other than localhost.
+		client := testSMTPClient(server,
+			WithSMTPHost("smtp.example.com"),
+			WithSMTPTLSMode(SMTPImplicitTLS),
+			WithSMTPAuthMechanism(mechanism),
+			WithSMTPDialTimeout(time.Second),
+			WithSMTPCommandTimeout(time.Second),
+		)
+		client.netDial = func(network, address string) (net.Conn, error) {
+			return net.Dial(network, server.Addr())
+		}
+		if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: test\r\n\r\nhello\r\n")); err != nil {
+			t.Fatalf("Unexpected error with %s: %v", mechanism, err)
+		}
+	}
+	if messages := server.Messages(); len(messages) != 2 || !messages[0].TLS || !messages[1].TLS {
+		t.Fatalf("Wrong messages: %+v", messages)
+	}
+}
+
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func countCommands(commands []string, verb string) int {
+	n := 0
+	for _, command := range commands {
+		if command == verb {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSMTPClientPool(t *testing.T) {
+	server := newTestSMTPServer(t)
+	client := testSMTPClient(server,
+		WithSMTPPool(2),
+	)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	connections, commands := server.Connections(), server.Commands()
+	if connections > 2 {
+		t.Errorf("Expected at most 2 connections, got %d", connections)
+	}
+	if n := countCommands(commands, "AUTH"); n != connections {
+		t.Errorf("Expected one AUTH per connection, got %d for %d connections", n, connections)
+	}
+	if n := countCommands(commands, "RSET"); n != 10 {
+		t.Errorf("Expected a RSET after each message, got %d", n)
+	}
+	if messages := server.Messages(); len(messages) != 10 {
+		t.Errorf("Expected 10 messages, got %d", len(messages))
+	}
+}
+
+func TestSMTPClientPoolExhausted(t *testing.T) {
+	pool := newSMTPPool(1, 0, 0, 10*time.Millisecond, func() (*smtp.Client, error) {
+		return nil, errors.New("unexpected dial")
+	})
+	pool.slots <- struct{}{}
+
+	if _, err := pool.get(); err != errSMTPPoolExhausted {
+		t.Fatalf("Expected errSMTPPoolExhausted, got %v", err)
+	}
+
+	pool.wait = time.Minute
+	errs := make(chan error)
+	go func() {
+		_, err := pool.get()
+		errs <- err
+	}()
+	pool.close()
+	select {
+	case err := <-errs:
+		if err != errSMTPPoolClosed {
+			t.Fatalf("Expected errSMTPPoolClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("get still waiting after close")
+	}
+}
+
+func TestSMTPClientPoolReconnect(t *testing.T) {
+	server := newTestSMTPServer(t)
+	client := testSMTPClient(server,
+		WithSMTPPool(1),
+	)
+	defer client.Close()
+
+	if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	server.InjectReply("MAIL", "421 Service not available, closing channel")
+	if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if connections := server.Connections(); connections != 2 {
+		t.Errorf("Expected a reconnection after 421, got %d connections", connections)
+	}
+
+	server.InjectReply("MAIL", "550 Mailbox unavailable")
+	if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err == nil {
+		t.Fatal("Expected error")
+	}
+	if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if connections := server.Connections(); connections != 2 {
+		t.Errorf("Expected the session to be kept after a 5xx, got %d connections", connections)
+	}
+}
+
+func TestSMTPClientPoolHealthCheck(t *testing.T) {
+	server := newTestSMTPServer(t)
+	client := testSMTPClient(server,
+		WithSMTPPool(1),
+		WithSMTPPoolHealthCheck(time.Nanosecond),
+	)
+
+	for i := 0; i < 2; i++ {
+		if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+	if commands := server.Commands(); countCommands(commands, "NOOP") != 1 {
+		t.Errorf("Expected a NOOP before reusing the session, got %q", commands)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := client.SendMail("from@example.com", []string{"to@example.com"}, []byte("hello")); err == nil {
+		t.Fatal("Expected error on closed pool")
+	}
+}
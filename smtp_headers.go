@@ -0,0 +1,173 @@
+package mailjet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Mailjet specific headers understood by the SMTP relay.
+const (
+	HeaderMJCampaign               = "X-Mailjet-Campaign"
+	HeaderMJDeduplicateCampaign    = "X-Mailjet-DeduplicateCampaign"
+	HeaderMJPriority               = "X-Mailjet-Prio"
+	HeaderMJTrackOpen              = "X-Mailjet-TrackOpen"
+	HeaderMJTrackClick             = "X-Mailjet-TrackClick"
+	HeaderMJCustomID               = "X-MJ-CustomID"
+	HeaderMJEventPayload           = "X-MJ-EventPayload"
+	HeaderMJTemplateID             = "X-MJ-TemplateID"
+	HeaderMJTemplateLanguage       = "X-MJ-TemplateLanguage"
+	HeaderMJTemplateErrorReporting = "X-MJ-TemplateErrorReporting"
+	HeaderMJTemplateErrorDeliver   = "X-MJ-TemplateErrorDeliver"
+	HeaderMJVars                   = "X-MJ-Vars"
+)
+
+// Values of the TrackOpens and TrackClicks fields, shared by the Send API v3.1 and SMTP.
+const (
+	TrackAccountDefault = "account_default"
+	TrackDisabled       = "disabled"
+	TrackEnabled        = "enabled"
+)
+
+// maxHeaderLine is the maximum length of a header line, without its CRLF (RFC 5322, section 2.1.1).
+const maxHeaderLine = 998
+
+// Header returns the Mailjet headers encoding the options.
+// It returns an error when a value holds a CR or LF, which would end the header.
+func (o *InfoSMTPOptions) Header() (textproto.MIMEHeader, error) {
+	header := make(textproto.MIMEHeader)
+	if o == nil {
+		return header, nil
+	}
+
+	if o.CustomCampaign != "" {
+		header.Set(HeaderMJCampaign, o.CustomCampaign)
+	}
+	if o.DeduplicateCampaign {
+		header.Set(HeaderMJDeduplicateCampaign, "1")
+	}
+	if o.Priority != 0 {
+		header.Set(HeaderMJPriority, strconv.Itoa(o.Priority))
+	}
+	if err := setTrackingHeader(header, HeaderMJTrackOpen, o.TrackOpens); err != nil {
+		return nil, err
+	}
+	if err := setTrackingHeader(header, HeaderMJTrackClick, o.TrackClicks); err != nil {
+		return nil, err
+	}
+	if o.CustomID != "" {
+		header.Set(HeaderMJCustomID, o.CustomID)
+	}
+	if o.EventPayload != "" {
+		header.Set(HeaderMJEventPayload, o.EventPayload)
+	}
+	if o.TemplateID != 0 {
+		header.Set(HeaderMJTemplateID, strconv.Itoa(o.TemplateID))
+	}
+	if o.TemplateLanguage {
+		header.Set(HeaderMJTemplateLanguage, "1")
+	}
+	if o.TemplateErrorReporting != nil && o.TemplateErrorReporting.Email != "" {
+		header.Set(HeaderMJTemplateErrorReporting, o.TemplateErrorReporting.Email)
+	}
+	if o.TemplateErrorDeliver {
+		header.Set(HeaderMJTemplateErrorDeliver, "deliver")
+	}
+	if len(o.Variables) > 0 {
+		vars, err := json.Marshal(o.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", HeaderMJVars, err)
+		}
+		// Compact JSON has no whitespace to fold a long header at.
+		if len(HeaderMJVars)+2+len(vars) > maxHeaderLine {
+			vars = spreadJSON(vars)
+		}
+		header.Set(HeaderMJVars, string(vars))
+	}
+	for key, values := range header {
+		for _, value := range values {
+			if err := checkHeader(key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return header, nil
+}
+
+// spreadJSON separates the tokens of compact JSON with spaces. Only the newlines added
+// by json.Indent are replaced, as the strings of JSON cannot hold raw newlines.
+func spreadJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", ""); err != nil {
+		return data
+	}
+	return bytes.ReplaceAll(buf.Bytes(), []byte("\n"), []byte(" "))
+}
+
+// checkHeader returns an error when a header field holds a CR or LF, which would
+// inject other fields or a body into the mail.
+func checkHeader(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "\r\n: \t") {
+		return fmt.Errorf("invalid header name %q", key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid %s header: the value holds a CR or LF", key)
+	}
+	return nil
+}
+
+// foldHeader returns a header field and its CRLF, folded at its whitespace so that
+// no line exceeds maxHeaderLine.
+func foldHeader(key, value string) (string, error) {
+	if err := checkHeader(key, value); err != nil {
+		return "", err
+	}
+	line := key + ": " + value
+	var b strings.Builder
+	for len(line) > maxHeaderLine {
+		i := strings.LastIndexAny(line[:maxHeaderLine+1], " \t")
+		if i <= 0 {
+			return "", fmt.Errorf("invalid %s header: a line is longer than %d characters", key, maxHeaderLine)
+		}
+		b.WriteString(line[:i])
+		b.WriteString("\r\n")
+		line = line[i:]
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String(), nil
+}
+
+func setTrackingHeader(header textproto.MIMEHeader, key, value string) error {
+	switch value {
+	case "", TrackAccountDefault:
+	case TrackDisabled:
+		header.Set(key, "0")
+	case TrackEnabled:
+		header.Set(key, "1")
+	default:
+		return fmt.Errorf("invalid %s value: %q", key, value)
+	}
+	return nil
+}
+
+// smtpHeader merges the raw header of the mail with the Mailjet headers of its options.
+// The options take precedence over the raw header.
+func (info *InfoSMTP) smtpHeader() (textproto.MIMEHeader, error) {
+	header := make(textproto.MIMEHeader, len(info.Header))
+	for key, values := range info.Header {
+		header[key] = values
+	}
+
+	mjHeader, err := info.Options.Header()
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range mjHeader {
+		header[key] = values
+	}
+	return header, nil
+}
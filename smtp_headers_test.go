@@ -0,0 +1,166 @@
+package mailjet
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/mail"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type capturingSMTPClient struct {
+	from string
+	to   []string
+	msg  []byte
+}
+
+func (c *capturingSMTPClient) SendMail(from string, to []string, msg []byte) error {
+	c.from, c.to, c.msg = from, to, msg
+	return nil
+}
+
+func TestInfoSMTPOptionsHeader(t *testing.T) {
+	opts := &InfoSMTPOptions{
+		Priority:               2,
+		CustomCampaign:         "summer",
+		DeduplicateCampaign:    true,
+		TrackOpens:             TrackDisabled,
+		TrackClicks:            TrackEnabled,
+		CustomID:               "order-42",
+		Variables:              map[string]interface{}{"day": "Monday"},
+		EventPayload:           `{"order":42}`,
+		TemplateID:             1234,
+		TemplateLanguage:       true,
+		TemplateErrorReporting: &RecipientV31{Email: "dev@example.com"},
+		TemplateErrorDeliver:   true,
+	}
+
+	header, err := opts.Header()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want := map[string]string{
+		"X-Mailjet-Prio":                "2",
+		"X-Mailjet-Campaign":            "summer",
+		"X-Mailjet-Deduplicatecampaign": "1",
+		"X-Mailjet-Trackopen":           "0",
+		"X-Mailjet-Trackclick":          "1",
+		"X-Mj-Customid":                 "order-42",
+		"X-Mj-Vars":                     `{"day":"Monday"}`,
+		"X-Mj-Eventpayload":             `{"order":42}`,
+		"X-Mj-Templateid":               "1234",
+		"X-Mj-Templatelanguage":         "1",
+		"X-Mj-Templateerrorreporting":   "dev@example.com",
+		"X-Mj-Templateerrordeliver":     "deliver",
+	}
+	if len(header) != len(want) {
+		t.Fatalf("Wrong headers: %v", header)
+	}
+	for key, value := range want {
+		if got := header.Get(key); got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+
+	_, err = (&InfoSMTPOptions{TrackOpens: "sometimes"}).Header()
+	if err == nil {
+		t.Fatal("Expected error on invalid tracking value")
+	}
+}
+
+func TestSendMailSMTPWithOptions(t *testing.T) {
+	smtpClient := &capturingSMTPClient{}
+	client := NewClient(NewhttpClientMock(true), smtpClient)
+
+	header := make(textproto.MIMEHeader)
+	header.Add("From", "Mailjet Pilot <pilot@mailjet.com>")
+	header.Add("To", "passenger@mailjet.com")
+	header.Add("Subject", "Hello")
+	header.Add(HeaderMJCustomID, "overridden")
+
+	err := client.SendMailSMTP(&InfoSMTP{
+		From:       "pilot@mailjet.com",
+		Recipients: []string{"passenger@mailjet.com"},
+		Header:     header,
+		Content:    []byte("Hello"),
+		Options: &InfoSMTPOptions{
+			CustomID:       "order-42",
+			CustomCampaign: "summer",
+		},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	msg := string(smtpClient.msg)
+	if !strings.Contains(msg, "X-Mj-Customid: order-42\r\n") || strings.Contains(msg, "overridden") {
+		t.Errorf("Missing custom ID header: %q", msg)
+	}
+	if !strings.Contains(msg, "X-Mailjet-Campaign: summer\r\n") {
+		t.Errorf("Missing campaign header: %q", msg)
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\nHello") {
+		t.Errorf("Wrong body: %q", msg)
+	}
+	if header.Get(HeaderMJCustomID) != "overridden" {
+		t.Error("The caller header must not be modified")
+	}
+}
+
+func TestSendMailSMTPHeaderInjection(t *testing.T) {
+	client := NewClient(NewhttpClientMock(true), &capturingSMTPClient{})
+	for _, options := range []*InfoSMTPOptions{
+		{CustomID: "order-42\r\nBcc: victim@example.com"},
+		{EventPayload: "{}\n\nInjected body"},
+	} {
+		err := client.SendMailSMTP(&InfoSMTP{
+			From: "pilot@mailjet.com", Recipients: []string{"passenger@mailjet.com"}, Options: options,
+		})
+		if err == nil {
+			t.Errorf("Expected error for %+v", options)
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("X-Custom", "value\r\nBcc: victim@example.com")
+	err := client.SendMailSMTP(&InfoSMTP{From: "pilot@mailjet.com", Recipients: []string{"passenger@mailjet.com"}, Header: header})
+	if err == nil {
+		t.Error("Expected error for a custom header with CRLF")
+	}
+}
+
+func TestBuildMessageFolding(t *testing.T) {
+	vars := make(map[string]interface{})
+	for i := 0; i < 200; i++ {
+		vars[strconv.Itoa(i)] = strings.Repeat("v", 10)
+	}
+	header, err := (&InfoSMTPOptions{Variables: vars}).Header()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	msg, err := buildMessage(header, []byte("Hello"))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for _, line := range strings.Split(string(msg), "\r\n") {
+		if len(line) > maxHeaderLine {
+			t.Fatalf("Line of %d characters", len(line))
+		}
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var decoded map[string]interface{}
+	if err = json.Unmarshal([]byte(parsed.Header.Get(HeaderMJVars)), &decoded); err != nil || !reflect.DeepEqual(decoded, vars) {
+		t.Errorf("Wrong variables after unfolding: %v (%v)", decoded, err)
+	}
+
+	header = textproto.MIMEHeader{HeaderMJEventPayload: {strings.Repeat("x", maxHeaderLine)}}
+	if _, err = buildMessage(header, nil); err == nil {
+		t.Error("Expected error for a header that cannot be folded")
+	}
+}
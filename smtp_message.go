@@ -0,0 +1,509 @@
+package mailjet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfoMessagesV31ToSMTP converts a Send API v3.1 message into a MIME mail sent via SMTP.
+// Mailjet features are carried by InfoSMTP.Options. MonitoringCategory and
+// StatisticsContactsListID have no SMTP equivalent and are dropped.
+func InfoMessagesV31ToSMTP(message *InfoMessagesV31) (*InfoSMTP, error) {
+	if message == nil || message.From == nil || message.From.Email == "" {
+		return nil, errors.New("converting message to SMTP: missing sender")
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("MIME-Version", "1.0")
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("From", formatAddress(message.From))
+	if message.Sender != nil && message.Sender.Email != "" {
+		header.Set("Sender", formatAddress(message.Sender))
+	}
+	if message.ReplyTo != nil && message.ReplyTo.Email != "" {
+		header.Set("Reply-To", formatAddress(message.ReplyTo))
+	}
+	if message.To != nil && len(*message.To) > 0 {
+		header.Set("To", formatAddressList(*message.To))
+	}
+	if message.Cc != nil && len(*message.Cc) > 0 {
+		header.Set("Cc", formatAddressList(*message.Cc))
+	}
+	if message.Subject != "" {
+		header.Set("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	}
+	for key, value := range message.Headers {
+		v := fmt.Sprint(value)
+		if err := checkHeader(key, v); err != nil {
+			return nil, fmt.Errorf("converting message to SMTP: %w", err)
+		}
+		header.Set(key, v)
+	}
+
+	var recipients []string
+	for _, list := range []*RecipientsV31{message.To, message.Cc, message.Bcc} {
+		if list == nil {
+			continue
+		}
+		for _, r := range *list {
+			recipients = append(recipients, r.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("converting message to SMTP: missing recipients")
+	}
+
+	contentType, content, err := buildMIMEBody(message)
+	if err != nil {
+		return nil, fmt.Errorf("converting message to SMTP: %w", err)
+	}
+	for key, values := range contentType {
+		header[key] = values
+	}
+
+	return &InfoSMTP{
+		From:       message.From.Email,
+		Recipients: recipients,
+		Header:     header,
+		Content:    content,
+		Options: &InfoSMTPOptions{
+			Priority:               message.Priority,
+			CustomCampaign:         message.CustomCampaign,
+			DeduplicateCampaign:    message.DeduplicateCampaign,
+			TrackClicks:            message.TrackClicks,
+			TrackOpens:             message.TrackOpens,
+			CustomID:               message.CustomID,
+			Variables:              message.Variables,
+			EventPayload:           message.EventPayload,
+			TemplateID:             message.TemplateID,
+			TemplateLanguage:       message.TemplateLanguage,
+			TemplateErrorReporting: message.TemplateErrorReporting,
+			TemplateErrorDeliver:   message.TemplateErrorDeliver,
+		},
+	}, nil
+}
+
+// InfoSMTPToMessagesV31 converts a MIME mail sent via SMTP into a Send API v3.1 message.
+// Envelope recipients missing from the To and Cc headers are sent as Bcc.
+func InfoSMTPToMessagesV31(info *InfoSMTP) (*InfoMessagesV31, error) {
+	if info == nil {
+		return nil, errors.New("converting SMTP mail: nil mail")
+	}
+	header := mail.Header(info.Header)
+	decoder := new(mime.WordDecoder)
+
+	message := &InfoMessagesV31{From: &RecipientV31{Email: info.From}}
+	if from, err := header.AddressList("From"); err == nil && len(from) > 0 {
+		message.From = &RecipientV31{Email: from[0].Address, Name: from[0].Name}
+	}
+	if sender, err := header.AddressList("Sender"); err == nil && len(sender) > 0 {
+		message.Sender = &RecipientV31{Email: sender[0].Address, Name: sender[0].Name}
+	}
+	if replyTo, err := header.AddressList("Reply-To"); err == nil && len(replyTo) > 0 {
+		message.ReplyTo = &RecipientV31{Email: replyTo[0].Address, Name: replyTo[0].Name}
+	}
+
+	visible := make(map[string]bool)
+	for _, key := range []string{"To", "Cc"} {
+		addresses, err := header.AddressList(key)
+		if err != nil {
+			continue
+		}
+		list := make(RecipientsV31, 0, len(addresses))
+		for _, address := range addresses {
+			visible[strings.ToLower(address.Address)] = true
+			list = append(list, RecipientV31{Email: address.Address, Name: address.Name})
+		}
+		if key == "To" {
+			message.To = &list
+		} else {
+			message.Cc = &list
+		}
+	}
+	var bcc RecipientsV31
+	for _, recipient := range info.Recipients {
+		if !visible[strings.ToLower(recipient)] {
+			bcc = append(bcc, RecipientV31{Email: recipient})
+		}
+	}
+	if len(bcc) > 0 {
+		message.Bcc = &bcc
+	}
+
+	if subject := header.Get("Subject"); subject != "" {
+		decoded, err := decoder.DecodeHeader(subject)
+		if err != nil {
+			decoded = subject
+		}
+		message.Subject = decoded
+	}
+
+	for key, values := range info.Header {
+		if isConvertedHeader(key) {
+			continue
+		}
+		if message.Headers == nil {
+			message.Headers = make(map[string]interface{})
+		}
+		message.Headers[key] = strings.Join(values, ", ")
+	}
+
+	if err := parseMIMEBody(message, info.Header.Get("Content-Type"),
+		info.Header.Get("Content-Transfer-Encoding"), bytes.NewReader(info.Content)); err != nil {
+		return nil, fmt.Errorf("converting SMTP mail: %w", err)
+	}
+
+	if o := info.Options; o != nil {
+		message.Priority = o.Priority
+		message.CustomCampaign = o.CustomCampaign
+		message.DeduplicateCampaign = o.DeduplicateCampaign
+		message.TrackClicks = o.TrackClicks
+		message.TrackOpens = o.TrackOpens
+		message.CustomID = o.CustomID
+		message.Variables = o.Variables
+		message.EventPayload = o.EventPayload
+		message.TemplateID = o.TemplateID
+		message.TemplateLanguage = o.TemplateLanguage
+		message.TemplateErrorReporting = o.TemplateErrorReporting
+		message.TemplateErrorDeliver = o.TemplateErrorDeliver
+	}
+	return message, nil
+}
+
+// isConvertedHeader reports whether the header is represented by a dedicated
+// InfoMessagesV31 field, or generated again when the message is sent.
+func isConvertedHeader(key string) bool {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case "From", "Sender", "Reply-To", "To", "Cc", "Bcc", "Subject", "Date",
+		"Mime-Version", "Content-Type", "Content-Transfer-Encoding":
+		return true
+	}
+	return false
+}
+
+func formatAddress(r *RecipientV31) string {
+	return (&mail.Address{Name: r.Name, Address: r.Email}).String()
+}
+
+func formatAddressList(list RecipientsV31) string {
+	addresses := make([]string, 0, len(list))
+	for i := range list {
+		addresses = append(addresses, formatAddress(&list[i]))
+	}
+	return strings.Join(addresses, ", ")
+}
+
+// mimePart is a MIME entity: its header and its encoded body.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// buildMIMEBody returns the top level content headers and the body of the message.
+// Text and HTML parts are nested in multipart/alternative, inline attachments
+// in multipart/related and attachments in multipart/mixed.
+func buildMIMEBody(message *InfoMessagesV31) (textproto.MIMEHeader, []byte, error) {
+	var alternatives []mimePart
+	if message.TextPart != "" {
+		alternatives = append(alternatives, textPart("text/plain", message.TextPart))
+	}
+	if message.HTMLPart != "" {
+		alternatives = append(alternatives, textPart("text/html", message.HTMLPart))
+	}
+
+	var body mimePart
+	switch len(alternatives) {
+	case 0:
+		body = textPart("text/plain", "")
+	case 1:
+		body = alternatives[0]
+	default:
+		var err error
+		if body, err = multipartOf("alternative", alternatives); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if message.InlinedAttachments != nil && len(*message.InlinedAttachments) > 0 {
+		parts := []mimePart{body}
+		for _, a := range *message.InlinedAttachments {
+			part, err := attachmentPart(a.AttachmentV31, "inline")
+			if err != nil {
+				return nil, nil, err
+			}
+			part.header.Set("Content-ID", "<"+a.ContentID+">")
+			parts = append(parts, part)
+		}
+		var err error
+		if body, err = multipartOf("related", parts); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if message.Attachments != nil && len(*message.Attachments) > 0 {
+		parts := []mimePart{body}
+		for _, a := range *message.Attachments {
+			part, err := attachmentPart(a, "attachment")
+			if err != nil {
+				return nil, nil, err
+			}
+			parts = append(parts, part)
+		}
+		var err error
+		if body, err = multipartOf("mixed", parts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return body.header, body.body, nil
+}
+
+func textPart(contentType, content string) mimePart {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	buff := bytes.NewBuffer(nil)
+	w := quotedprintable.NewWriter(buff)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+	return mimePart{header: header, body: buff.Bytes()}
+}
+
+func attachmentPart(a AttachmentV31, disposition string) (mimePart, error) {
+	content, err := base64.StdEncoding.DecodeString(a.Base64Content)
+	if err != nil {
+		return mimePart{}, fmt.Errorf("attachment %q: %w", a.Filename, err)
+	}
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+
+	encoded := base64.StdEncoding.EncodeToString(content)
+	buff := bytes.NewBuffer(nil)
+	for len(encoded) > 76 {
+		buff.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buff.WriteString(encoded)
+	return mimePart{header: header, body: buff.Bytes()}, nil
+}
+
+func multipartOf(subtype string, parts []mimePart) (mimePart, error) {
+	buff := bytes.NewBuffer(nil)
+	w := multipart.NewWriter(buff)
+	for _, part := range parts {
+		pw, err := w.CreatePart(part.header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if _, err = pw.Write(part.body); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return mimePart{}, err
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": w.Boundary()}))
+	return mimePart{header: header, body: buff.Bytes()}, nil
+}
+
+// parseMIMEBody walks the MIME tree and fills the text, HTML and attachment fields of the message.
+func parseMIMEBody(message *InfoMessagesV31, contentType, encoding string, r io.Reader) error {
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err = parseMIMEPart(message, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", encoding)
+	return parseMIMEPart(message, header, r)
+}
+
+func parseMIMEPart(message *InfoMessagesV31, header textproto.MIMEHeader, r io.Reader) error {
+	contentType := header.Get("Content-Type")
+	if strings.HasPrefix(strings.ToLower(contentType), "multipart/") {
+		return parseMIMEBody(message, contentType, "", r)
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, newLineSkipper(r))
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	contentID := strings.Trim(header.Get("Content-ID"), "<>")
+
+	switch {
+	case disposition == "" && contentID == "" && mediaType == "text/plain" && message.TextPart == "":
+		message.TextPart = string(content)
+	case disposition == "" && contentID == "" && mediaType == "text/html" && message.HTMLPart == "":
+		message.HTMLPart = string(content)
+	default:
+		attachment := AttachmentV31{
+			ContentType:   mediaType,
+			Base64Content: base64.StdEncoding.EncodeToString(content),
+			Filename:      dispositionParams["filename"],
+		}
+		if contentID != "" {
+			if message.InlinedAttachments == nil {
+				message.InlinedAttachments = &InlinedAttachmentsV31{}
+			}
+			*message.InlinedAttachments = append(*message.InlinedAttachments,
+				InlinedAttachmentV31{AttachmentV31: attachment, ContentID: contentID})
+		} else {
+			if message.Attachments == nil {
+				message.Attachments = &AttachmentsV31{}
+			}
+			*message.Attachments = append(*message.Attachments, attachment)
+		}
+	}
+	return nil
+}
+
+// lineSkipper drops the line breaks of base64 encoded content.
+type lineSkipper struct {
+	r io.Reader
+}
+
+func newLineSkipper(r io.Reader) io.Reader {
+	return &lineSkipper{r: r}
+}
+
+func (l *lineSkipper) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// sendMailFromV31 converts a Send API v3.1 message into a Send API v3 one.
+func sendMailFromV31(message *InfoMessagesV31) (*InfoSendMail, error) {
+	if message == nil || message.From == nil || message.From.Email == "" {
+		return nil, errors.New("converting message to Send API v3: missing sender")
+	}
+
+	data := &InfoSendMail{
+		FromEmail:             message.From.Email,
+		FromName:              message.From.Name,
+		Subject:               message.Subject,
+		TextPart:              message.TextPart,
+		HTMLPart:              message.HTMLPart,
+		MjPrio:                message.Priority,
+		MjCampaign:            message.CustomCampaign,
+		MjDeduplicateCampaign: message.DeduplicateCampaign,
+		MjCustomID:            message.CustomID,
+		MjEventPayLoad:        message.EventPayload,
+	}
+	if message.Sender != nil {
+		data.Sender = formatAddress(message.Sender)
+	}
+	if message.To != nil {
+		data.To = formatAddressList(*message.To)
+	}
+	if message.Cc != nil {
+		data.Cc = formatAddressList(*message.Cc)
+	}
+	if message.Bcc != nil {
+		data.Bcc = formatAddressList(*message.Bcc)
+	}
+	if message.Attachments != nil {
+		for _, a := range *message.Attachments {
+			data.Attachments = append(data.Attachments,
+				Attachment{ContentType: a.ContentType, Content: a.Base64Content, Filename: a.Filename})
+		}
+	}
+	if message.InlinedAttachments != nil {
+		for _, a := range *message.InlinedAttachments {
+			// The Send API v3 references inline attachments by filename.
+			filename := a.ContentID
+			if filename == "" {
+				filename = a.Filename
+			}
+			data.InlineAttachments = append(data.InlineAttachments,
+				Attachment{ContentType: a.ContentType, Content: a.Base64Content, Filename: filename})
+		}
+	}
+	if message.TemplateID != 0 {
+		data.MjTemplateID = strconv.Itoa(message.TemplateID)
+	}
+	if message.TemplateLanguage {
+		data.MjTemplateLanguage = "true"
+	}
+	if message.TemplateErrorReporting != nil {
+		data.MjTemplateErrorReporting = message.TemplateErrorReporting.Email
+	}
+	if message.TemplateErrorDeliver {
+		data.MjTemplateErrorDeliver = "deliver"
+	}
+	if len(message.Variables) > 0 {
+		data.Vars = message.Variables
+	}
+	if len(message.Headers) > 0 || message.ReplyTo != nil {
+		data.Headers = make(map[string]string)
+		for key, value := range message.Headers {
+			v := fmt.Sprint(value)
+			if err := checkHeader(key, v); err != nil {
+				return nil, fmt.Errorf("converting message to Send API v3: %w", err)
+			}
+			data.Headers[key] = v
+		}
+		if message.ReplyTo != nil {
+			data.Headers["Reply-To"] = formatAddress(message.ReplyTo)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,161 @@
+package smtpfake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// Message is a message received by the server.
+type Message struct {
+	// Helo is the name sent by the client with EHLO.
+	Helo string
+	// Auth is the mechanism and credentials used by the client, e.g. "PLAIN user:password".
+	Auth string
+	// TLS reports whether the message has been received over TLS.
+	TLS bool
+	// From and Recipients are the addresses of the envelope.
+	From       string
+	Recipients []string
+	// Data is the raw message.
+	Data []byte
+	// Header is the header of the message, nil if it could not be parsed.
+	Header mail.Header
+	// Parts are the leaf parts of the MIME tree, with decoded bodies.
+	// A message which is not multipart has a single part.
+	Parts []*Part
+	// ParseError is the error met while parsing Data, if any.
+	ParseError error
+}
+
+// Part is a leaf part of a MIME message.
+type Part struct {
+	Header textproto.MIMEHeader
+	// MediaType is the media type of the Content-Type, e.g. "text/html".
+	MediaType string
+	// Filename is the name of an attachment.
+	Filename string
+	// ContentID is the Content-ID of an inline part, without angle brackets.
+	ContentID string
+	// Body is the content of the part, decoded from its Content-Transfer-Encoding.
+	Body []byte
+}
+
+func newMessage(sess *session, data []byte) *Message {
+	m := &Message{
+		Helo:       sess.helo,
+		Auth:       sess.auth,
+		TLS:        sess.tls,
+		From:       sess.from,
+		Recipients: append([]string(nil), sess.to...),
+		Data:       data,
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		m.ParseError = err
+		return m
+	}
+	m.Header = msg.Header
+	m.Parts, m.ParseError = parseParts(textproto.MIMEHeader(msg.Header), msg.Body)
+	return m
+}
+
+// parseParts returns the leaf parts of the entity with this header and body.
+func parseParts(header textproto.MIMEHeader, body io.Reader) ([]*Part, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var parts []*Part
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := reader.NextRawPart()
+			if err == io.EOF {
+				return parts, nil
+			}
+			if err != nil {
+				return parts, err
+			}
+			children, err := parseParts(p.Header, p)
+			parts = append(parts, children...)
+			if err != nil {
+				return parts, err
+			}
+		}
+	}
+
+	content, err := decodeBody(header.Get("Content-Transfer-Encoding"), body)
+	part := &Part{
+		Header:    header,
+		MediaType: mediaType,
+		ContentID: strings.Trim(header.Get("Content-Id"), "<>"),
+		Body:      content,
+	}
+	if _, disposition, derr := mime.ParseMediaType(header.Get("Content-Disposition")); derr == nil {
+		part.Filename = disposition["filename"]
+	}
+	if part.Filename == "" {
+		part.Filename = params["name"]
+	}
+	return []*Part{part}, err
+}
+
+func decodeBody(encoding string, body io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, body))
+	case "quoted-printable":
+		return ioutil.ReadAll(quotedprintable.NewReader(body))
+	}
+	return ioutil.ReadAll(body)
+}
+
+// Subject returns the decoded subject of the message.
+func (m *Message) Subject() string {
+	if m.Header == nil {
+		return ""
+	}
+	subject := m.Header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		return decoded
+	}
+	return subject
+}
+
+// Text returns the body of the first text/plain part which is not an attachment.
+func (m *Message) Text() string {
+	return m.body("text/plain")
+}
+
+// HTML returns the body of the first text/html part which is not an attachment.
+func (m *Message) HTML() string {
+	return m.body("text/html")
+}
+
+func (m *Message) body(mediaType string) string {
+	for _, p := range m.Parts {
+		if p.MediaType == mediaType && p.Filename == "" {
+			return string(p.Body)
+		}
+	}
+	return ""
+}
+
+// Attachments returns the parts with a file name, inline ones included.
+func (m *Message) Attachments() []*Part {
+	var attachments []*Part
+	for _, p := range m.Parts {
+		if p.Filename != "" {
+			attachments = append(attachments, p)
+		}
+	}
+	return attachments
+}
@@ -0,0 +1,411 @@
+// Package smtpfake provides an in-process SMTP server for the tests of the SMTP transport.
+//
+// The server speaks ESMTP with STARTTLS (or implicit TLS) and AUTH PLAIN and LOGIN,
+// captures the received messages into an inspectable mailbox, and can inject
+// 4xx and 5xx replies:
+//
+//	srv, err := smtpfake.NewServer(smtpfake.WithCredentials("public", "private"))
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer srv.Close()
+//	client := mailjet.NewSMTPClient("public", "private",
+//		mailjet.WithSMTPHost(srv.Host()),
+//		mailjet.WithSMTPPort(srv.Port()),
+//		mailjet.WithSMTPTLSConfig(srv.ClientTLSConfig()),
+//	)
+package smtpfake
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an in-process SMTP server.
+type Server struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	certificate *x509.Certificate
+	implicitTLS bool
+	startTLS    bool
+	username    string
+	password    string
+	delay       time.Duration
+
+	mu          sync.Mutex
+	mailbox     []*Message
+	commands    []string
+	connections int
+	replies     map[string][]string
+}
+
+// Options are functional options that configure the Server.
+type Options func(*Server)
+
+// WithImplicitTLS makes the server expect TLS from the connection, as on port 465.
+func WithImplicitTLS() Options {
+	return func(s *Server) {
+		s.implicitTLS = true
+		s.startTLS = false
+	}
+}
+
+// WithoutSTARTTLS makes the server not offer the STARTTLS extension.
+func WithoutSTARTTLS() Options {
+	return func(s *Server) {
+		s.startTLS = false
+	}
+}
+
+// WithCredentials sets the only credentials accepted by AUTH.
+// By default, any credentials are accepted.
+func WithCredentials(username, password string) Options {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithDelay delays each reply of the server, e.g. to test the timeouts of the client.
+func WithDelay(delay time.Duration) Options {
+	return func(s *Server) {
+		s.delay = delay
+	}
+}
+
+// NewServer starts a server listening on a random local port. It should be closed with Close.
+func NewServer(options ...Options) (*Server, error) {
+	s := &Server{startTLS: true, replies: make(map[string][]string)}
+	for _, option := range options {
+		option(s)
+	}
+
+	var err error
+	s.tlsConfig, s.certificate, err = selfSignedTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("smtpfake: creating certificate: %w", err)
+	}
+	if s.implicitTLS {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smtpfake: listening: %w", err)
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// Addr returns the address of the server, as host:port.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Host returns the host name of the server, valid for its certificate.
+func (s *Server) Host() string {
+	return "localhost"
+}
+
+// Port returns the port of the server.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// ClientTLSConfig returns a TLS configuration trusting the self-signed certificate of the server.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.certificate)
+	return &tls.Config{RootCAs: roots, ServerName: s.Host(), MinVersion: tls.VersionTLS12}
+}
+
+// InjectReply queues a reply to the next command with this verb, e.g.
+// InjectReply("MAIL", "421 Service not available") or InjectReply("RCPT", "550 No such user").
+// The command is then not executed, and a 421 reply closes the connection.
+func (s *Server) InjectReply(verb, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	verb = strings.ToUpper(verb)
+	s.replies[verb] = append(s.replies[verb], reply)
+}
+
+// Messages returns the messages received by the server.
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Message(nil), s.mailbox...)
+}
+
+// Commands returns the verbs of the commands received by the server, e.g. "EHLO" or "MAIL".
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+// Connections returns the number of connections accepted by the server.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections
+}
+
+// Reset empties the mailbox and forgets the received commands.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mailbox = nil
+	s.commands = nil
+	s.connections = 0
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// session is the state of an SMTP connection.
+type session struct {
+	conn net.Conn
+	tp   *textproto.Conn
+	tls  bool
+	helo string
+	auth string
+	from string
+	to   []string
+}
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{conn: conn, tp: textproto.NewConn(conn), tls: s.implicitTLS}
+	defer func() { sess.conn.Close() }()
+	_ = sess.tp.PrintfLine("220 localhost ESMTP smtpfake")
+
+	for {
+		line, err := sess.tp.ReadLine()
+		if err != nil {
+			return
+		}
+		time.Sleep(s.delay)
+		fields := strings.Fields(line)
+		verb := ""
+		if len(fields) > 0 {
+			verb = strings.ToUpper(fields[0])
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.mu.Unlock()
+
+		if reply := s.injectedReply(verb); reply != "" {
+			_ = sess.tp.PrintfLine("%s", reply)
+			if strings.HasPrefix(reply, "421") {
+				return
+			}
+			continue
+		}
+		if !s.execute(sess, verb, line, fields) {
+			return
+		}
+	}
+}
+
+func (s *Server) injectedReply(verb string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replies := s.replies[verb]
+	if len(replies) == 0 {
+		return ""
+	}
+	s.replies[verb] = replies[1:]
+	return replies[0]
+}
+
+// execute runs the command and returns false when the connection must be closed.
+func (s *Server) execute(sess *session, verb, line string, fields []string) bool {
+	tp := sess.tp
+	switch verb {
+	case "EHLO", "HELO":
+		sess.helo = strings.TrimSpace(line[len(verb):])
+		lines := []string{"250-localhost"}
+		if s.startTLS && !sess.tls {
+			lines = append(lines, "250-STARTTLS")
+		}
+		lines = append(lines, "250-8BITMIME", "250 AUTH PLAIN LOGIN")
+		_ = tp.PrintfLine("%s", strings.Join(lines, "\r\n"))
+	case "STARTTLS":
+		if !s.startTLS || sess.tls {
+			_ = tp.PrintfLine("503 STARTTLS not available")
+			return true
+		}
+		_ = tp.PrintfLine("220 Ready to start TLS")
+		tlsConn := tls.Server(sess.conn, s.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		sess.conn, sess.tp, sess.tls = tlsConn, textproto.NewConn(tlsConn), true
+		sess.helo, sess.auth = "", ""
+	case "AUTH":
+		username, password, ok := readAuth(tp, fields)
+		if !ok {
+			_ = tp.PrintfLine("501 Malformed AUTH input")
+			return true
+		}
+		if s.username != "" && (username != s.username || password != s.password) {
+			_ = tp.PrintfLine("535 Authentication credentials invalid")
+			return true
+		}
+		sess.auth = strings.ToUpper(fields[1]) + " " + username + ":" + password
+		_ = tp.PrintfLine("235 Authentication succeeded")
+	case "MAIL":
+		if s.username != "" && sess.auth == "" {
+			_ = tp.PrintfLine("530 Authentication required")
+			return true
+		}
+		sess.from = pathArgument(line)
+		sess.to = nil
+		_ = tp.PrintfLine("250 OK")
+	case "RCPT":
+		if sess.from == "" {
+			_ = tp.PrintfLine("503 Need MAIL command")
+			return true
+		}
+		sess.to = append(sess.to, pathArgument(line))
+		_ = tp.PrintfLine("250 OK")
+	case "DATA":
+		if len(sess.to) == 0 {
+			_ = tp.PrintfLine("503 Need RCPT command")
+			return true
+		}
+		_ = tp.PrintfLine("354 Go ahead")
+		data, err := tp.ReadDotBytes()
+		if err != nil {
+			return false
+		}
+		s.mu.Lock()
+		s.mailbox = append(s.mailbox, newMessage(sess, data))
+		s.mu.Unlock()
+		sess.from, sess.to = "", nil
+		_ = tp.PrintfLine("250 OK queued")
+	case "RSET":
+		sess.from, sess.to = "", nil
+		_ = tp.PrintfLine("250 OK")
+	case "NOOP":
+		_ = tp.PrintfLine("250 OK")
+	case "QUIT":
+		_ = tp.PrintfLine("221 Bye")
+		return false
+	default:
+		_ = tp.PrintfLine("502 Command not implemented")
+	}
+	return true
+}
+
+// readAuth reads the credentials of an AUTH PLAIN or AUTH LOGIN exchange.
+func readAuth(tp *textproto.Conn, fields []string) (username, password string, ok bool) {
+	if len(fields) < 2 {
+		return "", "", false
+	}
+	switch strings.ToUpper(fields[1]) {
+	case "PLAIN":
+		response := ""
+		if len(fields) > 2 {
+			response = fields[2]
+		} else {
+			_ = tp.PrintfLine("334 ")
+			response, _ = tp.ReadLine()
+		}
+		raw, err := base64.StdEncoding.DecodeString(response)
+		parts := strings.Split(string(raw), "\x00")
+		if err != nil || len(parts) != 3 {
+			return "", "", false
+		}
+		return parts[1], parts[2], true
+	case "LOGIN":
+		var values [2]string
+		for i, prompt := range []string{"Username:", "Password:"} {
+			_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+			line, err := tp.ReadLine()
+			if err != nil {
+				return "", "", false
+			}
+			value, err := base64.StdEncoding.DecodeString(line)
+			if err != nil {
+				return "", "", false
+			}
+			values[i] = string(value)
+		}
+		return values[0], values[1], true
+	}
+	return "", "", false
+}
+
+// pathArgument returns the address of a MAIL FROM:<address> or RCPT TO:<address> command.
+func pathArgument(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func selfSignedTLSConfig() (*tls.Config, *x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return config, certificate, nil
+}
@@ -0,0 +1,153 @@
+package smtpfake_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/smtpfake"
+)
+
+func newClient(t *testing.T, options ...smtpfake.Options) (*smtpfake.Server, *mailjet.Client) {
+	t.Helper()
+	srv, err := smtpfake.NewServer(append([]smtpfake.Options{smtpfake.WithCredentials("public", "private")}, options...)...)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	smtpClient := mailjet.NewSMTPClient("public", "private",
+		mailjet.WithSMTPHost(srv.Host()),
+		mailjet.WithSMTPPort(srv.Port()),
+		mailjet.WithSMTPTLSConfig(srv.ClientTLSConfig()),
+		mailjet.WithSMTPTLSMode(mailjet.SMTPStartTLSRequired),
+	)
+	return srv, mailjet.NewClient(mailjet.NewHTTPClient("public", "private"), smtpClient)
+}
+
+func TestSendMailSMTP(t *testing.T) {
+	srv, client := newClient(t)
+
+	info, err := mailjet.InfoMessagesV31ToSMTP(&mailjet.InfoMessagesV31{
+		From:     &mailjet.RecipientV31{Email: "pilot@mailjet.com", Name: "Mailjet Pilot"},
+		To:       &mailjet.RecipientsV31{{Email: "passenger1@mailjet.com"}},
+		Bcc:      &mailjet.RecipientsV31{{Email: "passenger2@mailjet.com"}},
+		Subject:  "Vol à destination de Paris",
+		TextPart: "Dear passenger, welcome!",
+		HTMLPart: `<h3>Dear passenger, <img src="cid:logo"> welcome!</h3>`,
+		Attachments: &mailjet.AttachmentsV31{{
+			ContentType:   "text/plain",
+			Filename:      "ticket.txt",
+			Base64Content: base64.StdEncoding.EncodeToString([]byte("Seat 15B")),
+		}},
+		InlinedAttachments: &mailjet.InlinedAttachmentsV31{{
+			AttachmentV31: mailjet.AttachmentV31{
+				ContentType:   "image/png",
+				Filename:      "logo.png",
+				Base64Content: base64.StdEncoding.EncodeToString([]byte("not really a png")),
+			},
+			ContentID: "logo",
+		}},
+		CustomID: "flight-42",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = client.SendMailSMTP(info); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected one message, got %d", len(messages))
+	}
+	m := messages[0]
+	if m.ParseError != nil {
+		t.Fatal("Unexpected parse error:", m.ParseError)
+	}
+	if !m.TLS || m.Auth != "PLAIN public:private" {
+		t.Errorf("Expected an authenticated TLS session, got %q (TLS %t)", m.Auth, m.TLS)
+	}
+	if m.From != "pilot@mailjet.com" || strings.Join(m.Recipients, ",") != "passenger1@mailjet.com,passenger2@mailjet.com" {
+		t.Errorf("Wrong envelope: %s -> %v", m.From, m.Recipients)
+	}
+	if m.Header.Get("Bcc") != "" || m.Header.Get(mailjet.HeaderMJCustomID) != "flight-42" {
+		t.Errorf("Wrong headers: %v", m.Header)
+	}
+	if m.Subject() != "Vol à destination de Paris" || m.Text() != "Dear passenger, welcome!" ||
+		!strings.Contains(m.HTML(), `cid:logo`) {
+		t.Errorf("Wrong content: %q %q %q", m.Subject(), m.Text(), m.HTML())
+	}
+
+	attachments := m.Attachments()
+	if len(attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(attachments))
+	}
+	for _, a := range attachments {
+		switch a.Filename {
+		case "ticket.txt":
+			if string(a.Body) != "Seat 15B" {
+				t.Errorf("Wrong attachment: %q", a.Body)
+			}
+		case "logo.png":
+			if a.ContentID != "logo" || a.MediaType != "image/png" {
+				t.Errorf("Wrong inline attachment: %+v", a)
+			}
+		default:
+			t.Errorf("Unexpected attachment %q", a.Filename)
+		}
+	}
+}
+
+func TestInjectReply(t *testing.T) {
+	srv, client := newClient(t)
+	info := &mailjet.InfoSMTP{
+		From:       "pilot@mailjet.com",
+		Recipients: []string{"passenger@mailjet.com"},
+		Header:     textproto.MIMEHeader{"Subject": {"Hello"}},
+		Content:    []byte("Hello"),
+	}
+
+	srv.InjectReply("RCPT", "550 5.1.1 No such user")
+	err := client.SendMailSMTP(info)
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) || protoErr.Code != 550 {
+		t.Fatalf("Expected a 550 error, got %v", err)
+	}
+
+	srv.InjectReply("DATA", "452 4.3.1 Insufficient system storage")
+	if err = client.SendMailSMTP(info); err == nil || !strings.Contains(err.Error(), "452") {
+		t.Fatalf("Expected a 452 error, got %v", err)
+	}
+
+	if err = client.SendMailSMTP(info); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(srv.Messages()) != 1 {
+		t.Fatalf("Expected only the last message to be received, got %d", len(srv.Messages()))
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	srv, err := smtpfake.NewServer(smtpfake.WithCredentials("public", "private"))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer srv.Close()
+
+	client := mailjet.NewSMTPClient("public", "wrong",
+		mailjet.WithSMTPHost(srv.Host()),
+		mailjet.WithSMTPPort(srv.Port()),
+		mailjet.WithSMTPTLSConfig(srv.ClientTLSConfig()),
+	)
+	err = client.SendMail("pilot@mailjet.com", []string{"passenger@mailjet.com"}, []byte("Subject: Hello\r\n\r\nHello"))
+	if err == nil || !strings.Contains(err.Error(), "535") {
+		t.Fatalf("Expected an authentication error, got %v", err)
+	}
+	if len(srv.Messages()) != 0 {
+		t.Fatal("No message must be received")
+	}
+}
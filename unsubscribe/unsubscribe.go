@@ -0,0 +1,354 @@
+// Package unsubscribe implements one-click unsubscription (RFC 8058) and a preference centre.
+//
+// The Handler signs unsubscribe links, holding the address of a contact and the lists the
+// message was sent for, and serves them. A POST of the one-click body, as sent by the mailbox
+// providers, unsubscribes the contact from all the lists of the link with the
+// managecontactslists action. A GET shows the preference centre, a form where the contact
+// picks the lists to unsubscribe from, or redirects to the page set with WithPreferencePage.
+//
+//	h, err := unsubscribe.NewHandler(client, secret, "https://example.com/unsubscribe")
+//	http.Handle("/unsubscribe", h)
+//	err = h.SetHeaders(&message, "reader@mailjet.com", listID)
+//
+// Tokens are signed, not encrypted, and never expire unless WithTTL is set: mailbox providers
+// may use the link long after the message was sent.
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/internal/token"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// TokenParam is the query parameter of the unsubscribe link holding the token.
+const TokenParam = "token"
+
+// ListParam is the form field of the preference centre holding the lists to unsubscribe from.
+const ListParam = "list"
+
+// maxFormMemory bounds the memory of a parsed multipart/form-data body.
+const maxFormMemory = 64 << 10
+
+// Errors of Unsubscribe.
+var (
+	ErrInvalidToken = errors.New("unsubscribe: invalid token")
+	ErrExpiredToken = errors.New("unsubscribe: expired token")
+	ErrUnknownList  = errors.New("unsubscribe: list not in the token")
+)
+
+// Request is the payload of an unsubscribe token.
+type Request struct {
+	Email string
+	Lists []int64
+}
+
+// Handler signs and serves unsubscribe links.
+type Handler struct {
+	client         *mailjet.Client
+	secret         []byte
+	baseURL        string
+	ttl            time.Duration
+	mailto         string
+	preferencePage string
+	successURL     string
+	page           *template.Template
+	onUnsubscribe  func(r Request)
+	now            func() time.Time
+}
+
+// Options are functional options that configure the Handler.
+type Options func(*Handler)
+
+// WithTTL sets how long an unsubscribe link is valid. Links never expire by default.
+func WithTTL(ttl time.Duration) Options {
+	return func(h *Handler) {
+		h.ttl = ttl
+	}
+}
+
+// WithMailto sets the mailto address added to the List-Unsubscribe header by SetHeaders.
+func WithMailto(mailto string) Options {
+	return func(h *Handler) {
+		h.mailto = mailto
+	}
+}
+
+// WithPreferencePage redirects the GET requests of the links to a preference centre of the
+// application, with the token in its query. The page posts the ListParam fields of the lists
+// to unsubscribe from, with the token, to the Handler.
+func WithPreferencePage(url string) Options {
+	return func(h *Handler) {
+		h.preferencePage = url
+	}
+}
+
+// WithRedirect sets the page the preference centre redirects to once its form is posted.
+func WithRedirect(url string) Options {
+	return func(h *Handler) {
+		h.successURL = url
+	}
+}
+
+// WithTemplate sets the template of the preference centre. It is executed with a
+// PreferenceCentre.
+func WithTemplate(page *template.Template) Options {
+	return func(h *Handler) {
+		h.page = page
+	}
+}
+
+// WithUnsubscribeHook sets a function called after each unsubscription.
+func WithUnsubscribeHook(hook func(r Request)) Options {
+	return func(h *Handler) {
+		h.onUnsubscribe = hook
+	}
+}
+
+// WithClock sets the function returning the current time.
+func WithClock(now func() time.Time) Options {
+	return func(h *Handler) {
+		h.now = now
+	}
+}
+
+// NewHandler returns a Handler signing its tokens with secret. baseURL is the URL the
+// Handler is served at. It returns an error when secret is shorter than 32 bytes.
+func NewHandler(client *mailjet.Client, secret []byte, baseURL string, options ...Options) (*Handler, error) {
+	if err := token.CheckKey(secret); err != nil {
+		return nil, fmt.Errorf("unsubscribe: %w", err)
+	}
+	h := &Handler{
+		client:  client,
+		secret:  secret,
+		baseURL: baseURL,
+		page:    defaultPage,
+		now:     time.Now,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h, nil
+}
+
+// Link returns the unsubscribe link of a contact from lists.
+func (h *Handler) Link(email string, lists ...int64) (string, error) {
+	if email == "" || len(lists) == 0 {
+		return "", errors.New("unsubscribe: the link needs an e-mail address and a list")
+	}
+	var expires time.Time
+	if h.ttl > 0 {
+		expires = h.now().Add(h.ttl)
+	}
+	t, err := token.Sign(h.secret, Request{Email: email, Lists: lists}, expires)
+	if err != nil {
+		return "", err
+	}
+	separator := "?"
+	if strings.Contains(h.baseURL, "?") {
+		separator = "&"
+	}
+	return h.baseURL + separator + TokenParam + "=" + url.QueryEscape(t), nil
+}
+
+// SetHeaders adds the List-Unsubscribe headers of the link of a contact to a message.
+func (h *Handler) SetHeaders(m *mailjet.InfoMessagesV31, email string, lists ...int64) error {
+	link, err := h.Link(email, lists...)
+	if err != nil {
+		return err
+	}
+	return m.SetListUnsubscribe(link, h.mailto)
+}
+
+// SetHeadersSMTP adds the List-Unsubscribe headers of the link of a contact to a mail.
+func (h *Handler) SetHeadersSMTP(info *mailjet.InfoSMTP, email string, lists ...int64) error {
+	link, err := h.Link(email, lists...)
+	if err != nil {
+		return err
+	}
+	return info.SetListUnsubscribe(link, h.mailto)
+}
+
+// Verify returns the request of a token. It returns ErrInvalidToken or ErrExpiredToken
+// when the token cannot be used.
+func (h *Handler) Verify(t string) (Request, error) {
+	var r Request
+	switch err := token.Verify(h.secret, t, h.now(), &r); err {
+	case nil:
+		return r, nil
+	case token.ErrExpired:
+		return r, ErrExpiredToken
+	default:
+		return r, ErrInvalidToken
+	}
+}
+
+// Unsubscribe verifies a token and unsubscribes its contact from lists, which must be lists
+// of the token, or it returns ErrUnknownList. All the lists of the token are used when lists
+// is empty.
+func (h *Handler) Unsubscribe(ctx context.Context, t string, lists ...int64) (Request, error) {
+	r, err := h.Verify(t)
+	if err != nil {
+		return r, err
+	}
+	if len(lists) > 0 {
+		allowed := make(map[int64]bool, len(r.Lists))
+		for _, id := range r.Lists {
+			allowed[id] = true
+		}
+		for _, id := range lists {
+			if !allowed[id] {
+				return r, fmt.Errorf("%w: %d", ErrUnknownList, id)
+			}
+		}
+		r.Lists = lists
+	}
+
+	payload := resources.ContactManagecontactslists{}
+	for _, id := range r.Lists {
+		payload.ContactsLists = append(payload.ContactsLists, resources.ContactsListAction{ListID: id, Action: mailjet.ImportUnsub})
+	}
+	var resp []resources.ContactManagecontactslists
+	err = h.client.Post(&mailjet.FullRequest{
+		Info:    &mailjet.Request{Resource: "contact", AltID: r.Email, Action: "managecontactslists"},
+		Payload: payload,
+	}, &resp, mailjet.WithContext(ctx))
+	if err != nil {
+		return r, fmt.Errorf("unsubscribe: unsubscribing %s: %w", r.Email, err)
+	}
+	if h.onUnsubscribe != nil {
+		h.onUnsubscribe(r)
+	}
+	return r, nil
+}
+
+// PreferenceCentre is the data of the template of the preference centre.
+type PreferenceCentre struct {
+	Email string
+	Token string
+	Lists []resources.Contactslist
+}
+
+var defaultPage = template.Must(template.New("preferences").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Subscription preferences</title></head>
+<body>
+<form method="post">
+<p>Unsubscribe {{.Email}} from:</p>
+<input type="hidden" name="token" value="{{.Token}}">
+{{range .Lists}}<p><label><input type="checkbox" name="list" value="{{.ID}}" checked> {{.Name}}</label></p>
+{{end}}<p><button type="submit">Unsubscribe</button></p>
+</form>
+</body></html>
+`))
+
+// ServeHTTP serves the unsubscribe links: the one-click POST requests, the GET requests
+// of the preference centre, and the POST requests of its form. POST bodies are read as
+// multipart/form-data, as RFC 8058 recommends for one-click, or urlencoded.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.preferences(w, r)
+	case http.MethodPost:
+		if err := r.ParseMultipartForm(maxFormMemory); err != nil && err != http.ErrNotMultipart {
+			http.Error(w, "Invalid form.", http.StatusBadRequest)
+			return
+		}
+		var lists []int64
+		oneClick := r.PostForm.Get("List-Unsubscribe") == "One-Click"
+		if !oneClick {
+			for _, value := range r.PostForm[ListParam] {
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid list.", http.StatusBadRequest)
+					return
+				}
+				lists = append(lists, id)
+			}
+			if len(lists) == 0 {
+				h.done(w, r)
+				return
+			}
+		}
+		if _, err := h.Unsubscribe(r.Context(), r.Form.Get(TokenParam), lists...); err != nil {
+			writeError(w, err)
+			return
+		}
+		if oneClick {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintln(w, "You are unsubscribed.")
+			return
+		}
+		h.done(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// preferences serves the preference centre. Nothing is changed on GET requests, which may
+// be made by the link scanners of mailbox providers.
+func (h *Handler) preferences(w http.ResponseWriter, r *http.Request) {
+	t := r.URL.Query().Get(TokenParam)
+	req, err := h.Verify(t)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if h.preferencePage != "" {
+		separator := "?"
+		if strings.Contains(h.preferencePage, "?") {
+			separator = "&"
+		}
+		http.Redirect(w, r, h.preferencePage+separator+TokenParam+"="+url.QueryEscape(t), http.StatusSeeOther)
+		return
+	}
+
+	data := PreferenceCentre{Email: req.Email, Token: t}
+	for _, id := range req.Lists {
+		var lists []resources.Contactslist
+		err := h.client.Get(&mailjet.Request{Resource: "contactslist", ID: id}, &lists, mailjet.WithContext(r.Context()))
+		var reqErr mailjet.RequestError
+		if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		data.Lists = append(data.Lists, lists...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err = h.page.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// done answers a posted preference centre form.
+func (h *Handler) done(w http.ResponseWriter, r *http.Request) {
+	if h.successURL != "" {
+		http.Redirect(w, r, h.successURL, http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "Your preferences are saved.")
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		http.Error(w, "This unsubscribe link has expired.", http.StatusGone)
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrUnknownList):
+		http.Error(w, "This unsubscribe link is invalid.", http.StatusBadRequest)
+	default:
+		http.Error(w, "Your preferences could not be saved, please try again later.", http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,154 @@
+package unsubscribe_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/fake"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+	"github.com/mailjet/mailjet-apiv3-go/v4/unsubscribe"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func TestHandler(t *testing.T) {
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	client := mailjet.NewMailjetClient("apiKeyPublic", "apiKeyPrivate", srv.URL)
+	lists, err := srv.Seed("contactslist", resources.Contactslist{Name: "News"}, resources.Contactslist{Name: "Offers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	contacts, err := srv.Seed("contact", resources.Contact{Email: "reader@mailjet.com"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for _, id := range lists {
+		if _, err = srv.Seed("listrecipient", resources.Listrecipient{ContactID: contacts[0], ListID: id}); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	if _, err = unsubscribe.NewHandler(client, []byte("secret"), "https://example.com/unsubscribe"); err == nil {
+		t.Fatal("Expected an error for a short secret")
+	}
+	var unsubscribed []unsubscribe.Request
+	h, err := unsubscribe.NewHandler(client, []byte(secret), "https://example.com/unsubscribe",
+		unsubscribe.WithMailto("unsubscribe@example.com"),
+		unsubscribe.WithUnsubscribeHook(func(r unsubscribe.Request) { unsubscribed = append(unsubscribed, r) }),
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var m mailjet.InfoMessagesV31
+	if err = h.SetHeaders(&m, "reader@mailjet.com", lists...); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	header, _ := m.Headers[mailjet.HeaderListUnsubscribe].(string)
+	if !strings.HasPrefix(header, "<mailto:unsubscribe@example.com>, <https://example.com/unsubscribe?token=") ||
+		m.Headers[mailjet.HeaderListUnsubscribePost] != mailjet.ListUnsubscribeOneClick {
+		t.Fatalf("Wrong headers: %v", m.Headers)
+	}
+	link, _ := h.Link("reader@mailjet.com", lists...)
+	u, _ := url.Parse(link)
+
+	// The preference centre lists the lists of the token, without unsubscribing.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unsubscribe?"+u.RawQuery, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "News") || !strings.Contains(w.Body.String(), "Offers") {
+		t.Fatalf("Wrong preference centre: %d %s", w.Code, w.Body)
+	}
+	if len(unsubscribed) != 0 {
+		t.Fatalf("Unsubscribed on GET: %+v", unsubscribed)
+	}
+
+	// The form unsubscribes from the lists picked.
+	form := url.Values{unsubscribe.TokenParam: {u.Query().Get(unsubscribe.TokenParam)}, unsubscribe.ListParam: {strconv.FormatInt(lists[1], 10)}}
+	req := httptest.NewRequest(http.MethodPost, "/unsubscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(unsubscribed) != 1 || len(unsubscribed[0].Lists) != 1 || unsubscribed[0].Lists[0] != lists[1] {
+		t.Fatalf("Wrong form response: %d %s %+v", w.Code, w.Body, unsubscribed)
+	}
+	status, err := client.SubscriptionStatus(context.Background(), "reader@mailjet.com")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := status.Unsubscribed(); len(got) != 1 || got[0].ListID != lists[1] || got[0].UnsubscribedAt == nil {
+		t.Errorf("Wrong unsubscriptions: %+v", got)
+	}
+
+	// One-click unsubscribes from all the lists of the token, with a multipart/form-data body
+	// as RFC 8058 recommends.
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err = mw.WriteField("List-Unsubscribe", "One-Click"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err = mw.Close(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/unsubscribe?"+u.RawQuery, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(unsubscribed) != 2 || len(unsubscribed[1].Lists) != 2 {
+		t.Fatalf("Wrong one-click response: %d %s %+v", w.Code, w.Body, unsubscribed)
+	}
+	if status, err = client.SubscriptionStatus(context.Background(), "reader@mailjet.com"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := status.Unsubscribed(); len(got) != 2 {
+		t.Errorf("Wrong unsubscriptions: %+v", got)
+	}
+
+	// A urlencoded one-click body is accepted as well.
+	req = httptest.NewRequest(http.MethodPost, "/unsubscribe?"+u.RawQuery, strings.NewReader(mailjet.ListUnsubscribeOneClick))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(unsubscribed) != 3 {
+		t.Fatalf("Wrong urlencoded one-click response: %d %s %+v", w.Code, w.Body, unsubscribed)
+	}
+
+	// Lists outside of the token are refused.
+	form.Set(unsubscribe.ListParam, "999")
+	req = httptest.NewRequest(http.MethodPost, "/unsubscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Wrong status of an unknown list: %d", w.Code)
+	}
+}
+
+func TestHandlerExpired(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	h, err := unsubscribe.NewHandler(nil, []byte(secret), "https://example.com/unsubscribe",
+		unsubscribe.WithTTL(time.Hour), unsubscribe.WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	link, err := h.Link("reader@mailjet.com", 1)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	u, _ := url.Parse(link)
+	now = now.Add(2 * time.Hour)
+	if _, err = h.Verify(u.Query().Get(unsubscribe.TokenParam)); err != unsubscribe.ErrExpiredToken {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+	if _, err = h.Verify("x" + u.Query().Get(unsubscribe.TokenParam)); err != unsubscribe.ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package mailjet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+// Default limits of the jobs of UpsertContacts.
+const (
+	// DefaultUpsertBatchSize is the maximum number of contacts of a job.
+	DefaultUpsertBatchSize = 10000
+	// DefaultUpsertBatchBytes is the maximum size of the contacts of a job, in JSON.
+	DefaultUpsertBatchBytes = 8 << 20
+	// DefaultUpsertConcurrency is the number of jobs run at the same time.
+	DefaultUpsertConcurrency = 4
+)
+
+// ContactIterator returns the contacts to upsert. Next returns io.EOF after the last contact.
+type ContactIterator interface {
+	Next() (resources.AddContactAction, error)
+}
+
+type contactSlice struct {
+	contacts []resources.AddContactAction
+}
+
+func (s *contactSlice) Next() (resources.AddContactAction, error) {
+	if len(s.contacts) == 0 {
+		return resources.AddContactAction{}, io.EOF
+	}
+	contact := s.contacts[0]
+	s.contacts = s.contacts[1:]
+	return contact, nil
+}
+
+// ContactSlice returns a ContactIterator over contacts.
+func ContactSlice(contacts []resources.AddContactAction) ContactIterator {
+	return &contactSlice{contacts: contacts}
+}
+
+// UpsertOptions are functional options of UpsertContacts.
+type UpsertOptions func(*upsert)
+
+type upsert struct {
+	batchSize   int
+	batchBytes  int
+	concurrency int
+	jobOptions  []JobOptions
+}
+
+// WithUpsertBatchSize sets the maximum number of contacts of a job, DefaultUpsertBatchSize by default.
+func WithUpsertBatchSize(size int) UpsertOptions {
+	return func(u *upsert) {
+		u.batchSize = size
+	}
+}
+
+// WithUpsertBatchBytes sets the maximum size in JSON of the contacts of a job, DefaultUpsertBatchBytes by default.
+func WithUpsertBatchBytes(size int) UpsertOptions {
+	return func(u *upsert) {
+		u.batchBytes = size
+	}
+}
+
+// WithUpsertConcurrency sets the number of jobs run at the same time, DefaultUpsertConcurrency by default.
+func WithUpsertConcurrency(n int) UpsertOptions {
+	return func(u *upsert) {
+		u.concurrency = n
+	}
+}
+
+// WithUpsertJobOptions sets the options of the wait of each job, e.g. WithJobBackoff.
+func WithUpsertJobOptions(options ...JobOptions) UpsertOptions {
+	return func(u *upsert) {
+		u.jobOptions = options
+	}
+}
+
+// ContactFailure is a contact which could not be upserted.
+type ContactFailure struct {
+	Email string
+	Error string
+}
+
+// UpsertJob is a managemanycontacts job of UpsertContacts.
+type UpsertJob struct {
+	JobID    int64
+	Contacts int
+	Job      resources.Contactsjob
+	// Err is the error of the job, a *JobError when it failed or has contacts in error.
+	Err      error
+	Failures []ContactFailure
+}
+
+// UpsertReport is the result of UpsertContacts.
+type UpsertReport struct {
+	// Contacts is the number of contacts sent, and Succeeded the number of them upserted.
+	Contacts  int
+	Succeeded int
+	Failures  []ContactFailure
+	Jobs      []UpsertJob
+}
+
+// batch is a chunk of contacts sent in a job.
+type batch struct {
+	index    int
+	contacts []resources.AddContactAction
+}
+
+// UpsertContacts creates or updates contacts and applies the list actions to them. The
+// contacts are sent in managemanycontacts jobs of at most DefaultUpsertBatchSize contacts,
+// run DefaultUpsertConcurrency at a time, and each job is polled until it is done.
+// The report lists the jobs and the contacts in error, as given by their error files.
+// It returns an error when contacts can't be read or a job can't be started or polled,
+// along with the report of the jobs run.
+func (c *Client) UpsertContacts(ctx context.Context, lists []resources.ContactsListAction, contacts ContactIterator, options ...UpsertOptions) (*UpsertReport, error) {
+	u := &upsert{batchSize: DefaultUpsertBatchSize, batchBytes: DefaultUpsertBatchBytes, concurrency: DefaultUpsertConcurrency}
+	for _, option := range options {
+		option(u)
+	}
+	if u.concurrency < 1 {
+		u.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var jobs []UpsertJob
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	batches := make(chan batch)
+	var wg sync.WaitGroup
+	for i := 0; i < u.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				job := c.runUpsertJob(ctx, lists, b.contacts, u.jobOptions)
+				job.Failures = jobFailures(job, b.contacts)
+				var jobErr *JobError
+				if job.Err != nil && !errors.As(job.Err, &jobErr) {
+					fail(job.Err)
+				}
+				mu.Lock()
+				for len(jobs) <= b.index {
+					jobs = append(jobs, UpsertJob{})
+				}
+				jobs[b.index] = job
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := u.split(ctx, contacts, batches)
+	close(batches)
+	wg.Wait()
+	if err != nil {
+		fail(err)
+	}
+
+	report := &UpsertReport{Jobs: jobs}
+	for _, job := range jobs {
+		report.Contacts += job.Contacts
+		report.Failures = append(report.Failures, job.Failures...)
+		report.Succeeded += job.Contacts - len(job.Failures)
+	}
+	return report, firstErr
+}
+
+// split reads the contacts into batches until the iterator or ctx is done.
+func (u *upsert) split(ctx context.Context, contacts ContactIterator, batches chan<- batch) error {
+	b := batch{}
+	size := 0
+	send := func() error {
+		select {
+		case batches <- b:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		b = batch{index: b.index + 1}
+		size = 0
+		return nil
+	}
+
+	for {
+		contact, err := contacts.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(contact)
+		if err != nil {
+			return err
+		}
+		if len(b.contacts) > 0 && (len(b.contacts) >= u.batchSize || size+len(encoded) > u.batchBytes) {
+			if err = send(); err != nil {
+				return err
+			}
+		}
+		b.contacts = append(b.contacts, contact)
+		size += len(encoded) + 1
+	}
+	if len(b.contacts) > 0 {
+		return send()
+	}
+	return nil
+}
+
+// runUpsertJob runs a managemanycontacts job of contacts.
+func (c *Client) runUpsertJob(ctx context.Context, lists []resources.ContactsListAction, contacts []resources.AddContactAction, options []JobOptions) UpsertJob {
+	job := UpsertJob{Contacts: len(contacts)}
+	action := &Request{Resource: "contact", Action: ActionManageManyContacts}
+	job.JobID, job.Err = c.StartJob(ctx, &FullRequest{
+		Info:    action,
+		Payload: resources.ContactManagemanycontacts{ContactsLists: lists, Contacts: contacts},
+	})
+	if job.Err != nil {
+		return job
+	}
+	var state *resources.Contactsjob
+	state, job.Err = c.WaitJob(ctx, action, job.JobID, options...)
+	if state != nil {
+		job.Job = *state
+	}
+	return job
+}
+
+// jobFailures returns the contacts in error of a job, from its error file. All the contacts
+// of the job are in error when it has no readable error file or couldn't be run.
+func jobFailures(job UpsertJob, contacts []resources.AddContactAction) []ContactFailure {
+	if job.Err == nil {
+		return nil
+	}
+	var jobErr *JobError
+	if errors.As(job.Err, &jobErr) {
+		if failures := parseErrorFile(jobErr.ErrorFile); len(failures) > 0 {
+			return failures
+		}
+	}
+	failures := make([]ContactFailure, 0, len(contacts))
+	for _, contact := range contacts {
+		failures = append(failures, ContactFailure{Email: contact.Email, Error: job.Err.Error()})
+	}
+	return failures
+}
+
+// parseErrorFile returns the contacts of a CSV error file, with email and error columns.
+func parseErrorFile(file []byte) []ContactFailure {
+	records, err := csv.NewReader(bytes.NewReader(file)).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil
+	}
+	emailCol, errorCol := -1, -1
+	for i, name := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "email":
+			emailCol = i
+		case "error":
+			errorCol = i
+		}
+	}
+	if emailCol < 0 {
+		return nil
+	}
+	failures := make([]ContactFailure, 0, len(records)-1)
+	for _, record := range records[1:] {
+		failure := ContactFailure{}
+		if emailCol < len(record) {
+			failure.Email = record[emailCol]
+		}
+		if errorCol >= 0 && errorCol < len(record) {
+			failure.Error = record[errorCol]
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
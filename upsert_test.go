@@ -0,0 +1,79 @@
+package mailjet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/mailjet/mailjet-apiv3-go/v4/resources"
+)
+
+type failingIterator struct {
+	contacts mailjet.ContactIterator
+	err      error
+}
+
+func (it failingIterator) Next() (resources.AddContactAction, error) {
+	contact, err := it.contacts.Next()
+	if err != nil {
+		return contact, it.err
+	}
+	return contact, nil
+}
+
+func TestUpsertContacts(t *testing.T) {
+	srv, client := newFakeClient(t)
+	ids, err := srv.Seed("contactslist", resources.Contactslist{Name: "Passengers"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var contacts []resources.AddContactAction
+	for i := 1; i <= 4; i++ {
+		contacts = append(contacts, resources.AddContactAction{Email: fmt.Sprintf("passenger%d@mailjet.com", i)})
+	}
+	contacts = append(contacts, resources.AddContactAction{Email: "not an address"})
+	lists := []resources.ContactsListAction{{ListID: ids[0], Action: mailjet.ImportAddForce}}
+
+	report, err := client.UpsertContacts(context.Background(), lists, mailjet.ContactSlice(contacts),
+		mailjet.WithUpsertBatchSize(2), mailjet.WithUpsertConcurrency(2),
+		mailjet.WithUpsertJobOptions(mailjet.WithJobBackoff(fastBackoff)))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(report.Jobs) != 3 || report.Contacts != 5 || report.Succeeded != 4 {
+		t.Fatalf("Wrong report: %+v", report)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Email != "not an address" {
+		t.Errorf("Wrong failures: %+v", report.Failures)
+	}
+	var jobErr *mailjet.JobError
+	if !errors.As(report.Jobs[2].Err, &jobErr) {
+		t.Errorf("Expected a JobError for the last job, got %v", report.Jobs[2].Err)
+	}
+
+	var recipients []resources.Listrecipient
+	count, _, err := client.List("listrecipient", &recipients, mailjet.ListrecipientFilters.ContactsList(ids[0]))
+	if err != nil || count != 4 {
+		t.Fatalf("Wrong recipients: %d (%v)", count, err)
+	}
+}
+
+func TestUpsertContactsIteratorError(t *testing.T) {
+	_, client := newFakeClient(t)
+	iterErr := errors.New("CRM unavailable")
+	contacts := failingIterator{
+		contacts: mailjet.ContactSlice([]resources.AddContactAction{{Email: "passenger1@mailjet.com"}}),
+		err:      iterErr,
+	}
+	report, err := client.UpsertContacts(context.Background(), nil, contacts,
+		mailjet.WithUpsertBatchSize(1), mailjet.WithUpsertJobOptions(mailjet.WithJobBackoff(fastBackoff)))
+	if !errors.Is(err, iterErr) {
+		t.Fatalf("Expected the error of the iterator, got %v", err)
+	}
+	if report == nil || report.Contacts > 1 {
+		t.Errorf("Wrong report: %+v", report)
+	}
+}